@@ -1,9 +1,12 @@
 package main
 
 import (
+	"context"
 	"log"
 	"os"
 	"os/signal"
+	"runtime"
+	"sync"
 	"syscall"
 	"time"
 
@@ -11,17 +14,25 @@ import (
 	"github.com/mniyk/endpoint-security-and-monitoring-tools/internal/transmission"
 	"github.com/mniyk/endpoint-security-and-monitoring-tools/internal/userinfo"
 	"github.com/mniyk/endpoint-security-and-monitoring-tools/module"
-	"github.com/mniyk/endpoint-security-and-monitoring-tools/module/bluetooth"
-	"github.com/mniyk/endpoint-security-and-monitoring-tools/module/printer"
-	"github.com/mniyk/endpoint-security-and-monitoring-tools/module/usb"
+
+	// 各モジュールはinitでレジストリに登録される
+	_ "github.com/mniyk/endpoint-security-and-monitoring-tools/module/bluetooth"
+	_ "github.com/mniyk/endpoint-security-and-monitoring-tools/module/cloudsync"
+	_ "github.com/mniyk/endpoint-security-and-monitoring-tools/module/mtp"
+	_ "github.com/mniyk/endpoint-security-and-monitoring-tools/module/netshare"
+	_ "github.com/mniyk/endpoint-security-and-monitoring-tools/module/optical"
+	_ "github.com/mniyk/endpoint-security-and-monitoring-tools/module/printer"
+	_ "github.com/mniyk/endpoint-security-and-monitoring-tools/module/usb"
 )
 
 func main() {
 	// シグナルを受信するチャネルを作成
 	sigChan := make(chan os.Signal, 1)
+	reloadChan := make(chan os.Signal, 1)
 
-	// 終了シグナルを登録
+	// 終了シグナルと設定の再読み込みのシグナルを登録
 	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+	signal.Notify(reloadChan, syscall.SIGHUP)
 
 	// アプリケーションの開始
 	log.Println("[Main] Start security monitoring...")
@@ -39,7 +50,7 @@ func main() {
 	eventDispatcher := transmission.NewEventSender(5)
 
 	// モジュールの管理
-	manager := module.NewManager(cfg)
+	manager := module.NewManager(cfg, userInfo, eventDispatcher)
 	registerModules(manager, cfg, userInfo, eventDispatcher)
 
 	// すべてのモジュールを初期化
@@ -50,41 +61,66 @@ func main() {
 		}
 	}
 
+	// モジュールのライフサイクルを管理するコンテキストを作成
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
 	// すべてのモジュールを開始
-	startErrors := manager.StartAllModules()
+	startErrors := manager.StartAllModules(ctx)
 	if len(startErrors) > 0 {
 		for name, err := range startErrors {
 			log.Fatalf("[Main] Failed start module (%s): %v", name, err)
 		}
 	}
 
+	var flushWg sync.WaitGroup
+	flushWg.Add(1)
 	go func() {
+		defer flushWg.Done()
+
 		ticker := time.NewTicker(5 * time.Second)
 		defer ticker.Stop()
 
-		for range ticker.C {
-			if eventDispatcher.IsOverBatchSize() || eventDispatcher.IsOverTime() {
-				// 保留中のイベントを送信
-				eventDispatcher.Flush()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if eventDispatcher.IsOverBatchSize() || eventDispatcher.IsOverTime() {
+					// 保留中のイベントを送信
+					eventDispatcher.Flush()
+				}
 			}
 		}
 	}()
 
-	// シグナルを待機（無限ループを削除）
-	sig := <-sigChan
+	// シグナルを待機（SIGHUPの場合は設定を読み込み直してモジュールの有効/無効を反映）
+	var sig os.Signal
+	for sig == nil {
+		select {
+		case sig = <-sigChan:
+		case <-reloadChan:
+			reloadConfig(manager)
+		}
+	}
 	log.Printf("[Main] Received termination signal: %v", sig)
 
 	// クリーンアップ処理
 	log.Println("[Main] Start cleanup...")
 
-	// すべてのモジュールを停止
-	stopErrors := manager.StopAllModules()
-	if len(stopErrors) > 0 {
-		for name, err := range stopErrors {
-			log.Fatalf("[Main] Failed stop module (%s): %v", name, err)
-		}
+	// 停止期限内ですべてのモジュールを並行して停止
+	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), time.Duration(cfg.Agent.ShutdownTimeout))
+	defer shutdownCancel()
+
+	stopErrors := manager.StopAllModules(shutdownCtx)
+	for name, err := range stopErrors {
+		log.Printf("[Main] Failed stop module (%s): %v", name, err)
 	}
 
+	// 定期送信のゴルーチンを終了
+	cancel()
+	flushWg.Wait()
+
 	// 強制的にイベントキューをフラッシュ
 	eventDispatcher.Flush()
 
@@ -93,25 +129,56 @@ func main() {
 
 // モジュールを登録
 func registerModules(manager *module.Manager, cfg *config.Configs, userInfo *userinfo.UserInfo, eventDispatcher transmission.EventDispatcher) {
-	for name := range cfg.Modules {
-		var moduleInstance module.Module
-
-		switch name {
-		case "usb_file_transfer_monitoring":
-			config := usb.NewMonitorConfig(cfg.Modules[name])
-			moduleInstance = usb.NewMonitor(config, userInfo, eventDispatcher)
-		case "printer_transfer_monitoring":
-			config := printer.NewMonitorConfig(cfg.Modules[name])
-			moduleInstance = printer.NewMonitor(config, userInfo, eventDispatcher)
-		case "bluetooth_file_transfer_monitoring":
-			config := bluetooth.NewMonitorConfig(cfg.Modules[name])
-			moduleInstance = bluetooth.NewMonitor(config, userInfo, eventDispatcher)
+	deps := module.Dependencies{
+		UserInfo:  userInfo,
+		EventSink: eventDispatcher,
+	}
+
+	for name, moduleConfig := range cfg.Modules {
+		descriptor, err := module.Lookup(name)
+		if err != nil {
+			log.Fatalf("[Main] Failed %s registration: %v", name, err)
 		}
 
-		if moduleInstance != nil {
-			if err := manager.RegisterModule(name, moduleInstance); err != nil {
-				log.Fatalf("[Main] Failed %s registration: %v", name, err)
+		// 共通の設定ファイルを各OSで使えるよう、有効でも対応していないモジュールは警告して除外
+		if !descriptor.SupportsPlatform(runtime.GOOS) {
+			if moduleConfig.Enabled {
+				log.Printf("[Main] Warning: %s is enabled but not supported on %s, skipping", name, runtime.GOOS)
+			} else {
+				log.Printf("[Main] Skip %s: not supported on %s", name, runtime.GOOS)
 			}
+			continue
 		}
+
+		if err := descriptor.ValidateOptions(moduleConfig.Options); err != nil {
+			log.Fatalf("[Main] Failed %s registration: %v", name, err)
+		}
+
+		moduleInstance, err := descriptor.Factory(moduleConfig, deps)
+		if err != nil {
+			log.Fatalf("[Main] Failed %s registration: %v", name, err)
+		}
+
+		if err := manager.RegisterModule(name, moduleInstance); err != nil {
+			log.Fatalf("[Main] Failed %s registration: %v", name, err)
+		}
+	}
+}
+
+// config.jsonを読み込み直してモジュールの有効/無効を反映
+func reloadConfig(manager *module.Manager) {
+	log.Println("[Main] Reloading config.json...")
+
+	cfg, err := config.LoadConfig("config.json")
+	if err != nil {
+		log.Printf("[Main] Failed reload config.json: %v", err)
+		return
+	}
+
+	stopCtx, cancel := context.WithTimeout(context.Background(), time.Duration(cfg.Agent.ShutdownTimeout))
+	defer cancel()
+
+	for name, err := range manager.ApplyConfig(stopCtx, cfg) {
+		log.Printf("[Main] Failed apply config to module (%s): %v", name, err)
 	}
 }
@@ -2,9 +2,68 @@ package config
 
 import (
 	"encoding/json"
+	"fmt"
 	"os"
+	"time"
 )
 
+const (
+	DEFAULT_SHUTDOWN_TIMEOUT = 10 * time.Second
+	DEFAULT_MAX_RESTARTS     = 5
+	DEFAULT_RESTART_WINDOW   = 10 * time.Minute
+	DEFAULT_INITIAL_BACKOFF  = 1 * time.Second
+	DEFAULT_MAX_BACKOFF      = 1 * time.Minute
+	DEFAULT_STALL_TIMEOUT    = 2 * time.Minute
+	DEFAULT_HEARTBEAT        = 1 * time.Minute
+)
+
+// JSONで"10s"のような文字列として指定する時間の型
+type Duration time.Duration
+
+// JSONの文字列または秒数からDurationを読み込み
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var value interface{}
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+
+	switch v := value.(type) {
+	case string:
+		parsed, err := time.ParseDuration(v)
+		if err != nil {
+			return err
+		}
+		*d = Duration(parsed)
+	case float64:
+		*d = Duration(v * float64(time.Second))
+	default:
+		return fmt.Errorf("invalid duration: %s", string(data))
+	}
+
+	return nil
+}
+
+// DurationをJSONの文字列に変換
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+// モジュールの監視と再起動の設定の構造体
+type SupervisorConfig struct {
+	MaxRestarts    int      `json:"max_restarts"`    // RestartWindow内で許可する再起動回数
+	RestartWindow  Duration `json:"restart_window"`  // 再起動回数を数える期間
+	InitialBackoff Duration `json:"initial_backoff"` // 最初の再起動までの待機時間
+	MaxBackoff     Duration `json:"max_backoff"`     // 再起動までの待機時間の上限
+	StallTimeout   Duration `json:"stall_timeout"`   // ハートビートが途絶えたと判断するまでの時間
+}
+
+// エージェント全体の設定の構造体
+type AgentConfig struct {
+	ShutdownTimeout   Duration         `json:"shutdown_timeout"`
+	HeartbeatInterval Duration         `json:"heartbeat_interval"`
+	Supervisor        SupervisorConfig `json:"supervisor"`
+}
+
 // モジュール設定の構造体
 type Config struct {
 	Enabled bool                   `json:"enabled"`
@@ -13,6 +72,7 @@ type Config struct {
 
 // ConfigのJSONの構造体
 type Configs struct {
+	Agent   AgentConfig       `json:"agent"`
 	Modules map[string]Config `json:"modules"`
 }
 
@@ -28,5 +88,98 @@ func LoadConfig(path string) (*Configs, error) {
 		return nil, err
 	}
 
+	configs.applyDefaults()
+
 	return &configs, nil
 }
+
+// 未設定の項目にデフォルト値を設定
+func (c *Configs) applyDefaults() {
+	if c.Agent.ShutdownTimeout <= 0 {
+		c.Agent.ShutdownTimeout = Duration(DEFAULT_SHUTDOWN_TIMEOUT)
+	}
+	if c.Agent.HeartbeatInterval <= 0 {
+		c.Agent.HeartbeatInterval = Duration(DEFAULT_HEARTBEAT)
+	}
+
+	supervisor := &c.Agent.Supervisor
+	if supervisor.MaxRestarts <= 0 {
+		supervisor.MaxRestarts = DEFAULT_MAX_RESTARTS
+	}
+	if supervisor.RestartWindow <= 0 {
+		supervisor.RestartWindow = Duration(DEFAULT_RESTART_WINDOW)
+	}
+	if supervisor.InitialBackoff <= 0 {
+		supervisor.InitialBackoff = Duration(DEFAULT_INITIAL_BACKOFF)
+	}
+	if supervisor.MaxBackoff <= 0 {
+		supervisor.MaxBackoff = Duration(DEFAULT_MAX_BACKOFF)
+	}
+	if supervisor.StallTimeout <= 0 {
+		supervisor.StallTimeout = Duration(DEFAULT_STALL_TIMEOUT)
+	}
+}
+
+// 真偽値のオプションを取得（未設定または型が異なる場合はデフォルト値）
+func (c Config) Bool(key string, defaultValue bool) bool {
+	if value, ok := c.Options[key].(bool); ok {
+		return value
+	}
+
+	return defaultValue
+}
+
+// 文字列のオプションを取得（未設定または型が異なる場合はデフォルト値）
+func (c Config) String(key string, defaultValue string) string {
+	if value, ok := c.Options[key].(string); ok {
+		return value
+	}
+
+	return defaultValue
+}
+
+// 数値のオプションを整数で取得（未設定または型が異なる場合はデフォルト値）
+func (c Config) Int(key string, defaultValue int) int {
+	if value, ok := c.Options[key].(float64); ok {
+		return int(value)
+	}
+
+	return defaultValue
+}
+
+// 文字列の配列のオプションを取得（未設定または型が異なる場合はデフォルト値）
+func (c Config) Strings(key string, defaultValue []string) []string {
+	values, ok := c.Options[key].([]interface{})
+	if !ok {
+		return defaultValue
+	}
+
+	result := make([]string, 0, len(values))
+	for _, value := range values {
+		s, ok := value.(string)
+		if !ok {
+			return defaultValue
+		}
+		result = append(result, s)
+	}
+
+	return result
+}
+
+// オブジェクトのオプションを構造体に読み込み（未設定の場合はtargetを変更しない）
+func (c Config) Decode(key string, target interface{}) error {
+	value, ok := c.Options[key]
+	if !ok {
+		return nil
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, target); err != nil {
+		return fmt.Errorf("invalid option %s: %w", key, err)
+	}
+
+	return nil
+}
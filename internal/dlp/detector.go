@@ -0,0 +1,242 @@
+package dlp
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// 連続した数字、または空白かハイフンで区切られた数字のグループ
+var (
+	creditCardPattern = regexp.MustCompile(`\b(?:\d{13,19}|\d{4}[ -]\d{4}[ -]\d{4}[ -]\d{1,7}|\d{4}[ -]\d{6}[ -]\d{4,5})\b`)
+	myNumberPattern   = regexp.MustCompile(`\b(?:\d{12}|\d{4}[ -]\d{4}[ -]\d{4})\b`)
+	ssnPattern        = regexp.MustCompile(`\b(\d{3})-(\d{2})-(\d{4})\b`)
+)
+
+// 正規表現に一致した件数を数える検出器
+type RegexDetector struct {
+	name    string
+	pattern *regexp.Regexp
+}
+
+// 新しいRegexDetectorを作成
+func NewRegexDetector(name string, pattern string) (*RegexDetector, error) {
+	if err := validateName(name); err != nil {
+		return nil, err
+	}
+
+	compiled, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("dlp: invalid pattern for %s: %w", name, err)
+	}
+
+	return &RegexDetector{name: name, pattern: compiled}, nil
+}
+
+// 検出器の名前を取得
+func (d *RegexDetector) Name() string {
+	return d.name
+}
+
+// 一致した件数を数える
+func (d *RegexDetector) Count(text string) int {
+	return len(d.pattern.FindAllStringIndex(text, -1))
+}
+
+// 辞書のキーワードが出現した件数を数える検出器（大文字と小文字を区別しない）
+type KeywordDetector struct {
+	name  string
+	words []string
+}
+
+// 新しいKeywordDetectorを作成
+func NewKeywordDetector(name string, words []string) (*KeywordDetector, error) {
+	if err := validateName(name); err != nil {
+		return nil, err
+	}
+
+	var lowered []string
+	for _, word := range words {
+		if word != "" {
+			lowered = append(lowered, strings.ToLower(word))
+		}
+	}
+	if len(lowered) == 0 {
+		return nil, fmt.Errorf("dlp: keyword detector %s has no words", name)
+	}
+
+	return &KeywordDetector{name: name, words: lowered}, nil
+}
+
+// 検出器の名前を取得
+func (d *KeywordDetector) Name() string {
+	return d.name
+}
+
+// キーワードの出現件数を数える
+func (d *KeywordDetector) Count(text string) int {
+	lowered := strings.ToLower(text)
+
+	count := 0
+	for _, word := range d.words {
+		count += strings.Count(lowered, word)
+	}
+
+	return count
+}
+
+// Luhnチェックを満たすクレジットカード番号を数える検出器
+type CreditCardDetector struct{}
+
+// 新しいCreditCardDetectorを作成
+func NewCreditCardDetector() *CreditCardDetector {
+	return &CreditCardDetector{}
+}
+
+// 検出器の名前を取得
+func (d *CreditCardDetector) Name() string {
+	return "credit_card"
+}
+
+// 13〜19桁でLuhnチェックを満たす番号を数える
+func (d *CreditCardDetector) Count(text string) int {
+	count := 0
+	for _, candidate := range creditCardPattern.FindAllString(text, -1) {
+		digits := stripSeparators(candidate)
+		if len(digits) >= 13 && len(digits) <= 19 && luhnValid(digits) {
+			count++
+		}
+	}
+
+	return count
+}
+
+// Luhnアルゴリズムでチェックディジットを検証
+func luhnValid(digits string) bool {
+	sum := 0
+	double := false
+	for i := len(digits) - 1; i >= 0; i-- {
+		n := int(digits[i] - '0')
+		if double {
+			n *= 2
+			if n > 9 {
+				n -= 9
+			}
+		}
+		sum += n
+		double = !double
+	}
+
+	return sum%10 == 0
+}
+
+// 国民識別番号の書式を数える検出器
+type NationalIDDetector struct {
+	name  string
+	count func(text string) int
+}
+
+// 新しいNationalIDDetectorを作成（"jp_my_number" "us_ssn"）
+func NewNationalIDDetector(name string) (*NationalIDDetector, error) {
+	switch name {
+	case "jp_my_number":
+		return &NationalIDDetector{name: name, count: countMyNumbers}, nil
+	case "us_ssn":
+		return &NationalIDDetector{name: name, count: countSSNs}, nil
+	default:
+		return nil, fmt.Errorf("dlp: unknown national id format %s", name)
+	}
+}
+
+// 検出器の名前を取得
+func (d *NationalIDDetector) Name() string {
+	return d.name
+}
+
+// 書式と検査を満たす番号を数える
+func (d *NationalIDDetector) Count(text string) int {
+	return d.count(text)
+}
+
+// チェックディジットを満たすマイナンバー（個人番号12桁）を数える
+func countMyNumbers(text string) int {
+	count := 0
+	for _, candidate := range findIsolated(myNumberPattern, text) {
+		if myNumberValid(stripSeparators(candidate)) {
+			count++
+		}
+	}
+
+	return count
+}
+
+// マイナンバーのチェックディジットを検証
+func myNumberValid(digits string) bool {
+	if len(digits) != 12 {
+		return false
+	}
+
+	// 右から n 桁目（チェックディジットを除く）に重み n+1（n≦6）または n-5（n≧7）を掛ける
+	sum := 0
+	for n := 1; n <= 11; n++ {
+		p := int(digits[11-n] - '0')
+		q := n + 1
+		if n >= 7 {
+			q = n - 5
+		}
+		sum += p * q
+	}
+
+	check := 0
+	if remainder := sum % 11; remainder > 1 {
+		check = 11 - remainder
+	}
+
+	return check == int(digits[11]-'0')
+}
+
+// 発行されない範囲を除いた米国の社会保障番号（123-45-6789）を数える
+func countSSNs(text string) int {
+	count := 0
+	for _, match := range ssnPattern.FindAllStringSubmatch(text, -1) {
+		area, group, serial := match[1], match[2], match[3]
+		if area == "000" || area == "666" || area[0] == '9' || group == "00" || serial == "0000" {
+			continue
+		}
+		count++
+	}
+
+	return count
+}
+
+// 前後に区切り文字を挟んで数字が続かない一致のみを取得（カード番号などの一部を誤検出しないため）
+func findIsolated(pattern *regexp.Regexp, text string) []string {
+	var matches []string
+	for _, index := range pattern.FindAllStringIndex(text, -1) {
+		start, end := index[0], index[1]
+		if start >= 2 && isSeparator(text[start-1]) && isDigit(text[start-2]) {
+			continue
+		}
+		if end+1 < len(text) && isSeparator(text[end]) && isDigit(text[end+1]) {
+			continue
+		}
+		matches = append(matches, text[start:end])
+	}
+
+	return matches
+}
+
+// 数字かどうかを確認
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+// 番号の区切り文字かどうかを確認
+func isSeparator(c byte) bool {
+	return c == ' ' || c == '-'
+}
+
+// 空白とハイフンを取り除く
+func stripSeparators(value string) string {
+	return strings.NewReplacer(" ", "", "-", "").Replace(value)
+}
@@ -0,0 +1,113 @@
+package dlp
+
+import "testing"
+
+func TestLuhnValid(t *testing.T) {
+	tests := []struct {
+		digits string
+		want   bool
+	}{
+		{"4111111111111111", true},
+		{"378282246310005", true},
+		{"5555555555554444", true},
+		{"4111111111111112", false},
+		{"1234567812345678", false},
+	}
+
+	for _, tt := range tests {
+		if got := luhnValid(tt.digits); got != tt.want {
+			t.Errorf("luhnValid(%s) = %v, want %v", tt.digits, got, tt.want)
+		}
+	}
+}
+
+func TestMyNumberValid(t *testing.T) {
+	tests := []struct {
+		digits string
+		want   bool
+	}{
+		{"123456789018", true},
+		{"987654321018", true},
+		{"123456789012", false},
+		{"12345678901", false},
+		{"1234567890180", false},
+	}
+
+	for _, tt := range tests {
+		if got := myNumberValid(tt.digits); got != tt.want {
+			t.Errorf("myNumberValid(%s) = %v, want %v", tt.digits, got, tt.want)
+		}
+	}
+}
+
+func TestDetectorCount(t *testing.T) {
+	creditCard := NewCreditCardDetector()
+	myNumber, err := NewNationalIDDetector("jp_my_number")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ssn, err := NewNationalIDDetector("us_ssn")
+	if err != nil {
+		t.Fatal(err)
+	}
+	employeeID, err := NewRegexDetector("employee_id", `\bEMP-\d{6}\b`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keywords, err := NewKeywordDetector("confidential", []string{"Confidential", "", "社外秘"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		detector Detector
+		text     string
+		want     int
+	}{
+		{"card plain", creditCard, "card 4111111111111111", 1},
+		{"card separated", creditCard, "4111 1111 1111 1111 and 3782-822463-10005", 2},
+		{"card failing luhn", creditCard, "4111 1111 1111 1112", 0},
+		{"card too short", creditCard, "411111111111", 0},
+
+		{"my number", myNumber, "個人番号 1234 5678 9018", 1},
+		{"my number bad check digit", myNumber, "1234-5678-9012", 0},
+		{"my number inside card number", myNumber, "1234 5678 9018 1234", 0},
+
+		{"ssn", ssn, "SSN 123-45-6789", 1},
+		{"ssn area 000", ssn, "000-45-6789", 0},
+		{"ssn area 666", ssn, "666-45-6789", 0},
+		{"ssn area 9xx", ssn, "912-45-6789", 0},
+		{"ssn group 00", ssn, "123-00-6789", 0},
+		{"ssn serial 0000", ssn, "123-45-0000", 0},
+		{"ssn without hyphens", ssn, "123456789", 0},
+
+		{"regex", employeeID, "EMP-000123, EMP-999999 and EMP-12", 2},
+		{"regex no match", employeeID, "XEMP-000123", 0},
+
+		{"keywords ignore case", keywords, "CONFIDENTIAL: confidential", 2},
+		{"keywords multibyte", keywords, "【社外秘】", 1},
+		{"keywords no match", keywords, "public", 0},
+	}
+
+	for _, tt := range tests {
+		if got := tt.detector.Count(tt.text); got != tt.want {
+			t.Errorf("%s: %s.Count(%q) = %d, want %d", tt.name, tt.detector.Name(), tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestNewDetectorErrors(t *testing.T) {
+	if _, err := NewRegexDetector("broken", "("); err == nil {
+		t.Error("NewRegexDetector accepted an invalid pattern")
+	}
+	if _, err := NewRegexDetector(" ", `\d`); err == nil {
+		t.Error("NewRegexDetector accepted an empty name")
+	}
+	if _, err := NewKeywordDetector("empty", []string{""}); err == nil {
+		t.Error("NewKeywordDetector accepted no words")
+	}
+	if _, err := NewNationalIDDetector("uk_nino"); err == nil {
+		t.Error("NewNationalIDDetector accepted an unknown format")
+	}
+}
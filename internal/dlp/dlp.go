@@ -0,0 +1,183 @@
+package dlp
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+const (
+	DEFAULT_MAX_SIZE_MB      = 32
+	DEFAULT_MAX_EXTRACTED_MB = 64 // 圧縮を展開して読み込むバイト数の上限（ZIP爆弾対策）
+
+	STATUS_SCANNED     = "scanned"
+	STATUS_TOO_LARGE   = "skipped_too_large"
+	STATUS_UNSUPPORTED = "skipped_unsupported" // テキストを取り出せない形式
+	STATUS_FAILED      = "failed"
+)
+
+// テキスト中の機密情報の件数を数える検出器のインターフェース
+type Detector interface {
+	Name() string
+	Count(text string) int
+}
+
+// 正規表現の検出器の設定の構造体
+type RegexConfig struct {
+	Name    string `json:"name"`
+	Pattern string `json:"pattern"`
+}
+
+// キーワード辞書の検出器の設定の構造体
+type KeywordConfig struct {
+	Name  string   `json:"name"`
+	Words []string `json:"words"`
+}
+
+// DLPの設定の構造体
+type Config struct {
+	Enabled     bool            `json:"enabled"`
+	MaxSizeMB   int             `json:"max_size_mb"`
+	CreditCards bool            `json:"credit_cards"` // Luhnチェックでクレジットカード番号を検出
+	NationalIDs []string        `json:"national_ids"` // "jp_my_number" "us_ssn"
+	Regexes     []RegexConfig   `json:"regexes"`
+	Keywords    []KeywordConfig `json:"keywords"`
+}
+
+// 新しいConfigをデフォルト値で作成
+func NewConfig() Config {
+	return Config{
+		MaxSizeMB:   DEFAULT_MAX_SIZE_MB,
+		CreditCards: true,
+		NationalIDs: []string{"jp_my_number", "us_ssn"},
+	}
+}
+
+// スキャンの結果の構造体（一致した内容は保持しない）
+type Result struct {
+	Status  string
+	Matches map[string]int // 検出器名と一致件数
+}
+
+// 一致した検出器の名前を名前順で取得
+func (r Result) Detectors() []string {
+	names := make([]string, 0, len(r.Matches))
+	for name := range r.Matches {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// ファイルの内容を検出器でスキャンする構造体
+type Scanner struct {
+	detectors    []Detector
+	maxSize      int64
+	maxExtracted int64
+}
+
+// 設定から新しいScannerを作成
+func NewScanner(config Config) (*Scanner, error) {
+	var detectors []Detector
+
+	if config.CreditCards {
+		detectors = append(detectors, NewCreditCardDetector())
+	}
+
+	for _, name := range config.NationalIDs {
+		detector, err := NewNationalIDDetector(name)
+		if err != nil {
+			return nil, err
+		}
+		detectors = append(detectors, detector)
+	}
+
+	for _, regex := range config.Regexes {
+		detector, err := NewRegexDetector(regex.Name, regex.Pattern)
+		if err != nil {
+			return nil, err
+		}
+		detectors = append(detectors, detector)
+	}
+
+	for _, keyword := range config.Keywords {
+		detector, err := NewKeywordDetector(keyword.Name, keyword.Words)
+		if err != nil {
+			return nil, err
+		}
+		detectors = append(detectors, detector)
+	}
+
+	if len(detectors) == 0 {
+		return nil, errors.New("dlp: no detectors configured")
+	}
+
+	// 検出器名は結果のキーになるため重複を許可しない
+	seen := make(map[string]bool)
+	for _, detector := range detectors {
+		if seen[detector.Name()] {
+			return nil, fmt.Errorf("dlp: duplicate detector name %s", detector.Name())
+		}
+		seen[detector.Name()] = true
+	}
+
+	maxSize := config.MaxSizeMB
+	if maxSize <= 0 {
+		maxSize = DEFAULT_MAX_SIZE_MB
+	}
+
+	return &Scanner{
+		detectors:    detectors,
+		maxSize:      int64(maxSize) * 1024 * 1024,
+		maxExtracted: DEFAULT_MAX_EXTRACTED_MB * 1024 * 1024,
+	}, nil
+}
+
+// ファイルからテキストを取り出してスキャン
+func (s *Scanner) ScanFile(path string) (Result, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return Result{Status: STATUS_FAILED}, err
+	}
+	if info.Size() > s.maxSize {
+		return Result{Status: STATUS_TOO_LARGE}, nil
+	}
+
+	text, err := ExtractText(path, s.maxExtracted)
+	if errors.Is(err, ErrUnsupportedFormat) {
+		return Result{Status: STATUS_UNSUPPORTED}, nil
+	}
+	if err != nil {
+		return Result{Status: STATUS_FAILED}, err
+	}
+
+	return s.ScanText(text), nil
+}
+
+// テキストをすべての検出器でスキャン
+func (s *Scanner) ScanText(text string) Result {
+	result := Result{
+		Status:  STATUS_SCANNED,
+		Matches: make(map[string]int),
+	}
+
+	for _, detector := range s.detectors {
+		if count := detector.Count(text); count > 0 {
+			result.Matches[detector.Name()] = count
+		}
+	}
+
+	return result
+}
+
+// 検出器の名前を検証
+func validateName(name string) error {
+	if strings.TrimSpace(name) == "" {
+		return errors.New("dlp: detector name is empty")
+	}
+
+	return nil
+}
@@ -0,0 +1,317 @@
+package dlp
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/zlib"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+)
+
+const TEXT_SNIFF_SIZE = 8 * 1024
+
+// テキストを取り出せない形式の場合のエラー
+var ErrUnsupportedFormat = errors.New("dlp: unsupported file format")
+
+// ZIPコンテナ内でテキストを取り出す対象のファイル
+var (
+	docxParts = []string{"word/document.xml", "word/header*.xml", "word/footer*.xml", "word/footnotes.xml", "word/comments.xml"}
+	xlsxParts = []string{"xl/sharedStrings.xml", "xl/worksheets/sheet*.xml"}
+)
+
+// ファイルの形式に応じてテキストを取り出す（maxSizeは展開後に読み込むバイト数の上限で、超える分は切り捨て）
+func ExtractText(filePath string, maxSize int64) (string, error) {
+	switch strings.ToLower(filepath.Ext(filePath)) {
+	case ".docx", ".docm":
+		return extractZipXML(filePath, docxParts, maxSize)
+	case ".xlsx", ".xlsm":
+		return extractZipXML(filePath, xlsxParts, maxSize)
+	case ".pdf":
+		return extractPDF(filePath, maxSize)
+	default:
+		return extractPlainText(filePath, maxSize)
+	}
+}
+
+// テキストファイルとして読み込み（バイナリの場合はErrUnsupportedFormat）
+func extractPlainText(filePath string, maxSize int64) (string, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(io.LimitReader(file, maxSize))
+	if err != nil {
+		return "", err
+	}
+
+	// 先頭にNULがあるか、UTF-8として不正な場合はバイナリとみなす
+	sniff := data
+	if len(sniff) > TEXT_SNIFF_SIZE {
+		sniff = sniff[:TEXT_SNIFF_SIZE]
+	}
+	if bytes.IndexByte(sniff, 0) >= 0 || !utf8.Valid(trimIncompleteRune(sniff)) {
+		return "", ErrUnsupportedFormat
+	}
+
+	return string(data), nil
+}
+
+// 途中で切れたUTF-8の末尾を取り除く
+func trimIncompleteRune(data []byte) []byte {
+	for i := 0; i < utf8.UTFMax && len(data) > 0; i++ {
+		if utf8.Valid(data) {
+			return data
+		}
+		data = data[:len(data)-1]
+	}
+
+	return data
+}
+
+// Office Open XML（DOCX XLSX）のXMLパーツからテキストを取り出す
+func extractZipXML(filePath string, parts []string, maxSize int64) (string, error) {
+	reader, err := zip.OpenReader(filePath)
+	if err != nil {
+		return "", fmt.Errorf("dlp: failed opening %s: %w", filePath, err)
+	}
+	defer reader.Close()
+
+	var builder strings.Builder
+	remaining := maxSize
+	for _, file := range reader.File {
+		if !matchesAny(file.Name, parts) || remaining <= 0 {
+			continue
+		}
+
+		part, err := file.Open()
+		if err != nil {
+			return "", fmt.Errorf("dlp: failed opening %s in %s: %w", file.Name, filePath, err)
+		}
+		// 取り出したテキストではなく展開して読み込んだXMLのバイト数で上限を数える
+		limited := &io.LimitedReader{R: part, N: remaining}
+		text, err := xmlText(limited)
+		part.Close()
+		if err != nil {
+			return "", fmt.Errorf("dlp: failed parsing %s in %s: %w", file.Name, filePath, err)
+		}
+
+		builder.WriteString(text)
+		builder.WriteByte('\n')
+		remaining = limited.N
+	}
+
+	return builder.String(), nil
+}
+
+// パターンのいずれかに一致するかどうかを確認
+func matchesAny(name string, patterns []string) bool {
+	for _, pattern := range patterns {
+		if matched, _ := path.Match(pattern, name); matched {
+			return true
+		}
+	}
+
+	return false
+}
+
+// XMLの文字データを要素ごとに区切って連結
+func xmlText(r io.Reader) (string, error) {
+	var builder strings.Builder
+
+	decoder := xml.NewDecoder(r)
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF || errors.Is(err, io.ErrUnexpectedEOF) {
+			// 上限で切り捨てた場合もそこまでのテキストを使用
+			break
+		}
+		if err != nil {
+			return "", err
+		}
+
+		switch t := token.(type) {
+		case xml.CharData:
+			builder.Write(t)
+		case xml.EndElement:
+			// 段落（w:p）やセル（c）の区切りで単語が連結されないようにする
+			if t.Name.Local == "p" || t.Name.Local == "c" || t.Name.Local == "si" || t.Name.Local == "tab" {
+				builder.WriteByte(' ')
+			}
+		}
+	}
+
+	return builder.String(), nil
+}
+
+// PDFのストリームから文字列オブジェクトを取り出す
+func extractPDF(filePath string, maxSize int64) (string, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(io.LimitReader(file, maxSize))
+	if err != nil {
+		return "", err
+	}
+	if !bytes.HasPrefix(data, []byte("%PDF-")) {
+		return "", ErrUnsupportedFormat
+	}
+
+	var builder strings.Builder
+	remaining := maxSize
+	for rest := data; remaining > 0; {
+		start := bytes.Index(rest, []byte("stream"))
+		if start < 0 {
+			break
+		}
+		rest = rest[start+len("stream"):]
+		rest = bytes.TrimLeft(rest, "\r\n")
+
+		end := bytes.Index(rest, []byte("endstream"))
+		if end < 0 {
+			break
+		}
+		stream := rest[:end]
+		rest = rest[end+len("endstream"):]
+
+		// FlateDecodeのストリームは展開し、それ以外はそのまま使用
+		content := stream
+		if inflater, err := zlib.NewReader(bytes.NewReader(stream)); err == nil {
+			inflated, err := io.ReadAll(io.LimitReader(inflater, remaining))
+			inflater.Close()
+			if err == nil || len(inflated) > 0 {
+				content = inflated
+			}
+		}
+		if int64(len(content)) > remaining {
+			content = content[:remaining]
+		}
+		remaining -= int64(len(content))
+
+		builder.WriteString(pdfStrings(content))
+	}
+
+	return builder.String(), nil
+}
+
+// コンテンツストリームのリテラル文字列 "(...)" と16進文字列 "<...>" を取り出す
+func pdfStrings(content []byte) string {
+	var builder strings.Builder
+
+	for i := 0; i < len(content); i++ {
+		switch {
+		case content[i] == '(':
+			i = pdfLiteralString(content, i+1, &builder)
+			builder.WriteByte(' ')
+		case content[i] == '<' && i+1 < len(content) && content[i+1] == '<':
+			// 辞書の開始
+			i++
+		case content[i] == '<':
+			i = pdfHexString(content, i+1, &builder)
+			builder.WriteByte(' ')
+		}
+	}
+
+	return builder.String()
+}
+
+// リテラル文字列を取り出して閉じ括弧の位置を取得（入れ子の括弧とエスケープに対応）
+func pdfLiteralString(content []byte, i int, builder *strings.Builder) int {
+	depth := 1
+	for ; i < len(content); i++ {
+		c := content[i]
+		switch {
+		case c == '\\' && i+1 < len(content):
+			i++
+			switch e := content[i]; {
+			case e == 'n':
+				builder.WriteByte('\n')
+			case e == 'r':
+				builder.WriteByte('\r')
+			case e == 't':
+				builder.WriteByte('\t')
+			case e == 'b':
+				builder.WriteByte('\b')
+			case e == 'f':
+				builder.WriteByte('\f')
+			case e >= '0' && e <= '7':
+				// 1〜3桁の8進数（例: \053）
+				value := 0
+				for digits := 0; digits < 3 && i < len(content) && content[i] >= '0' && content[i] <= '7'; digits++ {
+					value = value*8 + int(content[i]-'0')
+					i++
+				}
+				i--
+				builder.WriteByte(byte(value))
+			case e == '\r' || e == '\n':
+				// 行末のバックスラッシュは改行を含めずに次の行へ続ける
+				if e == '\r' && i+1 < len(content) && content[i+1] == '\n' {
+					i++
+				}
+			default:
+				builder.WriteByte(e)
+			}
+		case c == '(':
+			depth++
+			builder.WriteByte(c)
+		case c == ')':
+			depth--
+			if depth == 0 {
+				return i
+			}
+			builder.WriteByte(c)
+		default:
+			builder.WriteByte(c)
+		}
+	}
+
+	return i
+}
+
+// 16進文字列を取り出して閉じ括弧の位置を取得（空白は無視し、奇数桁の場合は最後に0を補う）
+func pdfHexString(content []byte, i int, builder *strings.Builder) int {
+	value, digits := byte(0), 0
+	for ; i < len(content) && content[i] != '>'; i++ {
+		nibble, ok := hexValue(content[i])
+		if !ok {
+			continue
+		}
+
+		value = value<<4 | nibble
+		digits++
+		if digits == 2 {
+			builder.WriteByte(value)
+			value, digits = 0, 0
+		}
+	}
+	if digits == 1 {
+		builder.WriteByte(value << 4)
+	}
+
+	return i
+}
+
+// 16進数の1桁の値を取得
+func hexValue(c byte) (byte, bool) {
+	switch {
+	case c >= '0' && c <= '9':
+		return c - '0', true
+	case c >= 'a' && c <= 'f':
+		return c - 'a' + 10, true
+	case c >= 'A' && c <= 'F':
+		return c - 'A' + 10, true
+	default:
+		return 0, false
+	}
+}
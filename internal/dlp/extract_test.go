@@ -0,0 +1,96 @@
+package dlp
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestExtractText(t *testing.T) {
+	tests := []struct {
+		file     string
+		contains []string
+		excludes []string
+	}{
+		{"plain.txt", []string{"4111-1111-1111-1111", "1234 5678 9018", "CONFIDENTIAL"}, nil},
+		// docProps/app.xml はテキストを取り出す対象外
+		{"report.docx", []string{"Card 4111 1111 1111 1111 Confidential", "SSN 123-45-6789"}, []string{"378282246310005"}},
+		{"ledger.xlsx", []string{"My Number 1234-5678-9018", "4111111111111111"}, nil},
+		// FlateDecodeのストリームのリテラル文字列、16進文字列、8進エスケープと、非圧縮のストリーム
+		{"statement.pdf", []string{"Card 4111 1111 1111 1111", "SSN", "123-45-6789", "nested (paren)", "CONFIDENTIAL"}, []string{"Type"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			text, err := ExtractText(filepath.Join("testdata", tt.file), DEFAULT_MAX_EXTRACTED_MB*1024*1024)
+			if err != nil {
+				t.Fatalf("ExtractText: %v", err)
+			}
+
+			for _, want := range tt.contains {
+				if !strings.Contains(text, want) {
+					t.Errorf("text %q does not contain %q", text, want)
+				}
+			}
+			for _, unwanted := range tt.excludes {
+				if strings.Contains(text, unwanted) {
+					t.Errorf("text %q contains %q", text, unwanted)
+				}
+			}
+		})
+	}
+}
+
+func TestExtractTextUnsupported(t *testing.T) {
+	for _, file := range []string{"binary.bin", "fake.pdf"} {
+		if _, err := ExtractText(filepath.Join("testdata", file), 1024); !errors.Is(err, ErrUnsupportedFormat) {
+			t.Errorf("ExtractText(%s) = %v, want ErrUnsupportedFormat", file, err)
+		}
+	}
+}
+
+func TestExtractTextLimit(t *testing.T) {
+	// 上限は展開後に読み込むバイト数（word/document.xml は285バイトのため以降のパーツは読み込まない）
+	text, err := ExtractText(filepath.Join("testdata", "report.docx"), 285)
+	if err != nil {
+		t.Fatalf("ExtractText: %v", err)
+	}
+	if !strings.Contains(text, "Confidential") || strings.Contains(text, "SSN") {
+		t.Errorf("docx limited to the first part: %q", text)
+	}
+
+	text, err = ExtractText(filepath.Join("testdata", "plain.txt"), 13)
+	if err != nil || text != "Customer card" {
+		t.Errorf("plain text limited to 13 bytes = %q, %v", text, err)
+	}
+
+	// ストリームの途中までしか読み込まない場合は文字列を取り出さない
+	text, err = ExtractText(filepath.Join("testdata", "statement.pdf"), 100)
+	if err != nil || text != "" {
+		t.Errorf("pdf limited to 100 bytes = %q, %v", text, err)
+	}
+}
+
+func TestPDFStrings(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{"literal", `(Hello) Tj`, "Hello "},
+		{"escapes", `(a\nb\tc\\d) Tj`, "a\nb\tc\\d "},
+		{"octal escapes", `(\053\53\0533) Tj`, "+++3 "},
+		{"line continuation", "(split \\\nline) Tj", "split line "},
+		{"nested parentheses", `(a (b) c) Tj`, "a (b) c "},
+		{"hex", `<48656C6C 6f> Tj`, "Hello "},
+		{"hex odd digits", `<414> Tj`, "A@ "},
+		{"dictionary is not a hex string", `<< /Type /Font >> (x) Tj`, "x "},
+	}
+
+	for _, tt := range tests {
+		if got := pdfStrings([]byte(tt.content)); got != tt.want {
+			t.Errorf("%s: pdfStrings(%q) = %q, want %q", tt.name, tt.content, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,23 @@
+package fanotify
+
+import "errors"
+
+// fanotifyが使えない場合のエラー（Linux以外、またはCAP_SYS_ADMINがない場合）
+var ErrUnsupported = errors.New("fanotify is not available")
+
+// fanotifyで通知されたファイルアクセスの構造体
+type Event struct {
+	Mask uint64 // CLOSE_WRITE などの組み合わせ
+	PID  int    // アクセスしたプロセス
+	Path string // アクセスされたファイルのパス
+}
+
+// 読み込みのみで閉じられたかどうかを確認
+func (e Event) IsRead() bool {
+	return e.Mask&CLOSE_NOWRITE != 0
+}
+
+// 書き込み後に閉じられたかどうかを確認
+func (e Event) IsWrite() bool {
+	return e.Mask&(CLOSE_WRITE|MODIFY) != 0
+}
@@ -0,0 +1,113 @@
+package fanotify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+const (
+	MODIFY        = unix.FAN_MODIFY
+	CLOSE_WRITE   = unix.FAN_CLOSE_WRITE
+	CLOSE_NOWRITE = unix.FAN_CLOSE_NOWRITE
+
+	BUFFER_SIZE  = 64 * 1024
+	POLL_TIMEOUT = 1000 // ミリ秒（Readが受信を待機する最大時間）
+)
+
+// マウント単位でファイルアクセスを受信する構造体
+type Notifier struct {
+	fd  int
+	pid int
+}
+
+// 新しいNotifierを作成
+func Open() (*Notifier, error) {
+	fd, err := unix.FanotifyInit(unix.FAN_CLASS_NOTIF|unix.FAN_CLOEXEC|unix.FAN_NONBLOCK, unix.O_RDONLY|unix.O_LARGEFILE|unix.O_CLOEXEC)
+	if err != nil {
+		if errors.Is(err, unix.EPERM) || errors.Is(err, unix.ENOSYS) {
+			return nil, fmt.Errorf("%w: %v", ErrUnsupported, err)
+		}
+		return nil, fmt.Errorf("failed initializing fanotify: %w", err)
+	}
+
+	return &Notifier{fd: fd, pid: os.Getpid()}, nil
+}
+
+// マウントポイント配下のファイルアクセスを購読
+func (n *Notifier) MarkMount(path string, mask uint64) error {
+	if err := unix.FanotifyMark(n.fd, unix.FAN_MARK_ADD|unix.FAN_MARK_MOUNT, mask, unix.AT_FDCWD, path); err != nil {
+		return fmt.Errorf("failed marking %s for fanotify: %w", path, err)
+	}
+
+	return nil
+}
+
+// ファイルアクセスを受信（自身のプロセスによるアクセスは除く、POLL_TIMEOUT内に受信しなかった場合は空）
+func (n *Notifier) Read(ctx context.Context) ([]Event, error) {
+	buffer := make([]byte, BUFFER_SIZE)
+
+	for ctx.Err() == nil {
+		fds := []unix.PollFd{{Fd: int32(n.fd), Events: unix.POLLIN}}
+		count, err := unix.Poll(fds, POLL_TIMEOUT)
+		if err != nil {
+			if errors.Is(err, unix.EINTR) {
+				continue
+			}
+			return nil, fmt.Errorf("failed polling fanotify: %w", err)
+		}
+		if count == 0 {
+			return nil, nil
+		}
+
+		length, err := unix.Read(n.fd, buffer)
+		if err != nil {
+			if errors.Is(err, unix.EAGAIN) || errors.Is(err, unix.EINTR) {
+				continue
+			}
+			return nil, fmt.Errorf("failed reading fanotify: %w", err)
+		}
+
+		return n.parse(buffer[:length]), nil
+	}
+
+	return nil, ctx.Err()
+}
+
+// fanotify_event_metadataの列を解析
+func (n *Notifier) parse(buffer []byte) []Event {
+	var events []Event
+
+	for offset := 0; offset+int(unsafe.Sizeof(unix.FanotifyEventMetadata{})) <= len(buffer); {
+		metadata := (*unix.FanotifyEventMetadata)(unsafe.Pointer(&buffer[offset]))
+		if metadata.Event_len == 0 || metadata.Vers != unix.FANOTIFY_METADATA_VERSION {
+			break
+		}
+		offset += int(metadata.Event_len)
+
+		if metadata.Fd < 0 {
+			continue
+		}
+
+		// ファイルディスクリプタからパスを取得して閉じる
+		path, err := os.Readlink("/proc/self/fd/" + strconv.Itoa(int(metadata.Fd)))
+		unix.Close(int(metadata.Fd))
+		if err != nil || int(metadata.Pid) == n.pid {
+			continue
+		}
+
+		events = append(events, Event{Mask: metadata.Mask, PID: int(metadata.Pid), Path: path})
+	}
+
+	return events
+}
+
+// Notifierを閉じる
+func (n *Notifier) Close() error {
+	return unix.Close(n.fd)
+}
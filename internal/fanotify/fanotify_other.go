@@ -0,0 +1,34 @@
+//go:build !linux
+
+package fanotify
+
+import "context"
+
+const (
+	MODIFY        = 0x2
+	CLOSE_WRITE   = 0x8
+	CLOSE_NOWRITE = 0x10
+)
+
+// 対応していないプラットフォーム用のNotifier
+type Notifier struct{}
+
+// 対応していないためエラーを返す
+func Open() (*Notifier, error) {
+	return nil, ErrUnsupported
+}
+
+// 対応していないためエラーを返す
+func (n *Notifier) MarkMount(path string, mask uint64) error {
+	return ErrUnsupported
+}
+
+// 対応していないためエラーを返す
+func (n *Notifier) Read(ctx context.Context) ([]Event, error) {
+	return nil, ErrUnsupported
+}
+
+// 何もしない
+func (n *Notifier) Close() error {
+	return nil
+}
@@ -0,0 +1,87 @@
+package filewatch
+
+import (
+	"path/filepath"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+
+	"github.com/mniyk/endpoint-security-and-monitoring-tools/internal/procinfo"
+	"github.com/mniyk/endpoint-security-and-monitoring-tools/internal/userinfo"
+)
+
+const (
+	OPERATION_CREATE  = "create"
+	OPERATION_WRITE   = "write"
+	OPERATION_DELETE  = "delete"
+	OPERATION_RENAMED = "renamed"
+)
+
+// 確定したファイル操作の構造体
+type Change struct {
+	Operation string
+	Path      string // 名前変更でドライブなどの外へ移動した場合は空
+	OldPath   string // 名前変更前のパス（名前変更の場合のみ）
+	Moved     bool   // 別のディレクトリまたは監視対象の外へ移動した
+	Size      int64
+	Timestamp time.Time
+	Scanned   bool // 監視できない部分木の定期スキャンで検出した
+
+	Interrupted bool              // 書き込みが落ち着く前に停止、取り外しまたは削除で確定した
+	Process     *procinfo.Process // 書き込んだプロセス（Hooks.Writesで特定できた場合のみ）
+}
+
+// ファイル名を取得（外へ移動した場合は変更前のファイル名）
+func (c Change) FileName() string {
+	if c.Path == "" {
+		return filepath.Base(c.OldPath)
+	}
+
+	return filepath.Base(c.Path)
+}
+
+// ファイル操作イベントの共通のデータを作成（locationはドライブやデバイスなどの書き込み先）
+func (c Change) EventData(location string, user *userinfo.UserInfo) map[string]interface{} {
+	data := map[string]interface{}{
+		"path":      c.Path,
+		"filename":  c.FileName(),
+		"drive":     location,
+		"user":      user.UserName,
+		"host":      user.HostName,
+		"timestamp": c.Timestamp.Format(time.RFC3339),
+		"operation": c.Operation,
+		"size":      c.Size,
+	}
+
+	if c.Operation == OPERATION_RENAMED {
+		data["old_path"] = c.OldPath
+		data["new_path"] = c.Path
+		data["moved"] = c.Moved
+	}
+	if c.Scanned {
+		data["scanned"] = true
+	}
+	if c.Process != nil {
+		c.Process.AddTo(data, "process_")
+	}
+
+	return data
+}
+
+// イベント操作名を取得
+func OperationName(op fsnotify.Op) string {
+	switch {
+	case op&fsnotify.Create == fsnotify.Create:
+		return OPERATION_CREATE
+	case op&fsnotify.Write == fsnotify.Write:
+		return OPERATION_WRITE
+	case op&fsnotify.Remove == fsnotify.Remove:
+		return OPERATION_DELETE
+	case op&fsnotify.Rename == fsnotify.Rename:
+		return "rename"
+	case op&fsnotify.Chmod == fsnotify.Chmod:
+		return "chmod"
+	default:
+		return "unknown"
+	}
+}
@@ -0,0 +1,59 @@
+package filewatch
+
+import (
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// 名前変更の前半（Rename）で、対になるCreateを待っている状態の構造体
+type PendingRename struct {
+	Path string
+	At   time.Time
+}
+
+// 対になる名前変更を選択（cookieで対になった変更前のパスを優先し、なければRENAME_PAIR_WINDOW内の同じファイル名の移動、ない場合は-1）
+func PairRename(renames []PendingRename, newPath string, renamedFrom string, now time.Time) int {
+	if renamedFrom != "" {
+		return FindRename(renames, renamedFrom)
+	}
+
+	for i := len(renames) - 1; i >= 0; i-- {
+		rename := renames[i]
+		if rename.Path != newPath && filepath.Base(rename.Path) == filepath.Base(newPath) && now.Sub(rename.At) < RENAME_PAIR_WINDOW {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// 待機中の名前変更の位置を取得（ない場合は-1）
+func FindRename(renames []PendingRename, path string) int {
+	for i, rename := range renames {
+		if rename.Path == path {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// inotifyのcookieで対になった変更前のパスを取得（fsnotifyはEvent.Stringでのみ公開するため、ない場合は空）
+func RenamedFrom(event fsnotify.Event) string {
+	// 例: CREATE "/media/usb/new.txt" ← "/media/usb/old.txt"（パス中の引用符はエスケープされる）
+	_, quoted, found := strings.Cut(event.String(), `" ← "`)
+	if !found {
+		return ""
+	}
+
+	oldPath, err := strconv.Unquote(`"` + quoted)
+	if err != nil {
+		return ""
+	}
+
+	return oldPath
+}
@@ -0,0 +1,398 @@
+package filewatch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+
+	"github.com/mniyk/endpoint-security-and-monitoring-tools/module"
+)
+
+const (
+	UNCOVERED_DEPTH     = "depth"     // 深さの上限を超えた
+	UNCOVERED_BUDGET    = "budget"    // 監視するディレクトリ数の上限に達した
+	UNCOVERED_EXHAUSTED = "exhausted" // カーネルの監視数の上限に達した
+)
+
+// 監視の上限の構造体
+type Limits struct {
+	MaxDepth       int           // 監視するディレクトリの深さの上限（0の場合は無制限）
+	MaxDirectories int           // 監視するディレクトリ数の上限（0の場合は無制限）
+	ScanInterval   time.Duration // 監視できない部分木をスキャンする間隔
+}
+
+// ファイルの状態（定期スキャンで変更を検出するため）
+type fileState struct {
+	size    int64
+	modTime time.Time
+}
+
+// 監視の対象範囲の集計の構造体
+type Coverage struct {
+	Watched        int  // 監視しているディレクトリ数
+	Uncovered      int  // 定期スキャンで補っている部分木の数
+	UncoveredFiles int  // 定期スキャンで補っている部分木内のファイル数
+	Exhausted      bool // カーネルの監視数の上限に達した
+}
+
+// 集計を加算
+func (c *Coverage) Add(other Coverage) {
+	c.Watched += other.Watched
+	c.Uncovered += other.Uncovered
+	c.UncoveredFiles += other.UncoveredFiles
+	c.Exhausted = c.Exhausted || other.Exhausted
+}
+
+// 集計を稼働状況に記録
+func (c Coverage) SetDetails(health *module.HealthTracker) {
+	health.SetDetail("watched_directories", c.Watched)
+	health.SetDetail("uncovered_directories", c.Uncovered)
+	health.SetDetail("uncovered_files", c.UncoveredFiles)
+	health.SetDetail("watch_limit_reached", c.Exhausted)
+}
+
+// ディレクトリの再帰的な監視と、監視できない部分木の定期スキャンを管理する構造体
+type Tree struct {
+	name      string // ログに出力するモジュール名
+	watcher   *fsnotify.Watcher
+	roots     []string
+	limits    Limits
+	health    *module.HealthTracker
+	mu        sync.Mutex
+	watched   map[string]bool
+	uncovered map[string]string // 監視できない部分木のルートと理由
+	snapshot  map[string]fileState
+	exhausted bool
+}
+
+// 新しいTreeを作成
+func NewTree(name string, watcher *fsnotify.Watcher, limits Limits, health *module.HealthTracker) *Tree {
+	return &Tree{
+		name:      name,
+		watcher:   watcher,
+		limits:    limits,
+		health:    health,
+		watched:   make(map[string]bool),
+		uncovered: make(map[string]string),
+		snapshot:  make(map[string]fileState),
+	}
+}
+
+// fsnotifyのWatcherを取得
+func (t *Tree) Watcher() *fsnotify.Watcher {
+	return t.watcher
+}
+
+// 監視の起点を追加し、配下のディレクトリを監視（visitがnilでない場合はファイルごとに呼び出す）
+func (t *Tree) AddRoot(root string, visit func(path string, info os.FileInfo)) {
+	t.mu.Lock()
+	t.roots = append(t.roots, filepath.Clean(root))
+	t.mu.Unlock()
+
+	t.walk(root, visit)
+}
+
+// 作成または移動されたディレクトリの配下を監視（既存のファイルは次回のスキャンで作成として扱わない）
+func (t *Tree) AddTree(path string, visit func(path string, info os.FileInfo)) {
+	t.walk(path, visit)
+}
+
+// ディレクトリを再帰的に監視（監視できない部分木は定期スキャンの対象として記録）
+func (t *Tree) walk(root string, visit func(path string, info os.FileInfo)) {
+	// 監視できない部分木のルート（配下は監視せずにスキャンで補う）
+	uncoveredRoot := ""
+	// 監視できない部分木とその中のファイル（スキャンが途中の状態を見ないよう走査後にまとめて反映）
+	uncovered := make(map[string]string)
+	baseline := make(map[string]fileState)
+
+	// 走査中はロックを保持せず、監視の追加時のみロックを取得
+	err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info == nil {
+			// AccessDenied や NotFound などのエラーはスキップ
+			return nil
+		}
+
+		inUncovered := uncoveredRoot != "" && strings.HasPrefix(path, uncoveredRoot+string(os.PathSeparator))
+
+		if !info.IsDir() {
+			if visit != nil {
+				visit(path, info)
+			}
+			if inUncovered {
+				baseline[path] = fileState{size: info.Size(), modTime: info.ModTime()}
+			}
+			return nil
+		}
+
+		if inUncovered {
+			return nil
+		}
+		uncoveredRoot = ""
+
+		t.mu.Lock()
+		reason := t.watchDirectory(path)
+		t.mu.Unlock()
+
+		if reason != "" {
+			uncovered[path] = reason
+			uncoveredRoot = path
+		}
+		return nil
+	})
+	if err != nil {
+		log.Printf("[%s] Failed walking directory tree: %v\n", t.name, err)
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for path, reason := range uncovered {
+		t.uncovered[path] = reason
+	}
+	for path, state := range baseline {
+		t.snapshot[path] = state
+	}
+}
+
+// ディレクトリを監視対象に追加（監視できない場合はその理由を取得）
+func (t *Tree) watchDirectory(path string) string {
+	if t.watched[path] {
+		return ""
+	}
+	if t.limits.MaxDepth > 0 && t.depthOf(path) > t.limits.MaxDepth {
+		return UNCOVERED_DEPTH
+	}
+	if t.exhausted {
+		return UNCOVERED_EXHAUSTED
+	}
+	if t.limits.MaxDirectories > 0 && len(t.watched) >= t.limits.MaxDirectories {
+		return UNCOVERED_BUDGET
+	}
+
+	if err := t.watcher.Add(path); err != nil {
+		if !isWatchExhausted(err) {
+			log.Printf("[%s] Failed watching directory %s: %v\n", t.name, path, err)
+			return ""
+		}
+
+		// 以降のディレクトリは監視を試みずにスキャンで補う
+		log.Printf("[%s] Watch limit reached at %s, scanning uncovered directories every %s: %v\n", t.name, path, t.limits.ScanInterval, err)
+		t.health.RecordError(fmt.Errorf("watch limit reached at %s: %w", path, err))
+		t.exhausted = true
+		return UNCOVERED_EXHAUSTED
+	}
+
+	t.watched[path] = true
+	return ""
+}
+
+// 監視の起点からのディレクトリの深さを取得
+func (t *Tree) depthOf(path string) int {
+	for _, root := range t.roots {
+		relPath, err := filepath.Rel(root, path)
+		if err != nil || relPath == ".." || strings.HasPrefix(relPath, ".."+string(os.PathSeparator)) {
+			continue
+		}
+		if relPath == "." {
+			return 0
+		}
+		return len(strings.Split(relPath, string(os.PathSeparator)))
+	}
+
+	return 0
+}
+
+// 削除または移動されたディレクトリの配下を監視の記録から取り除く
+func (t *Tree) Forget(path string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	prefix := path + string(os.PathSeparator)
+	for watchedPath := range t.watched {
+		if watchedPath == path || strings.HasPrefix(watchedPath, prefix) {
+			delete(t.watched, watchedPath)
+			// 監視が解除されたため、次回のスキャンで監視できなかった部分木を再試行
+			t.exhausted = false
+		}
+	}
+	for uncoveredPath := range t.uncovered {
+		if uncoveredPath == path || strings.HasPrefix(uncoveredPath, prefix) {
+			delete(t.uncovered, uncoveredPath)
+		}
+	}
+	for filePath := range t.snapshot {
+		if strings.HasPrefix(filePath, prefix) {
+			delete(t.snapshot, filePath)
+		}
+	}
+}
+
+// 監視の対象範囲を集計
+func (t *Tree) Coverage() Coverage {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return Coverage{
+		Watched:        len(t.watched),
+		Uncovered:      len(t.uncovered),
+		UncoveredFiles: len(t.snapshot),
+		Exhausted:      t.exhausted,
+	}
+}
+
+// 監視できない部分木を一定間隔でスキャンし、変更をfsnotifyと同じ形式で送信
+func (t *Tree) StartScanning(ctx context.Context, wg *sync.WaitGroup) <-chan []fsnotify.Event {
+	changes := make(chan []fsnotify.Event, 1)
+
+	module.Go(ctx, wg, func() {
+		ticker := time.NewTicker(t.limits.ScanInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+
+			batch := t.Scan()
+			if len(batch) == 0 {
+				continue
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case changes <- batch:
+			}
+		}
+	})
+
+	return changes
+}
+
+// 監視できない部分木をスキャンして前回からの変更を取得
+func (t *Tree) Scan() []fsnotify.Event {
+	t.mu.Lock()
+	roots := make([]string, 0, len(t.uncovered))
+	for root := range t.uncovered {
+		roots = append(roots, root)
+	}
+	t.mu.Unlock()
+
+	if len(roots) == 0 {
+		return nil
+	}
+
+	// 部分木ごとのファイルの状態（存在しない部分木はnil）
+	current := make(map[string]map[string]fileState)
+	for _, root := range roots {
+		if _, err := os.Lstat(root); errors.Is(err, fs.ErrNotExist) {
+			current[root] = nil
+			continue
+		}
+
+		files := make(map[string]fileState)
+		filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+			if err != nil || entry.IsDir() {
+				return nil
+			}
+			if info, err := entry.Info(); err == nil {
+				files[path] = fileState{size: info.Size(), modTime: info.ModTime()}
+			}
+			return nil
+		})
+		current[root] = files
+	}
+
+	t.mu.Lock()
+
+	// スキャンした部分木の記録のみを更新（走査中に追加された部分木の記録は残す）
+	var changes []fsnotify.Event
+	for root, files := range current {
+		if _, exists := t.uncovered[root]; !exists {
+			// 走査中に取り除かれた部分木
+			continue
+		}
+		if files == nil {
+			delete(t.uncovered, root)
+		}
+
+		prefix := root + string(os.PathSeparator)
+		for path := range t.snapshot {
+			if !strings.HasPrefix(path, prefix) {
+				continue
+			}
+			if _, exists := files[path]; !exists {
+				changes = append(changes, fsnotify.Event{Name: path, Op: fsnotify.Remove})
+				delete(t.snapshot, path)
+			}
+		}
+		for path, state := range files {
+			before, exists := t.snapshot[path]
+			switch {
+			case !exists:
+				changes = append(changes, fsnotify.Event{Name: path, Op: fsnotify.Create})
+			case before.size != state.size || !before.modTime.Equal(state.modTime):
+				changes = append(changes, fsnotify.Event{Name: path, Op: fsnotify.Write})
+			}
+			t.snapshot[path] = state
+		}
+	}
+
+	retries := t.takeRetries()
+	t.mu.Unlock()
+
+	// 監視できるようになった部分木を監視し直す（監視できない部分は再びスキャンの対象になる）
+	for _, root := range retries {
+		t.walk(root, nil)
+	}
+
+	sort.Slice(changes, func(a, b int) bool {
+		return changes[a].Name < changes[b].Name
+	})
+
+	return changes
+}
+
+// 監視数の上限で監視できなかった部分木のうち、監視を再試行するものを記録から取り除いて取得
+func (t *Tree) takeRetries() []string {
+	if t.exhausted || (t.limits.MaxDirectories > 0 && len(t.watched) >= t.limits.MaxDirectories) {
+		return nil
+	}
+
+	var retries []string
+	for root, reason := range t.uncovered {
+		if reason != UNCOVERED_EXHAUSTED && reason != UNCOVERED_BUDGET {
+			continue
+		}
+
+		delete(t.uncovered, root)
+		prefix := root + string(os.PathSeparator)
+		for path := range t.snapshot {
+			if strings.HasPrefix(path, prefix) {
+				delete(t.snapshot, path)
+			}
+		}
+		retries = append(retries, root)
+	}
+	sort.Strings(retries)
+
+	return retries
+}
+
+// カーネルの監視数またはファイルディスクリプタの上限によるエラーかどうかを確認
+func isWatchExhausted(err error) bool {
+	return errors.Is(err, syscall.ENOSPC) || errors.Is(err, syscall.EMFILE)
+}
@@ -0,0 +1,161 @@
+package filewatch
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+
+	"github.com/mniyk/endpoint-security-and-monitoring-tools/module"
+)
+
+// 監視の深さを制限したTreeを作成
+func newTestTree(t *testing.T, root string) *Tree {
+	t.Helper()
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		t.Fatalf("NewWatcher: %v", err)
+	}
+	t.Cleanup(func() { watcher.Close() })
+
+	tree := NewTree("test", watcher, Limits{MaxDepth: 1, ScanInterval: time.Second}, module.NewHealthTracker())
+	tree.AddRoot(root, nil)
+	return tree
+}
+
+// ファイルを作成
+func writeFile(t *testing.T, path string, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestTreeAddTreeSeedsSnapshot(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "a", "b", "c", "existing.txt"), "before")
+	tree := newTestTree(t, root)
+
+	coverage := tree.Coverage()
+	if coverage.Uncovered != 1 || coverage.UncoveredFiles != 1 {
+		t.Fatalf("unexpected coverage after AddRoot: %+v", coverage)
+	}
+
+	// 深いディレクトリを移動しても既存のファイルは作成として扱わない
+	moved := filepath.Join(root, "moved")
+	writeFile(t, filepath.Join(root, "staging", "x", "y", "one.txt"), "1")
+	writeFile(t, filepath.Join(root, "staging", "x", "y", "z", "two.txt"), "2")
+	if err := os.Rename(filepath.Join(root, "staging"), moved); err != nil {
+		t.Fatal(err)
+	}
+
+	var visited []string
+	tree.AddTree(moved, func(path string, info os.FileInfo) {
+		visited = append(visited, path)
+	})
+	if len(visited) != 2 {
+		t.Fatalf("visited %v, want both files", visited)
+	}
+
+	if changes := tree.Scan(); len(changes) != 0 {
+		t.Fatalf("scan after AddTree reported %v", changes)
+	}
+
+	// 以降の変更は検出する
+	added := filepath.Join(moved, "x", "y", "three.txt")
+	writeFile(t, added, "3")
+	changes := tree.Scan()
+	if len(changes) != 1 || changes[0].Name != added || !changes[0].Has(fsnotify.Create) {
+		t.Fatalf("scan after a new file reported %v", changes)
+	}
+}
+
+func TestTreeForgetDropsSnapshot(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "a", "b", "file.txt"), "data")
+	tree := newTestTree(t, root)
+
+	tree.Forget(filepath.Join(root, "a"))
+	if coverage := tree.Coverage(); coverage.Uncovered != 0 || coverage.UncoveredFiles != 0 || coverage.Watched != 1 {
+		t.Fatalf("unexpected coverage after Forget: %+v", coverage)
+	}
+}
+
+func TestTreeScanKeepsConcurrentAddTree(t *testing.T) {
+	root := t.TempDir()
+	// スキャンの走査に時間がかかるよう多数のファイルを作成
+	for i := 0; i < 2000; i++ {
+		writeFile(t, filepath.Join(root, "a", "b", fmt.Sprintf("file%d.txt", i)), "data")
+	}
+	tree := newTestTree(t, root)
+
+	// スキャン中に追加された部分木の記録をスキャンの結果で上書きしない
+	for i := 0; i < 10; i++ {
+		moved := filepath.Join(root, fmt.Sprintf("moved%d", i))
+		writeFile(t, filepath.Join(moved, "x", "file.txt"), "data")
+
+		var wg sync.WaitGroup
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			tree.Scan()
+		}()
+		go func() {
+			defer wg.Done()
+			// スキャンの走査中に追加
+			time.Sleep(time.Millisecond)
+			tree.AddTree(moved, nil)
+		}()
+		wg.Wait()
+
+		if changes := tree.Scan(); len(changes) != 0 {
+			t.Fatalf("scan after a concurrent AddTree of %s reported %v", moved, changes)
+		}
+	}
+}
+
+func TestTreeRetriesAfterForget(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "a", "file.txt"), "a")
+	writeFile(t, filepath.Join(root, "b", "file.txt"), "b")
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		t.Fatalf("NewWatcher: %v", err)
+	}
+	t.Cleanup(func() { watcher.Close() })
+
+	// ルートとaのみ監視できる
+	tree := NewTree("test", watcher, Limits{MaxDirectories: 2, ScanInterval: time.Second}, module.NewHealthTracker())
+	tree.AddRoot(root, nil)
+	if coverage := tree.Coverage(); coverage.Watched != 2 || coverage.Uncovered != 1 || coverage.UncoveredFiles != 1 {
+		t.Fatalf("unexpected coverage after AddRoot: %+v", coverage)
+	}
+
+	// 上限に達したままでは再試行しない
+	tree.Scan()
+	if coverage := tree.Coverage(); coverage.Uncovered != 1 {
+		t.Fatalf("retried without free watches: %+v", coverage)
+	}
+
+	// 監視が解除されると次回のスキャンで監視する
+	tree.exhausted = true
+	tree.Forget(filepath.Join(root, "a"))
+	if tree.Coverage().Exhausted {
+		t.Fatal("Forget did not clear the exhausted watch limit")
+	}
+	if changes := tree.Scan(); len(changes) != 0 {
+		t.Fatalf("scan retrying the watch reported %v", changes)
+	}
+	if coverage := tree.Coverage(); coverage.Watched != 2 || coverage.Uncovered != 0 || coverage.UncoveredFiles != 0 {
+		t.Fatalf("unexpected coverage after retry: %+v", coverage)
+	}
+}
@@ -0,0 +1,337 @@
+package filewatch
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+
+	"github.com/mniyk/endpoint-security-and-monitoring-tools/internal/procinfo"
+	"github.com/mniyk/endpoint-security-and-monitoring-tools/module"
+)
+
+const (
+	WRITE_SETTLE_DELAY          = 2 * time.Second // 最後の書き込みからファイルが確定したと判断するまでの時間
+	WRITE_SETTLE_CHECK_INTERVAL = 500 * time.Millisecond
+	RENAME_PAIR_WINDOW          = 500 * time.Millisecond // 名前変更の前半と後半を対にする期間
+)
+
+// 監視のフックの構造体（nilの項目は呼び出さない）
+type Hooks struct {
+	Visit    func(path string, info os.FileInfo)  // 監視を開始したときに既存のファイルごとに呼び出す
+	Update   func(path string)                    // ファイルが作成、書き込みまたは削除された（定期スキャンを含む）
+	Move     func(oldPath string, newPath string) // 名前変更（newPathが空の場合は監視対象の外への移動）
+	Remove   func(path string)                    // ファイルまたはディレクトリが削除された
+	Allow    func(paths ...string) bool           // いずれかのパスが記録の対象かどうか
+	Coverage func()                               // 監視の対象範囲が変わった
+	Ready    func()                               // 起点の監視を開始した（既存のファイルはVisitで通知済み）
+	Writes   <-chan Write                         // 書き込んだプロセスの通知（fanotifyなど）
+}
+
+// 書き込んだプロセスが分かったファイルへの書き込みの構造体
+type Write struct {
+	Path    string
+	Process procinfo.Process
+}
+
+// ファイルの変更を通知
+func (h Hooks) update(path string) {
+	if h.Update != nil {
+		h.Update(path)
+	}
+}
+
+// 名前変更を通知
+func (h Hooks) move(oldPath string, newPath string) {
+	if h.Move != nil {
+		h.Move(oldPath, newPath)
+	}
+}
+
+// 削除を通知
+func (h Hooks) remove(path string) {
+	if h.Remove != nil {
+		h.Remove(path)
+	}
+}
+
+// 記録の対象かどうかを確認（Allowがない場合はすべて対象）
+func (h Hooks) allows(paths ...string) bool {
+	return h.Allow == nil || h.Allow(paths...)
+}
+
+// 監視の対象範囲の変化を通知
+func (h Hooks) coverage() {
+	if h.Coverage != nil {
+		h.Coverage()
+	}
+}
+
+// ディレクトリ配下のファイル操作を監視し、書き込みが落ち着いたものを確定する構造体
+type Watcher struct {
+	name   string
+	limits Limits
+	health *module.HealthTracker
+	mu     sync.Mutex
+	tree   *Tree
+}
+
+// 新しいWatcherを作成
+func NewWatcher(name string, limits Limits, health *module.HealthTracker) *Watcher {
+	return &Watcher{
+		name:   name,
+		limits: limits,
+		health: health,
+	}
+}
+
+// 監視の対象範囲を集計（監視を開始する前は空）
+func (w *Watcher) Coverage() Coverage {
+	w.mu.Lock()
+	tree := w.tree
+	w.mu.Unlock()
+
+	if tree == nil {
+		return Coverage{}
+	}
+
+	return tree.Coverage()
+}
+
+// コンテキストが終了するまでディレクトリを監視し、確定したファイル操作をhandleに渡す
+func (w *Watcher) Run(ctx context.Context, wg *sync.WaitGroup, roots []string, hooks Hooks, handle func(Change)) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("failed creating file watcher: %w", err)
+	}
+	defer watcher.Close()
+
+	tree := NewTree(w.name, watcher, w.limits, w.health)
+	for _, root := range roots {
+		tree.AddRoot(root, hooks.Visit)
+	}
+	w.mu.Lock()
+	w.tree = tree
+	w.mu.Unlock()
+	hooks.coverage()
+	if hooks.Ready != nil {
+		hooks.Ready()
+	}
+
+	scans := tree.StartScanning(ctx, wg)
+	writes := hooks.Writes
+
+	// 書き込みが落ち着くまで保留しているファイル操作
+	pending := make(map[string]*Change)
+	// 対になるCreateを待っている名前変更（発生順）
+	var renames []PendingRename
+	// 名前変更したディレクトリ（変更前のパスと変更後のパス）
+	renamedDirs := make(map[string]PendingRename)
+
+	// 作成または書き込みを保留（保留中の場合は最終書き込み時刻のみ更新、対象外の場合はnil）
+	track := func(filePath string, op fsnotify.Op, scanned bool) *Change {
+		hooks.update(filePath)
+		if !hooks.allows(filePath) {
+			return nil
+		}
+
+		if change, exists := pending[filePath]; exists {
+			change.Timestamp = time.Now()
+			if op.Has(fsnotify.Create) {
+				change.Operation = OPERATION_CREATE
+			}
+			return change
+		}
+
+		change := &Change{
+			Operation: OperationName(op),
+			Path:      filePath,
+			Timestamp: time.Now(),
+			Scanned:   scanned,
+		}
+		pending[filePath] = change
+		return change
+	}
+
+	// ファイルサイズを取得して確定（interruptedは書き込みが落ち着く前に確定した場合）
+	complete := func(change Change, interrupted bool) {
+		if fileInfo, err := os.Stat(change.Path); err == nil && !fileInfo.IsDir() {
+			change.Size = fileInfo.Size()
+		}
+		change.Interrupted = interrupted
+		handle(change)
+	}
+
+	// 名前変更を確定（newPathが空の場合は監視対象の外への移動）
+	rename := func(oldPath string, newPath string) {
+		hooks.move(oldPath, newPath)
+
+		// 変更前と変更後のどちらも対象外の場合は記録しない
+		if !hooks.allows(oldPath, newPath) {
+			return
+		}
+
+		handle(Change{
+			Operation: OPERATION_RENAMED,
+			Path:      newPath,
+			OldPath:   oldPath,
+			Moved:     newPath == "" || filepath.Dir(oldPath) != filepath.Dir(newPath),
+			Timestamp: time.Now(),
+		})
+	}
+
+	// 削除を確定
+	remove := func(filePath string, scanned bool) {
+		hooks.remove(filePath)
+		if hooks.allows(filePath) {
+			handle(Change{Operation: OPERATION_DELETE, Path: filePath, Timestamp: time.Now(), Scanned: scanned})
+		}
+	}
+
+	settleTicker := time.NewTicker(WRITE_SETTLE_CHECK_INTERVAL)
+	defer settleTicker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			// 停止や取り外しで確定できなかったファイル操作も記録（監視対象の外へ移動したファイルは名前変更のみ）
+			for _, pendingRename := range renames {
+				delete(pending, pendingRename.Path)
+				rename(pendingRename.Path, "")
+			}
+			for _, change := range pending {
+				complete(*change, true)
+			}
+			return nil
+
+		case <-settleTicker.C:
+			// 最後の書き込みから一定時間経過したファイル操作を確定（名前変更の後半を待っている場合は除く）
+			now := time.Now()
+			for filePath, change := range pending {
+				if now.Sub(change.Timestamp) >= WRITE_SETTLE_DELAY && FindRename(renames, filePath) < 0 {
+					delete(pending, filePath)
+					complete(*change, false)
+				}
+			}
+
+			// 対になるCreateが来ない名前変更は監視対象の外への移動として確定（書き込み中だった場合は作成を記録しない）
+			for len(renames) > 0 && now.Sub(renames[0].At) >= RENAME_PAIR_WINDOW {
+				tree.Forget(renames[0].Path)
+				delete(pending, renames[0].Path)
+				rename(renames[0].Path, "")
+				renames = renames[1:]
+			}
+			for oldPath, renamed := range renamedDirs {
+				if now.Sub(renamed.At) >= RENAME_PAIR_WINDOW {
+					delete(renamedDirs, oldPath)
+				}
+			}
+
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+
+			filePath := event.Name
+			renamedFrom := RenamedFrom(event)
+			receivedAt := time.Now()
+
+			switch {
+			case event.Has(fsnotify.Rename):
+				// ディレクトリ自身の名前変更の通知で監視が解除されるため、変更後のパスを監視し直す
+				if renamed, exists := renamedDirs[filePath]; exists {
+					delete(renamedDirs, filePath)
+					tree.AddTree(renamed.Path, nil)
+					hooks.coverage()
+					continue
+				}
+
+				// 名前変更の前半（変更前のパス）
+				if FindRename(renames, filePath) < 0 {
+					renames = append(renames, PendingRename{Path: filePath, At: receivedAt})
+				}
+
+			case event.Has(fsnotify.Create) && PairRename(renames, filePath, renamedFrom, receivedAt) >= 0:
+				// 名前変更の後半（変更後のパス）を前半と対にする
+				index := PairRename(renames, filePath, renamedFrom, receivedAt)
+				oldPath := renames[index].Path
+				renames = append(renames[:index], renames[index+1:]...)
+
+				if fileInfo, err := os.Stat(filePath); err == nil && fileInfo.IsDir() {
+					renamedDirs[oldPath] = PendingRename{Path: filePath, At: time.Now()}
+					tree.Forget(oldPath)
+					tree.AddTree(filePath, nil)
+					hooks.coverage()
+				}
+
+				// 書き込み中のファイルは変更後のパスで確定させる
+				if change, exists := pending[oldPath]; exists {
+					delete(pending, oldPath)
+					change.Path = filePath
+					pending[filePath] = change
+				}
+
+				rename(oldPath, filePath)
+
+			case event.Has(fsnotify.Create) || event.Has(fsnotify.Write):
+				// ディレクトリの場合は監視対象に追加（監視を始める前にコピーされたファイルも記録）
+				if fileInfo, err := os.Stat(filePath); err == nil && fileInfo.IsDir() {
+					tree.AddTree(filePath, func(path string, info os.FileInfo) {
+						track(path, fsnotify.Create, false)
+					})
+					hooks.coverage()
+					continue
+				}
+
+				track(filePath, event.Op, false)
+
+			case event.Has(fsnotify.Remove):
+				tree.Forget(filePath)
+
+				// 書き込み中に削除されたファイルは作成を記録してから削除を記録
+				if change, exists := pending[filePath]; exists {
+					delete(pending, filePath)
+					complete(*change, true)
+				}
+
+				remove(filePath, false)
+			}
+
+		case batch := <-scans:
+			// 監視できない部分木の変更を監視している場合と同様に記録
+			for _, event := range batch {
+				if event.Has(fsnotify.Remove) {
+					remove(event.Name, true)
+					continue
+				}
+				track(event.Name, event.Op, true)
+			}
+			hooks.coverage()
+
+		case write, ok := <-writes:
+			if !ok {
+				// 通知が終了した場合はfsnotifyのみで継続
+				writes = nil
+				continue
+			}
+
+			// 書き込んだプロセスを保留中のファイル操作に記録
+			if change := track(write.Path, fsnotify.Write, false); change != nil {
+				process := write.Process
+				change.Process = &process
+			}
+
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return nil
+			}
+			log.Printf("[%s] Failed watcher: %v\n", w.name, err)
+			w.health.RecordError(err)
+		}
+	}
+}
@@ -0,0 +1,250 @@
+package filewatch
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mniyk/endpoint-security-and-monitoring-tools/internal/procinfo"
+	"github.com/mniyk/endpoint-security-and-monitoring-tools/module"
+)
+
+func TestWatcherHooks(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "existing.txt"), "old")
+
+	var mu sync.Mutex
+	var visited, updated []string
+	ready := make(chan struct{})
+	writes := make(chan Write, 1)
+	changes := make(chan Change, 16)
+
+	hooks := Hooks{
+		Visit: func(path string, info os.FileInfo) {
+			mu.Lock()
+			visited = append(visited, path)
+			mu.Unlock()
+		},
+		Update: func(path string) {
+			mu.Lock()
+			updated = append(updated, path)
+			mu.Unlock()
+		},
+		Allow: func(paths ...string) bool {
+			for _, path := range paths {
+				if path != "" && !strings.HasSuffix(path, ".tmp") {
+					return true
+				}
+			}
+			return false
+		},
+		Ready:  func() { close(ready) },
+		Writes: writes,
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var wg sync.WaitGroup
+	watcher := NewWatcher("test", Limits{ScanInterval: time.Minute}, module.NewHealthTracker())
+	done := make(chan error, 1)
+	go func() {
+		done <- watcher.Run(ctx, &wg, []string{root}, hooks, func(change Change) {
+			changes <- change
+		})
+	}()
+
+	select {
+	case <-ready:
+	case <-time.After(5 * time.Second):
+		t.Fatal("watcher did not become ready")
+	}
+
+	written := filepath.Join(root, "copied.txt")
+	writeFile(t, written, "data")
+	writeFile(t, filepath.Join(root, "skip.tmp"), "temp")
+	writes <- Write{Path: written, Process: procinfo.Process{PID: 42, Executable: "/usr/bin/cp"}}
+
+	select {
+	case change := <-changes:
+		if change.Path != written || change.Operation != OPERATION_CREATE || change.Size != 4 || change.Interrupted {
+			t.Fatalf("unexpected change %+v", change)
+		}
+		if change.Process == nil || change.Process.PID != 42 {
+			t.Fatalf("writer not attached: %+v", change.Process)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("no change reported")
+	}
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	wg.Wait()
+
+	select {
+	case change := <-changes:
+		t.Fatalf("filtered file reported: %+v", change)
+	default:
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(visited) != 1 || visited[0] != filepath.Join(root, "existing.txt") {
+		t.Fatalf("visited %v", visited)
+	}
+	if !slices.Contains(updated, written) || !slices.Contains(updated, filepath.Join(root, "skip.tmp")) {
+		t.Fatalf("updated %v, want both new files", updated)
+	}
+}
+
+// ルートを監視するWatcherを開始して確定したファイル操作のチャネルを取得
+func startWatcher(t *testing.T, root string) <-chan Change {
+	t.Helper()
+
+	ready := make(chan struct{})
+	changes := make(chan Change, 16)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var wg sync.WaitGroup
+	watcher := NewWatcher("test", Limits{ScanInterval: time.Minute}, module.NewHealthTracker())
+	done := make(chan error, 1)
+	go func() {
+		done <- watcher.Run(ctx, &wg, []string{root}, Hooks{Ready: func() { close(ready) }}, func(change Change) {
+			changes <- change
+		})
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+		wg.Wait()
+	})
+
+	select {
+	case <-ready:
+	case <-time.After(5 * time.Second):
+		t.Fatal("watcher did not become ready")
+	}
+
+	return changes
+}
+
+// 確定したファイル操作を件数分、または期限まで取得
+func collectChanges(changes <-chan Change, count int, timeout time.Duration) []Change {
+	var collected []Change
+	deadline := time.After(timeout)
+	for len(collected) < count {
+		select {
+		case change := <-changes:
+			collected = append(collected, change)
+		case <-deadline:
+			return collected
+		}
+	}
+
+	return collected
+}
+
+func TestPairRename(t *testing.T) {
+	now := time.Now()
+	renames := []PendingRename{
+		{Path: "/media/usb/a/report.txt", At: now.Add(-2 * RENAME_PAIR_WINDOW)},
+		{Path: "/media/usb/a/notes.txt", At: now.Add(-RENAME_PAIR_WINDOW / 2)},
+		{Path: "/media/usb/a/draft.txt", At: now.Add(-RENAME_PAIR_WINDOW / 2)},
+	}
+
+	tests := []struct {
+		name        string
+		newPath     string
+		renamedFrom string
+		want        int
+	}{
+		{"cookie", "/media/usb/b/final.txt", "/media/usb/a/draft.txt", 2},
+		{"cookie not pending", "/media/usb/b/final.txt", "/media/usb/c/other.txt", -1},
+		{"same name within window", "/media/usb/b/notes.txt", "", 1},
+		{"same name after window", "/media/usb/b/report.txt", "", -1},
+		{"unrelated create", "/media/usb/a/new.txt", "", -1},
+		{"same path", "/media/usb/a/notes.txt", "", -1},
+	}
+
+	for _, tt := range tests {
+		if got := PairRename(renames, tt.newPath, tt.renamedFrom, now); got != tt.want {
+			t.Errorf("%s: PairRename = %d, want %d", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestWatcherDoesNotPairMoveOutWithUnrelatedCreate(t *testing.T) {
+	root := t.TempDir()
+	outside := t.TempDir()
+	writeFile(t, filepath.Join(root, "report.txt"), "report")
+
+	changes := startWatcher(t, root)
+
+	if err := os.Rename(filepath.Join(root, "report.txt"), filepath.Join(outside, "report.txt")); err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, filepath.Join(root, "notes.txt"), "notes")
+
+	collected := collectChanges(changes, 2, 10*time.Second)
+	if len(collected) != 2 {
+		t.Fatalf("got %d changes, want 2: %+v", len(collected), collected)
+	}
+
+	moved, created := collected[0], collected[1]
+	if moved.Operation != OPERATION_RENAMED || moved.OldPath != filepath.Join(root, "report.txt") || moved.Path != "" || !moved.Moved {
+		t.Errorf("move out of the tree reported as %+v", moved)
+	}
+	if created.Operation != OPERATION_CREATE || created.Path != filepath.Join(root, "notes.txt") || created.Size != 5 {
+		t.Errorf("unrelated create reported as %+v", created)
+	}
+}
+
+func TestWatcherPairsRenameByCookie(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("rename cookies are only reported by inotify")
+	}
+
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "draft.txt"), "draft")
+
+	changes := startWatcher(t, root)
+
+	if err := os.Rename(filepath.Join(root, "draft.txt"), filepath.Join(root, "final.txt")); err != nil {
+		t.Fatal(err)
+	}
+
+	collected := collectChanges(changes, 1, 5*time.Second)
+	if len(collected) != 1 {
+		t.Fatal("rename not reported")
+	}
+	if change := collected[0]; change.Operation != OPERATION_RENAMED || change.OldPath != filepath.Join(root, "draft.txt") || change.Path != filepath.Join(root, "final.txt") || change.Moved {
+		t.Fatalf("rename reported as %+v", change)
+	}
+}
+
+func TestWatcherCancelsWriteMovedOutOfTree(t *testing.T) {
+	root := t.TempDir()
+	outside := t.TempDir()
+
+	changes := startWatcher(t, root)
+
+	// 書き込みが落ち着く前に監視対象の外へ移動
+	writeFile(t, filepath.Join(root, "partial.txt"), "partial")
+	if err := os.Rename(filepath.Join(root, "partial.txt"), filepath.Join(outside, "partial.txt")); err != nil {
+		t.Fatal(err)
+	}
+
+	collected := collectChanges(changes, 2, WRITE_SETTLE_DELAY+2*time.Second)
+	if len(collected) != 1 {
+		t.Fatalf("got %d changes, want only the move: %+v", len(collected), collected)
+	}
+	if change := collected[0]; change.Operation != OPERATION_RENAMED || change.OldPath != filepath.Join(root, "partial.txt") || change.Path != "" {
+		t.Fatalf("move out of the tree reported as %+v", change)
+	}
+}
@@ -0,0 +1,98 @@
+package inspection
+
+import (
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"log"
+	"os"
+)
+
+const (
+	DEFAULT_HASH_MAX_SIZE_MB = 1024
+
+	HASH_STATUS_HASHED    = "hashed"
+	HASH_STATUS_TOO_LARGE = "skipped_too_large"
+	HASH_STATUS_FAILED    = "failed"
+)
+
+// 対応しているハッシュアルゴリズム
+var hashAlgorithms = map[string]func() hash.Hash{
+	"sha256": sha256.New,
+	"sha1":   sha1.New,
+	"md5":    md5.New,
+}
+
+// ファイルのハッシュを計算する構造体
+type Hasher struct {
+	name       string // ログに出力するモジュール名
+	algorithms []string
+	maxSize    int64
+}
+
+// 新しいHasherを作成
+func NewHasher(name string, algorithms []string, maxSize int64) *Hasher {
+	return &Hasher{
+		name:       name,
+		algorithms: algorithms,
+		maxSize:    maxSize,
+	}
+}
+
+// ハッシュアルゴリズムの設定を検証
+func ValidateHashAlgorithms(algorithms []string) error {
+	for _, algorithm := range algorithms {
+		if _, ok := hashAlgorithms[algorithm]; !ok {
+			return fmt.Errorf("unknown hash algorithm %q", algorithm)
+		}
+	}
+
+	return nil
+}
+
+// ハッシュ計算が有効かどうかを確認
+func (h *Hasher) Enabled() bool {
+	return len(h.algorithms) > 0
+}
+
+// ファイルを一度だけ読み込んですべてのアルゴリズムのハッシュを計算
+func (h *Hasher) HashFile(path string) (string, map[string]string) {
+	file, err := os.Open(path)
+	if err != nil {
+		log.Printf("[%s] Failed opening %s for hashing: %v\n", h.name, path, err)
+		return HASH_STATUS_FAILED, nil
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return HASH_STATUS_FAILED, nil
+	}
+	if h.maxSize > 0 && info.Size() > h.maxSize {
+		return HASH_STATUS_TOO_LARGE, nil
+	}
+
+	hashers := make(map[string]hash.Hash, len(h.algorithms))
+	writers := make([]io.Writer, 0, len(h.algorithms))
+	for _, algorithm := range h.algorithms {
+		hasher := hashAlgorithms[algorithm]()
+		hashers[algorithm] = hasher
+		writers = append(writers, hasher)
+	}
+
+	if _, err := io.Copy(io.MultiWriter(writers...), file); err != nil {
+		log.Printf("[%s] Failed hashing %s: %v\n", h.name, path, err)
+		return HASH_STATUS_FAILED, nil
+	}
+
+	hashes := make(map[string]string, len(hashers))
+	for algorithm, hasher := range hashers {
+		hashes[algorithm] = hex.EncodeToString(hasher.Sum(nil))
+	}
+
+	return HASH_STATUS_HASHED, hashes
+}
@@ -0,0 +1,250 @@
+package inspection
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/mniyk/endpoint-security-and-monitoring-tools/internal/config"
+	"github.com/mniyk/endpoint-security-and-monitoring-tools/internal/dlp"
+	"github.com/mniyk/endpoint-security-and-monitoring-tools/internal/sourceindex"
+	"github.com/mniyk/endpoint-security-and-monitoring-tools/module"
+)
+
+const (
+	STATUS_BUSY        = "skipped_busy"        // ワーカーが空いていないため検査しなかった
+	STATUS_NOT_SETTLED = "skipped_not_settled" // 書き込みが落ち着く前に取り外しや削除で確定した
+	STATUS_STOPPED     = "skipped_stopped"     // 検査する前にモジュールが停止した
+)
+
+// ハッシュ計算、内容検査と元ファイルの照合の設定の構造体
+type Config struct {
+	HashAlgorithms    []string           `json:"hash_algorithms"`    // "sha256" "sha1" "md5"（空の場合はハッシュを計算しない）
+	HashMaxSizeMB     int                `json:"hash_max_size_mb"`   // ハッシュを計算するファイルサイズの上限
+	DLP               dlp.Config         `json:"dlp"`                // 書き込まれたファイルの内容検査
+	SourceIndex       sourceindex.Config `json:"source_index"`       // 機密ディレクトリの元ファイルの索引
+	InspectionWorkers int                `json:"inspection_workers"` // 同時にハッシュ計算と内容検査をするワーカー数
+}
+
+// モジュールの設定から新しいConfigを作成
+func NewConfig(moduleConfig config.Config) (Config, error) {
+	inspectionConfig := Config{
+		HashAlgorithms:    moduleConfig.Strings("hash_algorithms", []string{"sha256"}),
+		HashMaxSizeMB:     moduleConfig.Int("hash_max_size_mb", DEFAULT_HASH_MAX_SIZE_MB),
+		DLP:               dlp.NewConfig(),
+		SourceIndex:       sourceindex.NewConfig(),
+		InspectionWorkers: moduleConfig.Int("inspection_workers", DEFAULT_WORKERS),
+	}
+
+	if err := moduleConfig.Decode("dlp", &inspectionConfig.DLP); err != nil {
+		return Config{}, err
+	}
+	if err := moduleConfig.Decode("source_index", &inspectionConfig.SourceIndex); err != nil {
+		return Config{}, err
+	}
+
+	return inspectionConfig, nil
+}
+
+// レジストリに登録するオプション（ファイル操作を記録するモジュールで共通）
+func Options() []module.OptionSpec {
+	return []module.OptionSpec{
+		{Name: "hash_algorithms", Type: "array", Default: []string{"sha256"}, Description: "Hashes of written files: sha256, sha1 and md5 (empty disables hashing)"},
+		{Name: "hash_max_size_mb", Type: "number", Default: DEFAULT_HASH_MAX_SIZE_MB, Description: "Files larger than this are not hashed"},
+		{Name: "dlp", Type: "object", Default: dlp.NewConfig(), Description: "Content inspection of written files: enabled, max_size_mb, credit_cards, national_ids, regexes and keywords"},
+		{Name: "source_index", Type: "object", Default: sourceindex.NewConfig(), Description: "Index of sensitive directories to find the original of copied files: enabled, directories, max_file_size_mb and refresh_interval"},
+		{Name: "inspection_workers", Type: "number", Default: DEFAULT_WORKERS, Description: "Number of files hashed and inspected concurrently"},
+	}
+}
+
+// 検査の結果の構造体
+type Result struct {
+	HashStatus string             // ハッシュ計算の結果（無効の場合は空）
+	Hashes     map[string]string  // アルゴリズム名とハッシュ値
+	DLPStatus  string             // 内容検査の結果（無効の場合は空）
+	DLPMatches map[string]int     // 検出器名と一致件数（一致した内容は保持しない）
+	Source     *sourceindex.Entry // 同じ内容の元ファイル（見つからない場合はnil）
+}
+
+// イベントデータに検査の結果を追加
+func (r Result) AddTo(data map[string]interface{}) {
+	if r.HashStatus != "" {
+		data["hash_status"] = r.HashStatus
+	}
+	for algorithm, value := range r.Hashes {
+		data[algorithm] = value
+	}
+	if r.Source != nil {
+		data["source_path"] = r.Source.Path
+		data["source_owner"] = r.Source.Owner
+		data["source_label"] = r.Source.Label
+	}
+	if r.DLPStatus != "" {
+		data["dlp_status"] = r.DLPStatus
+	}
+	if r.DLPStatus == dlp.STATUS_SCANNED {
+		result := dlp.Result{Status: r.DLPStatus, Matches: r.DLPMatches}
+		data["sensitive"] = len(r.DLPMatches) > 0
+		data["dlp_detectors"] = result.Detectors()
+		data["dlp_matches"] = r.DLPMatches
+	}
+}
+
+// 書き込まれたファイルのハッシュ計算、内容検査と元ファイルの照合をする構造体
+type Inspector struct {
+	name        string // ログに出力するモジュール名
+	config      Config
+	hasher      *Hasher
+	scanner     *dlp.Scanner
+	sourceIndex *sourceindex.Index
+	pool        *Pool
+	health      *module.HealthTracker
+}
+
+// 設定を検証して新しいInspectorを作成
+func NewInspector(name string, inspectionConfig Config, health *module.HealthTracker) (*Inspector, error) {
+	if err := ValidateHashAlgorithms(inspectionConfig.HashAlgorithms); err != nil {
+		return nil, fmt.Errorf("invalid hash settings: %w", err)
+	}
+
+	inspector := &Inspector{
+		name:   name,
+		config: inspectionConfig,
+		hasher: NewHasher(name, inspectionConfig.HashAlgorithms, int64(inspectionConfig.HashMaxSizeMB)*1024*1024),
+		pool:   NewPool(inspectionConfig.InspectionWorkers),
+		health: health,
+	}
+
+	// 内容検査の検出器を作成
+	if inspectionConfig.DLP.Enabled {
+		scanner, err := dlp.NewScanner(inspectionConfig.DLP)
+		if err != nil {
+			return nil, fmt.Errorf("invalid dlp settings: %w", err)
+		}
+		inspector.scanner = scanner
+	}
+
+	// 元ファイルの索引はSHA-256で照合する
+	if inspectionConfig.SourceIndex.Enabled {
+		if !slices.Contains(inspectionConfig.HashAlgorithms, "sha256") {
+			return nil, errors.New("source_index requires sha256 in hash_algorithms")
+		}
+		inspector.sourceIndex = sourceindex.NewIndex(inspectionConfig.SourceIndex.Directories, inspectionConfig.SourceIndex.MaxFileSizeMB)
+	}
+
+	return inspector, nil
+}
+
+// ハッシュ計算または内容検査が有効かどうかを確認
+func (i *Inspector) Enabled() bool {
+	return i.hasher.Enabled() || i.scanner != nil
+}
+
+// ワーカーと元ファイルの索引の更新を開始
+func (i *Inspector) Start(ctx context.Context, wg *sync.WaitGroup) {
+	i.pool.Start(ctx, wg)
+
+	if i.sourceIndex != nil {
+		module.Go(ctx, wg, func() {
+			i.refreshSourceIndex(ctx)
+		})
+	}
+}
+
+// 元ファイルの索引を作成し、一定間隔で作り直す
+func (i *Inspector) refreshSourceIndex(ctx context.Context) {
+	interval := time.Duration(i.config.SourceIndex.RefreshInterval)
+	if interval <= 0 {
+		interval = sourceindex.DEFAULT_REFRESH_INTERVAL
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		started := time.Now()
+		if err := i.sourceIndex.Build(ctx); err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			log.Printf("[%s] Failed building source index: %v\n", i.name, err)
+			i.health.RecordError(err)
+		} else {
+			log.Printf("[%s] Built source index of %d files in %s\n", i.name, i.sourceIndex.Len(), time.Since(started).Round(time.Millisecond))
+			i.health.SetDetail("source_index_files", i.sourceIndex.Len())
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// ファイルのハッシュを計算して内容を検査
+func (i *Inspector) Inspect(path string) Result {
+	var result Result
+
+	if i.hasher.Enabled() {
+		result.HashStatus, result.Hashes = i.hasher.HashFile(path)
+	}
+
+	// 同じ内容の元ファイルを検索
+	if i.sourceIndex != nil && result.HashStatus == HASH_STATUS_HASHED {
+		if source, ok := i.sourceIndex.Lookup(result.Hashes["sha256"]); ok {
+			result.Source = &source
+		}
+	}
+
+	if i.scanner != nil {
+		scanned, err := i.scanner.ScanFile(path)
+		if err != nil {
+			log.Printf("[%s] Failed scanning %s: %v\n", i.name, path, err)
+		}
+		result.DLPStatus = scanned.Status
+		result.DLPMatches = scanned.Matches
+	}
+
+	return result
+}
+
+// 検査しなかった理由を結果として取得
+func (i *Inspector) Skipped(status string) Result {
+	var result Result
+	if i.hasher.Enabled() {
+		result.HashStatus = status
+	}
+	if i.scanner != nil {
+		result.DLPStatus = status
+	}
+
+	return result
+}
+
+// ワーカーでファイルを検査してdoneに結果を渡す（検査できない場合は理由を結果としてすぐに渡す）
+func (i *Inspector) Submit(path string, done func(Result)) {
+	if !i.Enabled() {
+		done(Result{})
+		return
+	}
+
+	// ワーカーが空いていない場合は検査なしで記録（監視を止めないため）
+	submitted := i.pool.Submit(func() {
+		done(i.Inspect(path))
+	}, func() {
+		done(i.Skipped(STATUS_STOPPED))
+	})
+	if !submitted {
+		done(i.Skipped(STATUS_BUSY))
+	}
+}
+
+// 検査以外の処理をワーカーで実行（停止中またはキューが一杯の場合はfalse）
+func (i *Inspector) Run(run func(), skip func()) bool {
+	return i.pool.Submit(run, skip)
+}
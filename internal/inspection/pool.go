@@ -0,0 +1,103 @@
+package inspection
+
+import (
+	"context"
+	"sync"
+
+	"github.com/mniyk/endpoint-security-and-monitoring-tools/module"
+)
+
+const (
+	DEFAULT_WORKERS  = 2
+	QUEUE_PER_WORKER = 16
+)
+
+// 検査の依頼の構造体
+type job struct {
+	run  func() // 検査して記録
+	skip func() // 停止時にキューに残っていた場合に検査せずに記録
+}
+
+// 書き込まれたファイルのハッシュ計算や内容検査を限られた数のワーカーで実行する構造体
+type Pool struct {
+	workers int
+	mu      sync.RWMutex
+	jobs    chan job // 停止中はnil
+}
+
+// 新しいPoolを作成
+func NewPool(workers int) *Pool {
+	if workers <= 0 {
+		workers = DEFAULT_WORKERS
+	}
+
+	return &Pool{workers: workers}
+}
+
+// ワーカーを開始（停止時にキューに残った依頼は検査せずに記録）
+func (p *Pool) Start(ctx context.Context, wg *sync.WaitGroup) {
+	// 前回の開始時の依頼を実行しないよう開始ごとにキューを作成
+	jobs := make(chan job, p.workers*QUEUE_PER_WORKER)
+	p.mu.Lock()
+	p.jobs = jobs
+	p.mu.Unlock()
+
+	var workers sync.WaitGroup
+	for i := 0; i < p.workers; i++ {
+		workers.Add(1)
+		module.Go(ctx, wg, func() {
+			defer workers.Done()
+
+			// 停止した後は残りの依頼を実行しない
+			for ctx.Err() == nil {
+				select {
+				case <-ctx.Done():
+					return
+				case job := <-jobs:
+					// 停止と同時に受け取った場合はselectがどちらを選ぶか不定のため確認し直す
+					if ctx.Err() != nil {
+						job.skip()
+						return
+					}
+					job.run()
+				}
+			}
+		})
+	}
+
+	module.Go(ctx, wg, func() {
+		<-ctx.Done()
+
+		// 新しい依頼を受け付けないようにしてから残りを記録
+		p.mu.Lock()
+		p.jobs = nil
+		p.mu.Unlock()
+		workers.Wait()
+
+		for {
+			select {
+			case job := <-jobs:
+				job.skip()
+			default:
+				return
+			}
+		}
+	})
+}
+
+// 検査を依頼（停止中またはキューが一杯の場合はfalse）
+func (p *Pool) Submit(run func(), skip func()) bool {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	if p.jobs == nil {
+		return false
+	}
+
+	select {
+	case p.jobs <- job{run: run, skip: skip}:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,80 @@
+package inspection
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestPoolSkipsQueuedJobsOnStop(t *testing.T) {
+	pool := NewPool(1)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var wg sync.WaitGroup
+	pool.Start(ctx, &wg)
+
+	// ワーカーを占有してから依頼をキューに残す
+	started := make(chan struct{})
+	release := make(chan struct{})
+	var ran, skipped atomic.Int32
+	if !pool.Submit(func() { close(started); <-release; ran.Add(1) }, func() { skipped.Add(1) }) {
+		t.Fatal("Submit to an idle pool failed")
+	}
+	<-started
+
+	for i := 0; i < 3; i++ {
+		if !pool.Submit(func() { ran.Add(1) }, func() { skipped.Add(1) }) {
+			t.Fatalf("Submit %d failed", i)
+		}
+	}
+
+	cancel()
+	close(release)
+	wg.Wait()
+
+	if ran.Load() != 1 || skipped.Load() != 3 {
+		t.Fatalf("ran %d skipped %d, want ran 1 skipped 3", ran.Load(), skipped.Load())
+	}
+
+	// 停止中は受け付けない
+	if pool.Submit(func() {}, func() {}) {
+		t.Fatal("Submit to a stopped pool succeeded")
+	}
+
+	// 再度開始した場合は新しい依頼のみを実行
+	ctx, cancel = context.WithCancel(context.Background())
+	defer cancel()
+	pool.Start(ctx, &wg)
+
+	done := make(chan struct{})
+	if !pool.Submit(func() { close(done) }, func() {}) {
+		t.Fatal("Submit after restart failed")
+	}
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("job submitted after restart did not run")
+	}
+	if ran.Load() != 1 || skipped.Load() != 3 {
+		t.Fatalf("jobs from the previous start ran again: ran %d skipped %d", ran.Load(), skipped.Load())
+	}
+
+	cancel()
+	wg.Wait()
+}
+
+func TestPoolFull(t *testing.T) {
+	pool := NewPool(1)
+
+	// ワーカーを開始せずにキューを一杯にする
+	pool.jobs = make(chan job, 1)
+
+	if !pool.Submit(func() {}, func() {}) {
+		t.Fatal("Submit to an empty queue failed")
+	}
+	if pool.Submit(func() {}, func() {}) {
+		t.Fatal("Submit to a full queue succeeded")
+	}
+}
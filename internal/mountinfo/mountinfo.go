@@ -0,0 +1,146 @@
+package mountinfo
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// /proc/self/mountinfoの1行分のマウント情報の構造体
+type Mount struct {
+	ID           int
+	ParentID     int
+	Major        int
+	Minor        int
+	Root         string
+	MountPoint   string
+	Options      string
+	FSType       string
+	Source       string
+	SuperOptions string
+}
+
+// デバイス番号を"major:minor"形式で取得
+func (m Mount) DeviceNumber() string {
+	return fmt.Sprintf("%d:%d", m.Major, m.Minor)
+}
+
+// 読み取り専用でマウントされているかどうかを確認
+func (m Mount) ReadOnly() bool {
+	for _, option := range strings.Split(m.Options, ",") {
+		if option == "ro" {
+			return true
+		}
+	}
+
+	return false
+}
+
+// 指定されたパスのmountinfoを読み込み
+func ReadFile(path string) ([]Mount, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return Parse(file)
+}
+
+// mountinfo形式のテキストを解析
+func Parse(r io.Reader) ([]Mount, error) {
+	var mounts []Mount
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
+			continue
+		}
+
+		mount, err := parseLine(line)
+		if err != nil {
+			return nil, err
+		}
+		mounts = append(mounts, mount)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return mounts, nil
+}
+
+// mountinfoの1行を解析
+// 例: 36 35 98:0 /mnt1 /mnt2 rw,noatime master:1 - ext3 /dev/root rw,errors=continue
+func parseLine(line string) (Mount, error) {
+	fields := strings.Fields(line)
+
+	// 任意フィールドの終端を示す"-"を探す
+	separator := -1
+	for i := 6; i < len(fields); i++ {
+		if fields[i] == "-" {
+			separator = i
+			break
+		}
+	}
+	if separator < 0 || len(fields) < separator+3 {
+		return Mount{}, fmt.Errorf("malformed mountinfo line: %q", line)
+	}
+
+	id, err := strconv.Atoi(fields[0])
+	if err != nil {
+		return Mount{}, fmt.Errorf("malformed mount id in %q: %w", line, err)
+	}
+	parentID, err := strconv.Atoi(fields[1])
+	if err != nil {
+		return Mount{}, fmt.Errorf("malformed parent id in %q: %w", line, err)
+	}
+
+	var major, minor int
+	if _, err := fmt.Sscanf(fields[2], "%d:%d", &major, &minor); err != nil {
+		return Mount{}, fmt.Errorf("malformed device number in %q: %w", line, err)
+	}
+
+	mount := Mount{
+		ID:         id,
+		ParentID:   parentID,
+		Major:      major,
+		Minor:      minor,
+		Root:       unescape(fields[3]),
+		MountPoint: unescape(fields[4]),
+		Options:    fields[5],
+		FSType:     fields[separator+1],
+		Source:     unescape(fields[separator+2]),
+	}
+	if len(fields) > separator+3 {
+		mount.SuperOptions = fields[separator+3]
+	}
+
+	return mount, nil
+}
+
+// 8進数でエスケープされた空白などの文字を復元（例: "\040"）
+func unescape(value string) string {
+	if !strings.Contains(value, "\\") {
+		return value
+	}
+
+	var builder strings.Builder
+	for i := 0; i < len(value); i++ {
+		if value[i] == '\\' && i+4 <= len(value) {
+			if code, err := strconv.ParseUint(value[i+1:i+4], 8, 8); err == nil {
+				builder.WriteByte(byte(code))
+				i += 3
+				continue
+			}
+		}
+		builder.WriteByte(value[i])
+	}
+
+	return builder.String()
+}
@@ -0,0 +1,49 @@
+package procinfo
+
+// キャッシュのキー（PIDの再利用を区別するため開始時刻を含む）
+type cacheKey struct {
+	pid       int
+	startTime uint64
+}
+
+// 同じプロセスの情報を何度も読み込まないようにするキャッシュの構造体（複数のゴルーチンから使用しない）
+type Cache struct {
+	procfsRoot string
+	maxSize    int
+	processes  map[cacheKey]Process
+}
+
+// 新しいCacheを作成（maxSizeを超えた場合はすべて破棄）
+func NewCache(procfsRoot string, maxSize int) *Cache {
+	return &Cache{
+		procfsRoot: procfsRoot,
+		maxSize:    maxSize,
+		processes:  make(map[cacheKey]Process),
+	}
+}
+
+// キャッシュを使ってプロセスの情報を取得（取得に失敗した場合はキャッシュせず次回に再取得）
+func (c *Cache) Lookup(pid int) Process {
+	startTime, err := StartTime(c.procfsRoot, pid)
+	if err != nil {
+		process, _ := Lookup(c.procfsRoot, pid)
+		return process
+	}
+
+	key := cacheKey{pid: pid, startTime: startTime}
+	if process, ok := c.processes[key]; ok {
+		return process
+	}
+
+	process, err := Lookup(c.procfsRoot, pid)
+	if err != nil || process.UID < 0 {
+		return process
+	}
+
+	if len(c.processes) >= c.maxSize {
+		clear(c.processes)
+	}
+	c.processes[key] = process
+
+	return process
+}
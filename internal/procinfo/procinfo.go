@@ -0,0 +1,150 @@
+package procinfo
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// procfsの時刻の単位（LinuxのUSER_HZは常に100）
+const CLOCK_TICKS_PER_SECOND = 100
+
+// プロセスの情報の構造体
+type Process struct {
+	PID         int
+	Executable  string
+	CommandLine string
+	UID         int
+	User        string
+	StartTime   uint64 // 起動からの経過時間（クロックティック、取得できない場合は0）
+}
+
+// procfsからプロセスの情報を取得（Linux以外では取得できない）
+func Lookup(procfsRoot string, pid int) (Process, error) {
+	root := filepath.Join(procfsRoot, strconv.Itoa(pid))
+
+	process := Process{PID: pid, UID: -1}
+
+	uid, err := readUID(filepath.Join(root, "status"))
+	if err != nil {
+		return process, fmt.Errorf("failed reading process %d: %w", pid, err)
+	}
+	process.UID = uid
+	if startTime, err := StartTime(procfsRoot, pid); err == nil {
+		process.StartTime = startTime
+	}
+	if owner, err := user.LookupId(strconv.Itoa(uid)); err == nil {
+		process.User = owner.Username
+	}
+
+	// 権限がない場合や終了済みの場合は取得できた項目のみ返す
+	if executable, err := os.Readlink(filepath.Join(root, "exe")); err == nil {
+		process.Executable = executable
+	}
+	if cmdline, err := os.ReadFile(filepath.Join(root, "cmdline")); err == nil {
+		process.CommandLine = strings.TrimSpace(string(bytes.ReplaceAll(bytes.TrimRight(cmdline, "\x00"), []byte{0}, []byte{' '})))
+	}
+
+	return process, nil
+}
+
+// イベントデータにプロセスの情報を追加（prefixは"process_"や"reader_"など）
+func (p Process) AddTo(data map[string]interface{}, prefix string) {
+	data[prefix+"pid"] = p.PID
+	data[prefix+"executable"] = p.Executable
+	data[prefix+"command_line"] = p.CommandLine
+	data[prefix+"uid"] = p.UID
+	if p.User != "" {
+		data[prefix+"user"] = p.User
+	}
+}
+
+// statusファイルから実ユーザーIDを取得（例: "Uid:\t1000\t1000\t1000\t1000"）
+func readUID(path string) (int, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return -1, err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) >= 2 && fields[0] == "Uid:" {
+			return strconv.Atoi(fields[1])
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return -1, err
+	}
+
+	return -1, fmt.Errorf("no Uid in %s", path)
+}
+
+// statファイルの22番目の項目からプロセスの開始時刻を取得（PIDの再利用を区別するために使用）
+func StartTime(procfsRoot string, pid int) (uint64, error) {
+	data, err := os.ReadFile(filepath.Join(procfsRoot, strconv.Itoa(pid), "stat"))
+	if err != nil {
+		return 0, err
+	}
+
+	// プロセス名に空白や括弧が含まれる場合に備えて最後の")"以降を分割（3番目の項目から始まる）
+	end := bytes.LastIndexByte(data, ')')
+	if end < 0 {
+		return 0, fmt.Errorf("malformed stat of process %d", pid)
+	}
+	fields := strings.Fields(string(data[end+1:]))
+	if len(fields) < 20 {
+		return 0, fmt.Errorf("malformed stat of process %d", pid)
+	}
+
+	return strconv.ParseUint(fields[19], 10, 64)
+}
+
+// プロセスの開始時刻を取得（起動時刻にstatの22番目の項目の経過時間を加算）
+func StartedAt(procfsRoot string, pid int) (time.Time, error) {
+	startTime, err := StartTime(procfsRoot, pid)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	bootTime, err := readBootTime(filepath.Join(procfsRoot, "stat"))
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	elapsed := time.Duration(startTime) * time.Second / CLOCK_TICKS_PER_SECOND
+	return bootTime.Add(elapsed), nil
+}
+
+// statファイルからシステムの起動時刻を取得（例: "btime 1700000000"）
+func readBootTime(path string) (time.Time, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return time.Time{}, err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) >= 2 && fields[0] == "btime" {
+			seconds, err := strconv.ParseInt(fields[1], 10, 64)
+			if err != nil {
+				return time.Time{}, err
+			}
+			return time.Unix(seconds, 0), nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return time.Time{}, err
+	}
+
+	return time.Time{}, fmt.Errorf("no btime in %s", path)
+}
@@ -0,0 +1,147 @@
+package procinfo
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// 偽のprocfsにプロセスのファイルを作成
+func writeProcess(t *testing.T, root string, pid string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(root, pid, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// 22番目の項目がstarttimeになるstatの内容を作成
+func statLine(pid string, comm string, startTime string) string {
+	fields := make([]string, 0, 50)
+	fields = append(fields, pid, "("+comm+")", "S")
+	for i := 4; i <= 21; i++ {
+		fields = append(fields, "0")
+	}
+	fields = append(fields, startTime, "12345", "678")
+	return strings.Join(fields, " ") + "\n"
+}
+
+func TestStartTime(t *testing.T) {
+	root := t.TempDir()
+	writeProcess(t, root, "100", map[string]string{"stat": statLine("100", "cp", "4242")})
+	writeProcess(t, root, "101", map[string]string{"stat": statLine("101", "evil) S 1 2 (name", "777")})
+	writeProcess(t, root, "102", map[string]string{"stat": "102 (short) S 1\n"})
+
+	tests := []struct {
+		pid  int
+		want uint64
+		ok   bool
+	}{
+		{100, 4242, true},
+		{101, 777, true},
+		{102, 0, false},
+		{103, 0, false},
+	}
+
+	for _, tt := range tests {
+		got, err := StartTime(root, tt.pid)
+		if (err == nil) != tt.ok || got != tt.want {
+			t.Errorf("StartTime(%d) = %d, %v, want %d ok %v", tt.pid, got, err, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestLookup(t *testing.T) {
+	root := t.TempDir()
+	writeProcess(t, root, "200", map[string]string{
+		"status":  "Name:\tcp\nUid:\t0\t0\t0\t0\n",
+		"stat":    statLine("200", "cp", "900"),
+		"cmdline": "cp\x00a.txt\x00/media/usb/\x00",
+	})
+
+	process, err := Lookup(root, 200)
+	if err != nil {
+		t.Fatalf("Lookup: %v", err)
+	}
+	if process.UID != 0 || process.StartTime != 900 || process.CommandLine != "cp a.txt /media/usb/" {
+		t.Fatalf("unexpected process %+v", process)
+	}
+
+	process, err = Lookup(root, 201)
+	if err == nil || process.UID != -1 {
+		t.Fatalf("missing process: %+v, %v", process, err)
+	}
+}
+
+func TestStartedAt(t *testing.T) {
+	root := t.TempDir()
+	writeProcess(t, root, "300", map[string]string{"stat": statLine("300", "wodim", "12345")})
+	if err := os.WriteFile(filepath.Join(root, "stat"), []byte("cpu  1 2 3 4\nbtime 1700000000\nprocesses 42\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	startedAt, err := StartedAt(root, 300)
+	if err != nil {
+		t.Fatalf("StartedAt: %v", err)
+	}
+	if want := time.Unix(1700000123, 450000000); !startedAt.Equal(want) {
+		t.Fatalf("StartedAt = %s, want %s", startedAt, want)
+	}
+
+	if _, err := StartedAt(root, 301); err == nil {
+		t.Fatal("StartedAt of a missing process succeeded")
+	}
+}
+
+// 開始時刻とコマンドラインを指定してプロセスを作成
+func writeCachedProcess(t *testing.T, root string, pid string, startTime string, cmdline string) {
+	t.Helper()
+	writeProcess(t, root, pid, map[string]string{
+		"status":  "Uid:\t1000\t1000\t1000\t1000\n",
+		"stat":    statLine(pid, "proc", startTime),
+		"cmdline": cmdline,
+	})
+}
+
+func TestCache(t *testing.T) {
+	root := t.TempDir()
+	cache := NewCache(root, 16)
+
+	writeCachedProcess(t, root, "300", "100", "cp")
+	if process := cache.Lookup(300); process.CommandLine != "cp" {
+		t.Fatalf("first lookup: %+v", process)
+	}
+
+	// PIDが再利用された場合は別のプロセスとして取得し直す
+	writeCachedProcess(t, root, "300", "200", "rsync")
+	if process := cache.Lookup(300); process.CommandLine != "rsync" {
+		t.Fatalf("reused pid returned cached process: %+v", process)
+	}
+
+	// 取得に失敗したプロセスはキャッシュしない
+	writeProcess(t, root, "301", map[string]string{"stat": statLine("301", "proc", "500")})
+	if process := cache.Lookup(301); process.UID != -1 {
+		t.Fatalf("lookup without status: %+v", process)
+	}
+	if _, ok := cache.processes[cacheKey{pid: 301, startTime: 500}]; ok {
+		t.Fatal("failed lookup was cached")
+	}
+	writeCachedProcess(t, root, "301", "500", "dd")
+	if process := cache.Lookup(301); process.CommandLine != "dd" {
+		t.Fatalf("lookup after failure: %+v", process)
+	}
+
+	if err := os.RemoveAll(filepath.Join(root, "300")); err != nil {
+		t.Fatal(err)
+	}
+	if process := cache.Lookup(300); process.UID != -1 {
+		t.Fatalf("exited process: %+v", process)
+	}
+}
@@ -0,0 +1,25 @@
+//go:build !windows
+
+package sourceindex
+
+import (
+	"os"
+	"os/user"
+	"strconv"
+	"syscall"
+)
+
+// ファイルの所有者のユーザー名を取得（取得できない場合はUID）
+func fileOwner(path string, info os.FileInfo) string {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return ""
+	}
+
+	uid := strconv.FormatUint(uint64(stat.Uid), 10)
+	if owner, err := user.LookupId(uid); err == nil {
+		return owner.Username
+	}
+
+	return uid
+}
@@ -0,0 +1,30 @@
+package sourceindex
+
+import (
+	"os"
+
+	"golang.org/x/sys/windows"
+)
+
+// ファイルの所有者のアカウント名を取得（例: DOMAIN\user）
+func fileOwner(path string, info os.FileInfo) string {
+	descriptor, err := windows.GetNamedSecurityInfo(path, windows.SE_FILE_OBJECT, windows.OWNER_SECURITY_INFORMATION)
+	if err != nil {
+		return ""
+	}
+
+	owner, _, err := descriptor.Owner()
+	if err != nil {
+		return ""
+	}
+
+	account, domain, _, err := owner.LookupAccount("")
+	if err != nil {
+		return owner.String()
+	}
+	if domain == "" {
+		return account
+	}
+
+	return domain + `\` + account
+}
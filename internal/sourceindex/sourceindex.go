@@ -0,0 +1,176 @@
+package sourceindex
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/mniyk/endpoint-security-and-monitoring-tools/internal/config"
+)
+
+const (
+	DEFAULT_MAX_FILE_SIZE_MB = 256
+	DEFAULT_REFRESH_INTERVAL = 1 * time.Hour
+)
+
+// 元ファイルの索引の設定の構造体
+type Config struct {
+	Enabled         bool            `json:"enabled"`
+	Directories     []Directory     `json:"directories"`
+	MaxFileSizeMB   int             `json:"max_file_size_mb"`
+	RefreshInterval config.Duration `json:"refresh_interval"` // 索引を作り直す間隔
+}
+
+// 新しいConfigをデフォルト値で作成
+func NewConfig() Config {
+	return Config{
+		MaxFileSizeMB:   DEFAULT_MAX_FILE_SIZE_MB,
+		RefreshInterval: config.Duration(DEFAULT_REFRESH_INTERVAL),
+	}
+}
+
+// 索引を作成する機密ディレクトリの設定の構造体
+type Directory struct {
+	Path  string `json:"path"`
+	Label string `json:"label"` // 分類ラベル（例: "Confidential"）
+}
+
+// 索引に登録された元ファイルの情報の構造体
+type Entry struct {
+	Path    string
+	Owner   string
+	Label   string
+	Size    int64
+	ModTime time.Time
+}
+
+// 機密ディレクトリのファイルをSHA-256で引けるようにする索引の構造体
+type Index struct {
+	directories []Directory
+	maxSize     int64
+
+	mu     sync.RWMutex
+	byHash map[string]Entry
+	byPath map[string]cachedHash // 変更されていないファイルを再計算しないためのキャッシュ
+}
+
+// パスごとに計算済みのハッシュの構造体
+type cachedHash struct {
+	hash    string
+	size    int64
+	modTime time.Time
+}
+
+// 新しいIndexを作成
+func NewIndex(directories []Directory, maxSizeMB int) *Index {
+	if maxSizeMB <= 0 {
+		maxSizeMB = DEFAULT_MAX_FILE_SIZE_MB
+	}
+
+	return &Index{
+		directories: directories,
+		maxSize:     int64(maxSizeMB) * 1024 * 1024,
+		byHash:      make(map[string]Entry),
+		byPath:      make(map[string]cachedHash),
+	}
+}
+
+// 機密ディレクトリを走査して索引を作り直す（変更されていないファイルはキャッシュを使用）
+func (i *Index) Build(ctx context.Context) error {
+	i.mu.RLock()
+	previous := i.byPath
+	i.mu.RUnlock()
+
+	byHash := make(map[string]Entry)
+	byPath := make(map[string]cachedHash)
+
+	for _, directory := range i.directories {
+		err := filepath.WalkDir(directory.Path, func(path string, entry fs.DirEntry, err error) error {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			if err != nil || !entry.Type().IsRegular() {
+				// 読み込めないディレクトリやファイル以外はスキップ
+				return nil
+			}
+
+			// 空のファイルはすべて同じハッシュになり、無関係なファイルと一致するため登録しない
+			info, err := entry.Info()
+			if err != nil || info.Size() == 0 || info.Size() > i.maxSize {
+				return nil
+			}
+
+			cached, ok := previous[path]
+			if !ok || cached.size != info.Size() || !cached.modTime.Equal(info.ModTime()) {
+				hash, err := hashFile(path)
+				if err != nil {
+					return nil
+				}
+				cached = cachedHash{hash: hash, size: info.Size(), modTime: info.ModTime()}
+			}
+			byPath[path] = cached
+
+			// 同じ内容のファイルが複数ある場合は最初に見つかったものを使用
+			if _, exists := byHash[cached.hash]; !exists {
+				byHash[cached.hash] = Entry{
+					Path:    path,
+					Owner:   fileOwner(path, info),
+					Label:   directory.Label,
+					Size:    info.Size(),
+					ModTime: info.ModTime(),
+				}
+			}
+
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	i.mu.Lock()
+	i.byHash = byHash
+	i.byPath = byPath
+	i.mu.Unlock()
+
+	return nil
+}
+
+// SHA-256から元ファイルを検索
+func (i *Index) Lookup(sha256Hash string) (Entry, bool) {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
+	entry, ok := i.byHash[sha256Hash]
+	return entry, ok
+}
+
+// 索引に登録されたファイル数を取得
+func (i *Index) Len() int {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
+	return len(i.byPath)
+}
+
+// ファイルのSHA-256を計算
+func hashFile(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	hasher := sha256.New()
+	if _, err := io.Copy(hasher, file); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(hasher.Sum(nil)), nil
+}
@@ -0,0 +1,86 @@
+package sourceindex
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// 内容のSHA-256を取得
+func sha256Of(content string) string {
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
+
+func TestBuildAndLookup(t *testing.T) {
+	root := t.TempDir()
+	files := map[string]string{
+		"finance/budget.xlsx": "quarterly budget",
+		"finance/copy.xlsx":   "quarterly budget",
+		"finance/empty.txt":   "",
+		"hr/.keep":            "",
+		"hr/salaries.csv":     "name,salary",
+	}
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	index := NewIndex([]Directory{
+		{Path: filepath.Join(root, "finance"), Label: "Confidential"},
+		{Path: filepath.Join(root, "hr"), Label: "Restricted"},
+	}, 1)
+	if err := index.Build(context.Background()); err != nil {
+		t.Fatalf("Build: %v", err)
+	}
+
+	// 空のファイルは登録しない
+	if got := index.Len(); got != 3 {
+		t.Errorf("Len() = %d, want 3", got)
+	}
+	if entry, ok := index.Lookup(sha256Of("")); ok {
+		t.Errorf("empty file indexed as %s", entry.Path)
+	}
+
+	tests := []struct {
+		content string
+		path    string
+		label   string
+	}{
+		{"quarterly budget", filepath.Join(root, "finance", "budget.xlsx"), "Confidential"},
+		{"name,salary", filepath.Join(root, "hr", "salaries.csv"), "Restricted"},
+	}
+	for _, tt := range tests {
+		entry, ok := index.Lookup(sha256Of(tt.content))
+		if !ok {
+			t.Errorf("Lookup(%q) found nothing", tt.content)
+			continue
+		}
+		if entry.Path != tt.path || entry.Label != tt.label || entry.Size != int64(len(tt.content)) {
+			t.Errorf("Lookup(%q) = %+v, want path %s label %s", tt.content, entry, tt.path, tt.label)
+		}
+	}
+}
+
+func TestBuildCanceled(t *testing.T) {
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, "report.docx"), []byte("report"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	index := NewIndex([]Directory{{Path: root}}, 1)
+	if err := index.Build(ctx); err == nil {
+		t.Fatal("Build succeeded with a canceled context")
+	}
+}
@@ -0,0 +1,16 @@
+package sysfs
+
+import (
+	"os"
+	"strings"
+)
+
+// sysfsやprocfsの属性ファイルの値を取得（読み込めない場合は空）
+func ReadValue(path string) string {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+
+	return strings.TrimSpace(string(data))
+}
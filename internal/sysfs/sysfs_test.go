@@ -0,0 +1,21 @@
+package sysfs
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestReadValue(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "removable")
+	if err := os.WriteFile(path, []byte("1\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := ReadValue(path); got != "1" {
+		t.Fatalf("ReadValue = %q, want %q", got, "1")
+	}
+	if got := ReadValue(path + ".missing"); got != "" {
+		t.Fatalf("ReadValue of a missing file = %q, want empty", got)
+	}
+}
@@ -0,0 +1,79 @@
+package testutil
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mniyk/endpoint-security-and-monitoring-tools/internal/inspection"
+	"github.com/mniyk/endpoint-security-and-monitoring-tools/module"
+)
+
+// イベントを待機する最大時間
+const EVENT_WAIT_TIMEOUT = 5 * time.Second
+
+// 送信せずに破棄するEventSink
+type DiscardSink struct{}
+
+func (DiscardSink) Add(event module.Event) error { return nil }
+
+// 記録したイベントを取得できるモジュール
+type EventSource interface {
+	GetEvents() []module.Event
+}
+
+// 一定時間内にイベントが記録されるまで待機
+func WaitForEvents(t *testing.T, source EventSource, count int) []module.Event {
+	t.Helper()
+
+	deadline := time.Now().Add(EVENT_WAIT_TIMEOUT)
+	for time.Now().Before(deadline) {
+		if events := source.GetEvents(); len(events) >= count {
+			return events
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	t.Fatalf("expected %d events, got %d", count, len(source.GetEvents()))
+	return nil
+}
+
+// イベントを種類ごとに取得（同じ種類が複数ある場合は最後のもの）
+func EventsByType(events []module.Event) map[string]module.Event {
+	byType := make(map[string]module.Event)
+	for _, event := range events {
+		byType[event.Type] = event
+	}
+
+	return byType
+}
+
+// 検査のワーカーを開始（テストの終了時に停止して終了を待機）
+func StartInspector(t *testing.T, inspector *inspection.Inspector, wg *sync.WaitGroup) {
+	t.Helper()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	inspector.Start(ctx, wg)
+	t.Cleanup(func() {
+		cancel()
+		wg.Wait()
+	})
+}
+
+// テスト用のファイルを作成（キーはrootからの相対パス、親ディレクトリも作成）
+func WriteFiles(t *testing.T, root string, files map[string]string) {
+	t.Helper()
+
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
@@ -3,6 +3,7 @@ package transmission
 import (
 	"encoding/json"
 	"log"
+	"sync"
 	"time"
 
 	"github.com/mniyk/endpoint-security-and-monitoring-tools/module"
@@ -21,6 +22,7 @@ type EventSender struct {
 	BatchSize    int
 	eventQueue   []module.Event
 	LastSendTime time.Time
+	mu           sync.Mutex
 }
 
 // 新しいEventSenderを作成
@@ -34,6 +36,9 @@ func NewEventSender(batchSize int) *EventSender {
 
 // イベントをキューに追加
 func (s *EventSender) Add(event module.Event) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
 	s.eventQueue = append(s.eventQueue, event)
 
 	return nil
@@ -41,6 +46,9 @@ func (s *EventSender) Add(event module.Event) error {
 
 // 保留中のすべてのイベントを送信
 func (s *EventSender) Flush() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
 	if len(s.eventQueue) == 0 {
 		return nil
 	}
@@ -62,10 +70,16 @@ func (s *EventSender) Flush() error {
 
 // バッチサイズを超えたかどうかを確認
 func (s *EventSender) IsOverBatchSize() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
 	return len(s.eventQueue) >= s.BatchSize
 }
 
 // 最後の送信から一定時間経過したかどうかを確認
 func (s *EventSender) IsOverTime() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
 	return time.Since(s.LastSendTime) > 5*time.Second
 }
@@ -1,6 +1,7 @@
 package bluetooth
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"os/exec"
@@ -9,7 +10,6 @@ import (
 	"time"
 
 	"github.com/mniyk/endpoint-security-and-monitoring-tools/internal/config"
-	"github.com/mniyk/endpoint-security-and-monitoring-tools/internal/transmission"
 	"github.com/mniyk/endpoint-security-and-monitoring-tools/internal/userinfo"
 	"github.com/mniyk/endpoint-security-and-monitoring-tools/module"
 )
@@ -28,7 +28,7 @@ type MonitorConfig struct {
 // 新しいMonitorConfigを作成
 func NewMonitorConfig(moduleConfig config.Config) *MonitorConfig {
 	return &MonitorConfig{
-		EnableBluetooth: true,
+		EnableBluetooth: moduleConfig.Bool("enable_bluetooth", true),
 	}
 }
 
@@ -47,38 +47,54 @@ type FileTransfer struct {
 type Monitor struct {
 	events          []module.Event
 	config          MonitorConfig
-	stopChan        chan struct{}
+	cancel          context.CancelFunc
+	lifecycleMu     sync.Mutex
+	wg              sync.WaitGroup
 	eventsMu        sync.RWMutex
 	activeTransfers map[string]time.Time // 重複検出防止用
 	userInfo        *userinfo.UserInfo
-	eventDispatcher transmission.EventDispatcher
+	eventDispatcher module.EventSink
+	health          *module.HealthTracker
 }
 
 // 新しいMonitorを作成
-func NewMonitor(config *MonitorConfig, userInfo *userinfo.UserInfo, eventDispatcher transmission.EventDispatcher) *Monitor {
+func NewMonitor(config *MonitorConfig, userInfo *userinfo.UserInfo, eventDispatcher module.EventSink) *Monitor {
 	return &Monitor{
 		config:          *config,
 		events:          make([]module.Event, 0),
 		activeTransfers: make(map[string]time.Time),
 		userInfo:        userInfo,
 		eventDispatcher: eventDispatcher,
+		health:          module.NewHealthTracker(),
 	}
 }
 
 // モジュールを初期化
 func (m *Monitor) Initialize() error {
-	m.stopChan = make(chan struct{})
+	m.health.SetState(module.StateInitializing)
 	log.Printf("[%s] Initialize...", MODULE_NAME)
 	return nil
 }
 
 // モニタリングを開始
-func (m *Monitor) Start() error {
+func (m *Monitor) Start(ctx context.Context) error {
+	m.lifecycleMu.Lock()
+	defer m.lifecycleMu.Unlock()
+
+	if m.cancel != nil {
+		return fmt.Errorf("%s already started", MODULE_NAME)
+	}
+
 	log.Printf("[%s] Start...", MODULE_NAME)
 
+	ctx, m.cancel = context.WithCancel(ctx)
+	m.health.SetState(module.StateRunning)
+
 	// Bluetooth ファイル転送監視を開始
 	if m.config.EnableBluetooth {
-		go m.startBluetoothFileTransferMonitoring()
+		module.Go(ctx, &m.wg, func() {
+			m.startBluetoothFileTransferMonitoring(ctx)
+		})
 		log.Printf("[%s] Started Bluetooth file transfer monitoring", MODULE_NAME)
 	}
 
@@ -86,29 +102,39 @@ func (m *Monitor) Start() error {
 }
 
 // Bluetooth ファイル転送監視を開始
-func (m *Monitor) startBluetoothFileTransferMonitoring() {
+func (m *Monitor) startBluetoothFileTransferMonitoring(ctx context.Context) {
+	ticker := time.NewTicker(MONITOR_INTERVAL)
+	defer ticker.Stop()
+
+	// 監視者にループを登録
+	heartbeat := module.StartHeartbeat(ctx, "file_transfer_monitoring")
+	defer heartbeat.Stop()
+
 	for {
+		// 監視者へ生存を通知
+		heartbeat.Beat()
+
+		// Bluetoothファイル転送を検出
+		transfers := m.detectBluetoothFileTransfers(ctx)
+		for _, transfer := range transfers {
+			m.logFileTransfer(transfer)
+		}
+		m.health.MarkScan()
+
 		select {
-		case <-m.stopChan:
+		case <-ctx.Done():
 			return
-		default:
-			// Bluetoothファイル転送を検出
-			transfers := m.detectBluetoothFileTransfers()
-			for _, transfer := range transfers {
-				m.logFileTransfer(transfer)
-			}
-
-			time.Sleep(MONITOR_INTERVAL)
+		case <-ticker.C:
 		}
 	}
 }
 
 // Bluetoothファイル転送を検出（送信のみ）
-func (m *Monitor) detectBluetoothFileTransfers() []FileTransfer {
+func (m *Monitor) detectBluetoothFileTransfers(ctx context.Context) []FileTransfer {
 	var transfers []FileTransfer
 
 	// Bluetoothファイル転送ウィザード（fsquirt.exe）を監視
-	cmd := exec.Command("tasklist", "/FI", "IMAGENAME eq fsquirt.exe", "/FO", "CSV")
+	cmd := exec.CommandContext(ctx, "tasklist", "/FI", "IMAGENAME eq fsquirt.exe", "/FO", "CSV")
 	output, err := cmd.Output()
 	if err == nil && strings.Contains(string(output), "fsquirt.exe") {
 		// 重複検出防止
@@ -167,12 +193,36 @@ func (m *Monitor) logFileTransfer(transfer FileTransfer) {
 }
 
 // モニタリングを停止
-func (m *Monitor) Stop() error {
+func (m *Monitor) Stop(ctx context.Context) error {
+	m.lifecycleMu.Lock()
+	cancel := m.cancel
+	m.cancel = nil
+	m.lifecycleMu.Unlock()
+
+	// 停止済みの場合は何もしない
+	if cancel == nil {
+		return nil
+	}
+
 	log.Printf("[%s] Stop...", MODULE_NAME)
-	close(m.stopChan)
+
+	cancel()
+
+	// 監視ゴルーチンの終了を待機
+	if err := module.Wait(ctx, &m.wg); err != nil {
+		return fmt.Errorf("%s did not stop: %w", MODULE_NAME, err)
+	}
+
+	m.health.SetState(module.StateStopped)
+
 	return nil
 }
 
+// モジュールの稼働状況を取得
+func (m *Monitor) Health() module.Health {
+	return m.health.Snapshot()
+}
+
 // モジュールが検出したイベントを取得
 func (m *Monitor) GetEvents() []module.Event {
 	m.eventsMu.RLock()
@@ -199,6 +249,8 @@ func (m *Monitor) addEvent(eventType string, severity int, data map[string]inter
 	m.events = append(m.events, event)
 	m.eventsMu.Unlock()
 
+	m.health.RecordEvent()
+
 	log.Printf("[%s] Detection new event: %s Importance: %d", MODULE_NAME, eventType, severity)
 
 	// イベントをdispatcherに送信（非同期）
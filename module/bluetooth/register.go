@@ -0,0 +1,26 @@
+package bluetooth
+
+import (
+	"github.com/mniyk/endpoint-security-and-monitoring-tools/internal/config"
+	"github.com/mniyk/endpoint-security-and-monitoring-tools/module"
+)
+
+const REGISTRY_NAME = "bluetooth_file_transfer_monitoring"
+
+// モジュールをレジストリに登録
+func init() {
+	module.Register(module.Descriptor{
+		Name:        REGISTRY_NAME,
+		Description: "Tracking of file transfers to Bluetooth",
+		Options: []module.OptionSpec{
+			{Name: "enable_bluetooth", Type: "bool", Default: true, Description: "Watch the Bluetooth file transfer wizard"},
+		},
+		Platforms: []string{"windows"},
+		Factory:   newModule,
+	})
+}
+
+// 設定からモジュールを生成
+func newModule(moduleConfig config.Config, deps module.Dependencies) (module.Module, error) {
+	return NewMonitor(NewMonitorConfig(moduleConfig), deps.UserInfo, deps.EventSink), nil
+}
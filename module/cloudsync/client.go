@@ -0,0 +1,107 @@
+package cloudsync
+
+import (
+	"context"
+	"errors"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/mniyk/endpoint-security-and-monitoring-tools/internal/fanotify"
+	"github.com/mniyk/endpoint-security-and-monitoring-tools/internal/filewatch"
+	"github.com/mniyk/endpoint-security-and-monitoring-tools/internal/procinfo"
+	"github.com/mniyk/endpoint-security-and-monitoring-tools/module"
+)
+
+const (
+	MAX_CACHED_PROCESSES = 1024
+	FILE_WRITE_BUFFER    = 64
+)
+
+// 同期フォルダへの書き込みをfanotifyで記録し、書き込んだプロセスを通知（使えない場合はnilのチャネル）
+func (m *Monitor) startWriterTracking(ctx context.Context, folder SyncFolder) <-chan filewatch.Write {
+	if !m.config.IgnoreClientWrites {
+		return nil
+	}
+
+	notifier, err := fanotify.Open()
+	if err == nil {
+		if err = notifier.MarkMount(folder.Path, fanotify.CLOSE_WRITE); err != nil {
+			notifier.Close()
+		}
+	}
+	if err != nil {
+		// 書き込んだプロセスを特定できないため、クライアント自身の書き込みも記録する
+		m.health.SetDetail("client_write_filter", "unavailable")
+		if !errors.Is(err, fanotify.ErrUnsupported) {
+			log.Printf("[%s] fanotify unavailable for folder %s, writes of the sync client are recorded: %v\n", MODULE_NAME, folder.Path, err)
+			m.health.RecordError(err)
+		}
+		return nil
+	}
+	m.health.SetDetail("client_write_filter", "fanotify")
+
+	writes := make(chan filewatch.Write, FILE_WRITE_BUFFER)
+
+	module.Go(ctx, &m.wg, func() {
+		defer notifier.Close()
+		defer close(writes)
+
+		m.readWriteEvents(ctx, notifier, folder, writes)
+	})
+
+	return writes
+}
+
+// fanotifyのイベントのうち同期フォルダ内の書き込みをチャネルに送信
+func (m *Monitor) readWriteEvents(ctx context.Context, notifier *fanotify.Notifier, folder SyncFolder, writes chan<- filewatch.Write) {
+	root := strings.TrimSuffix(filepath.Clean(folder.Path), string(os.PathSeparator)) + string(os.PathSeparator)
+	processes := procinfo.NewCache(m.config.ProcfsRoot, MAX_CACHED_PROCESSES)
+
+	// 受信待ちの間もPOLL_TIMEOUTごとに生存を通知
+	heartbeat := module.StartHeartbeat(ctx, "writes:"+folder.Path)
+	defer heartbeat.Stop()
+
+	for {
+		heartbeat.Beat()
+
+		events, err := notifier.Read(ctx)
+		if err != nil {
+			if ctx.Err() == nil {
+				log.Printf("[%s] Failed reading fanotify events: %v\n", MODULE_NAME, err)
+				m.health.RecordError(err)
+			}
+			return
+		}
+
+		for _, event := range events {
+			// 同じマウントの同期フォルダ外のファイルを除外
+			if !event.IsWrite() || !strings.HasPrefix(event.Path, root) {
+				continue
+			}
+
+			select {
+			case writes <- filewatch.Write{Path: event.Path, Process: processes.Lookup(event.PID)}:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}
+}
+
+// 同期クライアントのプロセスかどうかを確認（実行ファイル名が取得できない場合はコマンドラインの先頭）
+func (m *Monitor) isSyncClient(folder SyncFolder, process procinfo.Process) bool {
+	executable := strings.TrimSuffix(process.Executable, " (deleted)")
+	if executable == "" {
+		fields := strings.Fields(process.CommandLine)
+		if len(fields) == 0 {
+			return false
+		}
+		executable = fields[0]
+	}
+
+	name := strings.ToLower(filepath.Base(executable))
+	return slices.Contains(clientExecutables[folder.Provider], name) || slices.Contains(m.config.ClientExecutables, name)
+}
@@ -0,0 +1,324 @@
+package cloudsync
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+const (
+	PROVIDER_DROPBOX      = "dropbox"
+	PROVIDER_ONEDRIVE     = "onedrive"
+	PROVIDER_GOOGLE_DRIVE = "google_drive"
+
+	ACCOUNT_PERSONAL  = "personal"
+	ACCOUNT_CORPORATE = "corporate"
+	ACCOUNT_UNKNOWN   = "unknown"
+
+	DISCOVERY_CONFIG        = "config"        // 設定で指定
+	DISCOVERY_CLIENT_CONFIG = "client_config" // 同期クライアントの設定ファイルから取得
+	DISCOVERY_DEFAULT       = "default"       // 既定の場所に存在
+)
+
+// ホームディレクトリの置き場所のうち、ユーザーのものではないディレクトリ
+var nonUserHomes = map[string]bool{
+	"public":       true,
+	"default":      true,
+	"default user": true,
+	"all users":    true,
+	"shared":       true,
+	"lost+found":   true,
+}
+
+// 個人向けのメールアドレスのドメイン
+var consumerDomains = []string{"gmail.com", "googlemail.com", "outlook.com", "hotmail.com", "live.com", "msn.com", "icloud.com", "me.com", "yahoo.com"}
+
+// 同期クライアントが作成するメタデータ（ユーザーのファイルではないため記録しない、長い16進数はOneDriveのフォルダの識別子）
+var clientMetadataNames = map[string]bool{
+	".dropbox":                              true,
+	".dropbox.cache":                        true,
+	".dropbox.device":                       true,
+	".tmp.drivedownload":                    true,
+	".tmp.driveupload":                      true,
+	".849c9593-d756-4e56-8d6e-42412f2a707b": true,
+	"desktop.ini":                           true,
+	".ds_store":                             true,
+	"icon\r":                                true,
+}
+
+// 同期クライアントの実行ファイル名（クライアント自身の書き込みはダウンロードのため記録しない）
+var clientExecutables = map[string][]string{
+	PROVIDER_DROPBOX:      {"dropbox"},
+	PROVIDER_ONEDRIVE:     {"onedrive"},
+	PROVIDER_GOOGLE_DRIVE: {"insync", "google-drive-ocamlfuse"},
+}
+
+// 設定で指定する同期フォルダの構造体
+type FolderConfig struct {
+	Path     string `json:"path"`     // "~/" で始まる場合は各ユーザーのホームディレクトリからの相対パス
+	Provider string `json:"provider"` // 例: "dropbox" "box"
+	Account  string `json:"account"`  // "personal" "corporate"（空の場合は不明）
+}
+
+// 同期フォルダの構造体
+type SyncFolder struct {
+	Path         string
+	Provider     string
+	Owner        string // ホームディレクトリのユーザー名
+	Account      string // "personal" "corporate" "unknown"
+	Email        string // アカウントのメールアドレス（取得できた場合のみ）
+	Organization string // 法人アカウントの組織名（取得できた場合のみ）
+	Discovery    string
+}
+
+// イベントデータに同期フォルダの情報を追加
+func (f SyncFolder) addTo(data map[string]interface{}) {
+	data["provider"] = f.Provider
+	data["sync_folder"] = f.Path
+	data["folder_owner"] = f.Owner
+	data["account"] = f.Account
+	data["account_email"] = f.Email
+	data["organization"] = f.Organization
+	data["discovery"] = f.Discovery
+}
+
+// 同期フォルダを探す構造体
+type finder struct {
+	homeRoots        []string
+	folders          []FolderConfig
+	corporateDomains []string
+}
+
+// 各ユーザーの同期フォルダを探す（キーはパス、設定、クライアントの設定ファイル、既定の場所の順に優先）
+func (f *finder) find() map[string]SyncFolder {
+	found := make(map[string]SyncFolder)
+	add := func(folder SyncFolder) {
+		folder.Path = filepath.Clean(folder.Path)
+		if _, exists := found[folder.Path]; exists {
+			return
+		}
+		if info, err := os.Stat(folder.Path); err != nil || !info.IsDir() {
+			return
+		}
+		found[folder.Path] = folder
+	}
+
+	homes := f.listHomes()
+
+	for _, folder := range f.folders {
+		account := folder.Account
+		if account == "" {
+			account = ACCOUNT_UNKNOWN
+		}
+
+		if !strings.HasPrefix(folder.Path, "~/") {
+			add(SyncFolder{Path: folder.Path, Provider: folder.Provider, Account: account, Discovery: DISCOVERY_CONFIG})
+			continue
+		}
+		for owner, home := range homes {
+			add(SyncFolder{Path: filepath.Join(home, folder.Path[2:]), Provider: folder.Provider, Owner: owner, Account: account, Discovery: DISCOVERY_CONFIG})
+		}
+	}
+
+	for owner, home := range homes {
+		for _, folder := range f.findInHome(home) {
+			folder.Owner = owner
+			add(folder)
+		}
+	}
+
+	return found
+}
+
+// ホームディレクトリを列挙（キーはユーザー名）
+func (f *finder) listHomes() map[string]string {
+	homes := make(map[string]string)
+	for _, root := range f.homeRoots {
+		entries, err := os.ReadDir(root)
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			if !entry.IsDir() || strings.HasPrefix(entry.Name(), ".") || nonUserHomes[strings.ToLower(entry.Name())] {
+				continue
+			}
+			homes[entry.Name()] = filepath.Join(root, entry.Name())
+		}
+	}
+
+	return homes
+}
+
+// ホームディレクトリ内の同期フォルダを探す（クライアントの設定ファイルから取得したものを先に返す）
+func (f *finder) findInHome(home string) []SyncFolder {
+	folders := dropboxFolders(home)
+	folders = append(folders, linuxOneDriveFolders(home)...)
+
+	// Dropboxの既定のフォルダ（設定ファイルから取得できた場合は重複として除外）
+	folders = append(folders, SyncFolder{Path: filepath.Join(home, "Dropbox"), Provider: PROVIDER_DROPBOX, Account: ACCOUNT_UNKNOWN, Discovery: DISCOVERY_DEFAULT})
+
+	// WindowsのOneDrive（法人アカウントは "OneDrive - 組織名"）
+	if entries, err := os.ReadDir(home); err == nil {
+		for _, entry := range entries {
+			name := entry.Name()
+			switch {
+			case name == "OneDrive":
+				folders = append(folders, SyncFolder{Path: filepath.Join(home, name), Provider: PROVIDER_ONEDRIVE, Account: personalOneDriveAccount(home), Discovery: DISCOVERY_DEFAULT})
+			case strings.HasPrefix(name, "OneDrive - "):
+				folders = append(folders, SyncFolder{Path: filepath.Join(home, name), Provider: PROVIDER_ONEDRIVE, Account: ACCOUNT_CORPORATE, Organization: strings.TrimPrefix(name, "OneDrive - "), Discovery: DISCOVERY_DEFAULT})
+			}
+		}
+	}
+
+	// macOSのFile Provider（例: "OneDrive-Personal" "OneDrive-組織名" "GoogleDrive-user@example.com" "Dropbox"）
+	cloudStorage := filepath.Join(home, "Library", "CloudStorage")
+	if entries, err := os.ReadDir(cloudStorage); err == nil {
+		for _, entry := range entries {
+			name := entry.Name()
+			path := filepath.Join(cloudStorage, name)
+			switch {
+			case name == "OneDrive-Personal":
+				folders = append(folders, SyncFolder{Path: path, Provider: PROVIDER_ONEDRIVE, Account: ACCOUNT_PERSONAL, Discovery: DISCOVERY_DEFAULT})
+			case strings.HasPrefix(name, "OneDrive-"):
+				folders = append(folders, SyncFolder{Path: path, Provider: PROVIDER_ONEDRIVE, Account: ACCOUNT_CORPORATE, Organization: strings.TrimPrefix(name, "OneDrive-"), Discovery: DISCOVERY_DEFAULT})
+			case strings.HasPrefix(name, "GoogleDrive-"):
+				email := strings.TrimPrefix(name, "GoogleDrive-")
+				folders = append(folders, SyncFolder{Path: path, Provider: PROVIDER_GOOGLE_DRIVE, Account: f.emailAccount(email), Email: email, Discovery: DISCOVERY_DEFAULT})
+			case name == "Dropbox" || strings.HasPrefix(name, "Dropbox-"):
+				folders = append(folders, SyncFolder{Path: path, Provider: PROVIDER_DROPBOX, Account: ACCOUNT_UNKNOWN, Discovery: DISCOVERY_DEFAULT})
+			}
+		}
+	}
+
+	// Googleドライブのミラーリングのフォルダと旧クライアントのフォルダ（ストリーミングの仮想ドライブは対象外）
+	for _, name := range []string{"My Drive", "Google Drive"} {
+		folders = append(folders, SyncFolder{Path: filepath.Join(home, name), Provider: PROVIDER_GOOGLE_DRIVE, Account: ACCOUNT_UNKNOWN, Discovery: DISCOVERY_DEFAULT})
+	}
+
+	return folders
+}
+
+// Dropboxのinfo.jsonから同期フォルダを取得（"personal" と "business" のアカウントごとにパスを記録）
+func dropboxFolders(home string) []SyncFolder {
+	candidates := []string{
+		filepath.Join(home, ".dropbox", "info.json"),
+		filepath.Join(home, "AppData", "Local", "Dropbox", "info.json"),
+		filepath.Join(home, "AppData", "Roaming", "Dropbox", "info.json"),
+	}
+
+	var folders []SyncFolder
+	for _, candidate := range candidates {
+		data, err := os.ReadFile(candidate)
+		if err != nil {
+			continue
+		}
+
+		var accounts map[string]struct {
+			Path   string `json:"path"`
+			IsTeam bool   `json:"is_team"`
+		}
+		if err := json.Unmarshal(data, &accounts); err != nil {
+			continue
+		}
+
+		for kind, account := range accounts {
+			if account.Path == "" {
+				continue
+			}
+			folder := SyncFolder{Path: account.Path, Provider: PROVIDER_DROPBOX, Account: ACCOUNT_PERSONAL, Discovery: DISCOVERY_CLIENT_CONFIG}
+			if kind == "business" || account.IsTeam {
+				folder.Account = ACCOUNT_CORPORATE
+			}
+			folders = append(folders, folder)
+		}
+	}
+
+	return folders
+}
+
+// LinuxのOneDriveクライアント（abraunegg/onedrive）の設定ファイルから同期フォルダを取得
+// 例: sync_dir = "~/OneDrive"
+func linuxOneDriveFolders(home string) []SyncFolder {
+	file, err := os.Open(filepath.Join(home, ".config", "onedrive", "config"))
+	if err != nil {
+		return nil
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		key, value, found := strings.Cut(scanner.Text(), "=")
+		if !found || strings.TrimSpace(key) != "sync_dir" {
+			continue
+		}
+
+		path := strings.Trim(strings.TrimSpace(value), `"`)
+		if strings.HasPrefix(path, "~/") {
+			path = filepath.Join(home, path[2:])
+		}
+		return []SyncFolder{{Path: path, Provider: PROVIDER_ONEDRIVE, Account: ACCOUNT_UNKNOWN, Discovery: DISCOVERY_CLIENT_CONFIG}}
+	}
+
+	return nil
+}
+
+// Windowsの "OneDrive" フォルダのアカウントを判定（個人用アカウントの設定がある場合のみ個人用）
+func personalOneDriveAccount(home string) string {
+	if info, err := os.Stat(filepath.Join(home, "AppData", "Local", "Microsoft", "OneDrive", "settings", "Personal")); err == nil && info.IsDir() {
+		return ACCOUNT_PERSONAL
+	}
+
+	return ACCOUNT_UNKNOWN
+}
+
+// メールアドレスのドメインからアカウントを判定（社内のドメインは法人用、個人向けのドメインは個人用、それ以外は不明）
+func (f *finder) emailAccount(email string) string {
+	_, domain, found := strings.Cut(strings.ToLower(email), "@")
+	if !found {
+		return ACCOUNT_UNKNOWN
+	}
+
+	switch {
+	case slices.Contains(f.corporateDomains, domain):
+		return ACCOUNT_CORPORATE
+	case slices.Contains(consumerDomains, domain):
+		return ACCOUNT_PERSONAL
+	default:
+		return ACCOUNT_UNKNOWN
+	}
+}
+
+// 同期クライアントが作成するメタデータかどうかを確認（同期フォルダからの相対パスのいずれかの要素が一致）
+func isClientMetadata(root string, path string) bool {
+	relPath, err := filepath.Rel(root, path)
+	if err != nil {
+		return false
+	}
+
+	for _, element := range strings.Split(relPath, string(os.PathSeparator)) {
+		if clientMetadataNames[strings.ToLower(element)] {
+			return true
+		}
+	}
+
+	return false
+}
+
+// 空のフォルダかどうかを確認（同期クライアントが作成するメタデータは除く）
+func isEmptyFolder(path string) bool {
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return false
+	}
+
+	for _, entry := range entries {
+		if !clientMetadataNames[strings.ToLower(entry.Name())] {
+			return false
+		}
+	}
+
+	return true
+}
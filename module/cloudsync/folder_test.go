@@ -0,0 +1,52 @@
+package cloudsync
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestEmailAccount(t *testing.T) {
+	f := &finder{corporateDomains: []string{"example.co.jp"}}
+
+	tests := []struct {
+		email string
+		want  string
+	}{
+		{"taro@example.co.jp", ACCOUNT_CORPORATE},
+		{"Taro@Gmail.com", ACCOUNT_PERSONAL},
+		{"taro@partner.example.net", ACCOUNT_UNKNOWN},
+		{"not-an-email", ACCOUNT_UNKNOWN},
+	}
+
+	for _, tt := range tests {
+		if got := f.emailAccount(tt.email); got != tt.want {
+			t.Errorf("emailAccount(%q) = %s, want %s", tt.email, got, tt.want)
+		}
+	}
+
+	// 社内のドメインが設定されていなくても個人向けのドメイン以外を法人用としない
+	if got := (&finder{}).emailAccount("taro@partner.example.net"); got != ACCOUNT_UNKNOWN {
+		t.Errorf("emailAccount without corporate domains = %s, want %s", got, ACCOUNT_UNKNOWN)
+	}
+}
+
+func TestIsEmptyFolder(t *testing.T) {
+	root := t.TempDir()
+	if err := os.Mkdir(filepath.Join(root, ".dropbox.cache"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if !isEmptyFolder(root) {
+		t.Fatal("folder with only client metadata is not empty")
+	}
+
+	if err := os.WriteFile(filepath.Join(root, "report.txt"), []byte("hello"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if isEmptyFolder(root) {
+		t.Fatal("folder with a user file is empty")
+	}
+	if isEmptyFolder(filepath.Join(root, "missing")) {
+		t.Fatal("missing folder is empty")
+	}
+}
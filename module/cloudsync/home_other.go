@@ -0,0 +1,6 @@
+//go:build !windows && !darwin
+
+package cloudsync
+
+// ユーザーのホームディレクトリの置き場所
+var defaultHomeRoots = []string{"/home"}
@@ -0,0 +1,4 @@
+package cloudsync
+
+// ユーザーのホームディレクトリの置き場所
+var defaultHomeRoots = []string{`C:\Users`}
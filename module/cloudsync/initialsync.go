@@ -0,0 +1,47 @@
+package cloudsync
+
+import (
+	"log"
+	"time"
+)
+
+const (
+	INITIAL_SYNC_QUIET_PERIOD = 2 * time.Minute // ファイル操作がこの時間なければ初回同期が終わったと判断
+	INITIAL_SYNC_MAX_DURATION = time.Hour       // 初回同期としてファイル操作を記録しない期間の上限
+)
+
+// 同期フォルダの初回同期の状態の構造体（クライアントが既存のファイルをダウンロードしている間は記録しない）
+type initialSync struct {
+	path       string
+	started    time.Time
+	lastChange time.Time
+	suppressed int
+	finished   bool
+}
+
+// 新しいinitialSyncを作成（syncingがfalseの場合は同期済み）
+func newInitialSync(path string, syncing bool, now time.Time) *initialSync {
+	return &initialSync{
+		path:       path,
+		started:    now,
+		lastChange: now,
+		finished:   !syncing,
+	}
+}
+
+// 初回同期中のファイル操作として記録しないかどうかを確認（監視のゴルーチンからのみ呼び出す）
+func (s *initialSync) suppress(now time.Time) bool {
+	if s.finished {
+		return false
+	}
+
+	if now.Sub(s.lastChange) >= INITIAL_SYNC_QUIET_PERIOD || now.Sub(s.started) >= INITIAL_SYNC_MAX_DURATION {
+		s.finished = true
+		log.Printf("[%s] Initial sync of folder %s finished, %d file operations were not recorded\n", MODULE_NAME, s.path, s.suppressed)
+		return false
+	}
+
+	s.lastChange = now
+	s.suppressed++
+	return true
+}
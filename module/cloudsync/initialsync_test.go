@@ -0,0 +1,37 @@
+package cloudsync
+
+import (
+	"testing"
+	"time"
+)
+
+func TestInitialSync(t *testing.T) {
+	now := time.Now()
+
+	if newInitialSync("/home/taro/Dropbox", false, now).suppress(now) {
+		t.Fatal("synced folder suppressed a file operation")
+	}
+
+	// ファイル操作が続く間は記録せず、静かな期間の後は記録する
+	s := newInitialSync("/home/taro/Dropbox", true, now)
+	for i := 1; i <= 3; i++ {
+		if !s.suppress(now.Add(time.Duration(i) * time.Minute)) {
+			t.Fatalf("operation %d during initial sync was not suppressed", i)
+		}
+	}
+	if s.suppress(now.Add(3*time.Minute + INITIAL_SYNC_QUIET_PERIOD)) {
+		t.Fatal("operation after the quiet period was suppressed")
+	}
+	if s.suppressed != 3 || s.suppress(now.Add(10*time.Minute)) {
+		t.Fatalf("unexpected state after initial sync %+v", s)
+	}
+
+	// ファイル操作が続いても上限を超えたら記録する
+	s = newInitialSync("/home/taro/Dropbox", true, now)
+	for at := time.Minute; at < INITIAL_SYNC_MAX_DURATION; at += time.Minute {
+		s.suppress(now.Add(at))
+	}
+	if s.suppress(now.Add(INITIAL_SYNC_MAX_DURATION)) {
+		t.Fatal("operation after the maximum duration was suppressed")
+	}
+}
@@ -0,0 +1,424 @@
+package cloudsync
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mniyk/endpoint-security-and-monitoring-tools/internal/config"
+	"github.com/mniyk/endpoint-security-and-monitoring-tools/internal/filewatch"
+	"github.com/mniyk/endpoint-security-and-monitoring-tools/internal/inspection"
+	"github.com/mniyk/endpoint-security-and-monitoring-tools/internal/userinfo"
+	"github.com/mniyk/endpoint-security-and-monitoring-tools/module"
+)
+
+const (
+	DISCOVERY_INTERVAL                = 60 * time.Second
+	MODULE_NAME                       = "Cloud Sync Folder Monitoring"
+	DEFAULT_WATCH_MAX_DEPTH           = 10
+	DEFAULT_WATCH_MAX_DIRECTORIES     = 4096
+	DEFAULT_WATCH_SCAN_INTERVAL       = 300 // 秒
+	DEFAULT_PROCFS_ROOT               = "/proc"
+	DETECTED_FOLDER_SEVERITY          = 2
+	PERSONAL_FILE_OPERATION_SEVERITY  = 5
+	UNKNOWN_FILE_OPERATION_SEVERITY   = 4
+	CORPORATE_FILE_OPERATION_SEVERITY = 2
+	FILE_CHANNEL                      = "cloud_sync" // ファイル操作イベントの持ち出し経路
+)
+
+// Cloud Sync Folder Monitoringの設定の構造体
+type MonitorConfig struct {
+	HomeRoots           []string       `json:"home_roots"`            // ユーザーのホームディレクトリの置き場所
+	Folders             []FolderConfig `json:"folders"`               // 既定の場所以外の同期フォルダ
+	CorporateDomains    []string       `json:"corporate_domains"`     // 社内のアカウントのメールアドレスのドメイン
+	WatchMaxDepth       int            `json:"watch_max_depth"`       // 監視するディレクトリの深さの上限（0の場合は無制限）
+	WatchMaxDirectories int            `json:"watch_max_directories"` // 同期フォルダごとに監視するディレクトリ数の上限（0の場合は無制限）
+	WatchScanInterval   int            `json:"watch_scan_interval"`   // 監視できない部分木をスキャンする間隔（秒）
+	ProcfsRoot          string         `json:"procfs_root"`           // procfsを読み込むルート
+	IgnoreClientWrites  bool           `json:"ignore_client_writes"`  // 同期クライアント自身の書き込みを記録しない（fanotifyが使える場合）
+	ClientExecutables   []string       `json:"client_executables"`    // 既定のクライアントに加えて同期クライアントとして扱う実行ファイル名
+
+	inspection.Config // 書き込まれたファイルのハッシュ計算、内容検査と元ファイルの照合
+}
+
+// 新しいMonitorConfigを作成
+func NewMonitorConfig(moduleConfig config.Config) (*MonitorConfig, error) {
+	inspectionConfig, err := inspection.NewConfig(moduleConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	monitorConfig := &MonitorConfig{
+		HomeRoots:           moduleConfig.Strings("home_roots", defaultHomeRoots),
+		CorporateDomains:    moduleConfig.Strings("corporate_domains", nil),
+		WatchMaxDepth:       moduleConfig.Int("watch_max_depth", DEFAULT_WATCH_MAX_DEPTH),
+		WatchMaxDirectories: moduleConfig.Int("watch_max_directories", DEFAULT_WATCH_MAX_DIRECTORIES),
+		WatchScanInterval:   moduleConfig.Int("watch_scan_interval", DEFAULT_WATCH_SCAN_INTERVAL),
+		ProcfsRoot:          moduleConfig.String("procfs_root", DEFAULT_PROCFS_ROOT),
+		IgnoreClientWrites:  moduleConfig.Bool("ignore_client_writes", true),
+		ClientExecutables:   moduleConfig.Strings("client_executables", nil),
+		Config:              inspectionConfig,
+	}
+
+	if err := moduleConfig.Decode("folders", &monitorConfig.Folders); err != nil {
+		return nil, err
+	}
+	for i, domain := range monitorConfig.CorporateDomains {
+		monitorConfig.CorporateDomains[i] = strings.ToLower(domain)
+	}
+	for i, executable := range monitorConfig.ClientExecutables {
+		monitorConfig.ClientExecutables[i] = strings.ToLower(executable)
+	}
+
+	return monitorConfig, nil
+}
+
+// 監視中の同期フォルダの構造体
+type watchedFolder struct {
+	folder      SyncFolder
+	watcher     *filewatch.Watcher
+	initialSync *initialSync
+	cancel      context.CancelFunc
+}
+
+// 監視のための構造体
+type Monitor struct {
+	events          []module.Event
+	config          MonitorConfig
+	cancel          context.CancelFunc
+	lifecycleMu     sync.Mutex
+	wg              sync.WaitGroup
+	eventsMu        sync.RWMutex
+	finder          *finder
+	watches         map[string]*watchedFolder
+	scanned         bool // 最初の同期フォルダの検出が終わった
+	inspector       *inspection.Inspector
+	userInfo        *userinfo.UserInfo
+	eventDispatcher module.EventSink
+	health          *module.HealthTracker
+}
+
+// 新しいMonitorを作成
+func NewMonitor(config *MonitorConfig, userInfo *userinfo.UserInfo, eventDispatcher module.EventSink) *Monitor {
+	return &Monitor{
+		config: *config,
+		events: make([]module.Event, 0),
+		finder: &finder{
+			homeRoots:        config.HomeRoots,
+			folders:          config.Folders,
+			corporateDomains: config.CorporateDomains,
+		},
+		watches:         make(map[string]*watchedFolder),
+		userInfo:        userInfo,
+		eventDispatcher: eventDispatcher,
+		health:          module.NewHealthTracker(),
+	}
+}
+
+// モジュールを初期化
+func (m *Monitor) Initialize() error {
+	m.health.SetState(module.StateInitializing)
+	m.watches = make(map[string]*watchedFolder)
+	m.scanned = false
+	log.Printf("[%s] Initialize...", MODULE_NAME)
+
+	if m.config.WatchMaxDepth < 0 || m.config.WatchMaxDirectories < 0 {
+		return fmt.Errorf("invalid watch limits: depth %d, directories %d", m.config.WatchMaxDepth, m.config.WatchMaxDirectories)
+	}
+	if m.config.WatchScanInterval <= 0 {
+		return fmt.Errorf("watch_scan_interval must be positive: %d", m.config.WatchScanInterval)
+	}
+	for _, folder := range m.config.Folders {
+		if folder.Path == "" || folder.Provider == "" {
+			return fmt.Errorf("folders entries need path and provider: %+v", folder)
+		}
+		if folder.Account != "" && folder.Account != ACCOUNT_PERSONAL && folder.Account != ACCOUNT_CORPORATE {
+			return fmt.Errorf("invalid account %q for %s: must be %s or %s", folder.Account, folder.Path, ACCOUNT_PERSONAL, ACCOUNT_CORPORATE)
+		}
+	}
+
+	// ハッシュ計算、内容検査と元ファイルの照合を作成
+	inspector, err := inspection.NewInspector(MODULE_NAME, m.config.Config, m.health)
+	if err != nil {
+		return err
+	}
+	m.inspector = inspector
+
+	return nil
+}
+
+// モニタリングを開始
+func (m *Monitor) Start(ctx context.Context) error {
+	m.lifecycleMu.Lock()
+	defer m.lifecycleMu.Unlock()
+
+	if m.cancel != nil {
+		return fmt.Errorf("%s already started", MODULE_NAME)
+	}
+
+	log.Printf("[%s] Start...", MODULE_NAME)
+
+	ctx, m.cancel = context.WithCancel(ctx)
+	m.health.SetState(module.StateRunning)
+
+	// ハッシュ計算と内容検査のワーカー、元ファイルの索引の更新を開始
+	if m.inspector.Enabled() {
+		m.inspector.Start(ctx, &m.wg)
+	}
+
+	// 同期フォルダの検出と監視を開始
+	module.Go(ctx, &m.wg, func() {
+		m.startFolderDiscovery(ctx)
+	})
+
+	return nil
+}
+
+// 同期フォルダを定期的に探すループを実行（ユーザーの追加やクライアントのインストールに追従）
+func (m *Monitor) startFolderDiscovery(ctx context.Context) {
+	ticker := time.NewTicker(DISCOVERY_INTERVAL)
+	defer ticker.Stop()
+
+	// 監視者にループを登録
+	heartbeat := module.StartHeartbeat(ctx, "folder_discovery")
+	defer heartbeat.Stop()
+
+	for {
+		// 監視者へ生存を通知
+		heartbeat.Beat()
+
+		m.scanFolders(ctx)
+		m.health.MarkScan()
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// 同期フォルダの監視を開始または終了
+func (m *Monitor) scanFolders(ctx context.Context) {
+	folders := m.finder.find()
+
+	for path, folder := range folders {
+		if _, exists := m.watches[path]; exists {
+			continue
+		}
+
+		// 起動後に現れたフォルダや空のフォルダはクライアントが既存のファイルをダウンロードするため初回同期とする
+		syncing := m.scanned || isEmptyFolder(path)
+
+		log.Printf("[%s] Detected %s folder %s Owner: %s Account: %s (%s) Initial sync: %t\n", MODULE_NAME, folder.Provider, path, folder.Owner, folder.Account, folder.Discovery, syncing)
+		data := m.folderEventData(folder)
+		data["initial_sync"] = syncing
+		m.addEvent("cloud_folder_detected", DETECTED_FOLDER_SEVERITY, data)
+		m.startFolderWatch(ctx, folder, syncing)
+	}
+	m.scanned = true
+
+	for path, watched := range m.watches {
+		if _, exists := folders[path]; !exists {
+			log.Printf("[%s] Removed %s folder %s\n", MODULE_NAME, watched.folder.Provider, path)
+			watched.cancel()
+			delete(m.watches, path)
+		}
+	}
+
+	// 監視の対象範囲を集計
+	var coverage filewatch.Coverage
+	personalCount := 0
+	for _, watched := range m.watches {
+		coverage.Add(watched.watcher.Coverage())
+		if watched.folder.Account == ACCOUNT_PERSONAL {
+			personalCount++
+		}
+	}
+	coverage.SetDetails(m.health)
+	m.health.SetDetail("sync_folders", len(m.watches))
+	m.health.SetDetail("personal_sync_folders", personalCount)
+}
+
+// 同期フォルダのファイル監視を開始
+func (m *Monitor) startFolderWatch(ctx context.Context, folder SyncFolder, syncing bool) {
+	watchCtx, cancel := context.WithCancel(ctx)
+	watched := &watchedFolder{
+		folder: folder,
+		watcher: filewatch.NewWatcher(MODULE_NAME, filewatch.Limits{
+			MaxDepth:       m.config.WatchMaxDepth,
+			MaxDirectories: m.config.WatchMaxDirectories,
+			ScanInterval:   time.Duration(m.config.WatchScanInterval) * time.Second,
+		}, m.health),
+		initialSync: newInitialSync(folder.Path, syncing, time.Now()),
+		cancel:      cancel,
+	}
+	m.watches[folder.Path] = watched
+
+	module.Go(ctx, &m.wg, func() {
+		hooks := filewatch.Hooks{Writes: m.startWriterTracking(watchCtx, folder)}
+		err := watched.watcher.Run(watchCtx, &m.wg, []string{folder.Path}, hooks, func(change filewatch.Change) {
+			m.handleChange(watched, change)
+		})
+		if err != nil {
+			log.Printf("[%s] Failed monitoring folder %s: %v\n", MODULE_NAME, folder.Path, err)
+			m.health.RecordError(err)
+		}
+		log.Printf("[%s] Stopped file monitoring for folder %s\n", MODULE_NAME, folder.Path)
+	})
+}
+
+// アップロードされるファイル操作（作成、書き込み、フォルダ内への移動）のみ記録
+func (m *Monitor) handleChange(watched *watchedFolder, change filewatch.Change) {
+	folder := watched.folder
+
+	switch {
+	case change.Operation == filewatch.OPERATION_DELETE:
+		return
+	case change.Operation == filewatch.OPERATION_RENAMED && change.Path == "":
+		// フォルダの外への移動
+		return
+	case isClientMetadata(folder.Path, change.Path):
+		return
+	case change.Operation == filewatch.OPERATION_RENAMED && isClientMetadata(folder.Path, change.OldPath):
+		// クライアントがダウンロードした一時ファイルを配置
+		return
+	case watched.initialSync.suppress(time.Now()):
+		return
+	case change.Process != nil && m.isSyncClient(folder, *change.Process):
+		// クライアント自身の書き込み（他の端末で変更されたファイルのダウンロード）
+		return
+	}
+
+	m.completeFileOperation(folder, change)
+}
+
+// 確定したファイル操作を記録（作成と書き込みはハッシュ計算と内容検査をしてから記録）
+func (m *Monitor) completeFileOperation(folder SyncFolder, change filewatch.Change) {
+	if change.Operation != filewatch.OPERATION_CREATE && change.Operation != filewatch.OPERATION_WRITE {
+		m.logFileOperation(folder, change, inspection.Result{})
+		return
+	}
+
+	// 書き込みが落ち着く前に確定した場合は検査しない
+	if change.Interrupted {
+		m.logFileOperation(folder, change, m.inspector.Skipped(inspection.STATUS_NOT_SETTLED))
+		return
+	}
+
+	m.inspector.Submit(change.Path, func(result inspection.Result) {
+		m.logFileOperation(folder, change, result)
+	})
+}
+
+// ファイル操作を記録（USBのファイル操作イベントと同じ項目に同期フォルダの情報を追加）
+func (m *Monitor) logFileOperation(folder SyncFolder, change filewatch.Change, result inspection.Result) {
+	log.Printf(
+		"[%s] Operation: %s User: %s Host: %s Provider: %s Account: %s Path: %s Size: %d Time: %s\n",
+		MODULE_NAME,
+		strings.ToUpper(change.Operation),
+		m.userInfo.UserName,
+		m.userInfo.HostName,
+		folder.Provider,
+		folder.Account,
+		change.Path,
+		change.Size,
+		change.Timestamp.Format("2006-01-02 15:04:05"),
+	)
+
+	data := change.EventData(folder.Path, m.userInfo)
+	data["channel"] = FILE_CHANNEL
+	folder.addTo(data)
+	result.AddTo(data)
+
+	severity := UNKNOWN_FILE_OPERATION_SEVERITY
+	switch folder.Account {
+	case ACCOUNT_PERSONAL:
+		severity = PERSONAL_FILE_OPERATION_SEVERITY
+	case ACCOUNT_CORPORATE:
+		severity = CORPORATE_FILE_OPERATION_SEVERITY
+	}
+
+	m.addEvent("file_"+change.Operation, severity, data)
+}
+
+// 同期フォルダの検出のイベントデータを作成
+func (m *Monitor) folderEventData(folder SyncFolder) map[string]interface{} {
+	data := map[string]interface{}{
+		"user":      m.userInfo.UserName,
+		"host":      m.userInfo.HostName,
+		"timestamp": time.Now().Format(time.RFC3339),
+	}
+	folder.addTo(data)
+
+	return data
+}
+
+// モニタリングを停止
+func (m *Monitor) Stop(ctx context.Context) error {
+	m.lifecycleMu.Lock()
+	cancel := m.cancel
+	m.cancel = nil
+	m.lifecycleMu.Unlock()
+
+	// 停止済みの場合は何もしない
+	if cancel == nil {
+		return nil
+	}
+
+	log.Printf("[%s] Stop...", MODULE_NAME)
+
+	// 同期フォルダの検出とすべてのファイル監視を終了
+	cancel()
+
+	// 監視ゴルーチンの終了を待機
+	if err := module.Wait(ctx, &m.wg); err != nil {
+		return fmt.Errorf("%s did not stop: %w", MODULE_NAME, err)
+	}
+
+	m.health.SetState(module.StateStopped)
+
+	return nil
+}
+
+// モジュールの稼働状況を取得
+func (m *Monitor) Health() module.Health {
+	return m.health.Snapshot()
+}
+
+// モジュールが検出したイベントを取得
+func (m *Monitor) GetEvents() []module.Event {
+	m.eventsMu.RLock()
+	defer m.eventsMu.RUnlock()
+
+	// イベントのコピーを返す（オリジナルが変更されないように）
+	eventsCopy := make([]module.Event, len(m.events))
+	copy(eventsCopy, m.events)
+
+	return eventsCopy
+}
+
+// 新しいイベントを追加
+func (m *Monitor) addEvent(eventType string, severity int, data map[string]interface{}) {
+	event := module.Event{
+		ID:        fmt.Sprintf("cloud-%d", time.Now().UnixNano()),
+		Timestamp: time.Now(),
+		Type:      eventType,
+		Severity:  severity,
+		Data:      data,
+	}
+
+	m.eventsMu.Lock()
+	m.events = append(m.events, event)
+	m.eventsMu.Unlock()
+
+	m.health.RecordEvent()
+
+	log.Printf("[%s] Detection new event: %s Importance: %d\n", MODULE_NAME, eventType, severity)
+
+	// イベントをsenderに送信（非同期）
+	go m.eventDispatcher.Add(event)
+}
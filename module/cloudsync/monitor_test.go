@@ -0,0 +1,90 @@
+package cloudsync
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/mniyk/endpoint-security-and-monitoring-tools/internal/config"
+	"github.com/mniyk/endpoint-security-and-monitoring-tools/internal/filewatch"
+	"github.com/mniyk/endpoint-security-and-monitoring-tools/internal/inspection"
+	"github.com/mniyk/endpoint-security-and-monitoring-tools/internal/procinfo"
+	"github.com/mniyk/endpoint-security-and-monitoring-tools/internal/testutil"
+	"github.com/mniyk/endpoint-security-and-monitoring-tools/internal/userinfo"
+)
+
+// 検査のワーカーを開始したMonitorを作成
+func newTestMonitor(t *testing.T, options map[string]interface{}) *Monitor {
+	t.Helper()
+
+	monitorConfig, err := NewMonitorConfig(config.Config{Options: options})
+	if err != nil {
+		t.Fatalf("NewMonitorConfig: %v", err)
+	}
+	monitor := NewMonitor(monitorConfig, &userinfo.UserInfo{UserName: "alice", HostName: "pc"}, testutil.DiscardSink{})
+	if err := monitor.Initialize(); err != nil {
+		t.Fatalf("Initialize: %v", err)
+	}
+
+	testutil.StartInspector(t, monitor.inspector, &monitor.wg)
+
+	return monitor
+}
+
+func TestHandleChange(t *testing.T) {
+	monitor := newTestMonitor(t, map[string]interface{}{"client_executables": []interface{}{"Rclone"}})
+
+	root := t.TempDir()
+	path := filepath.Join(root, "report.txt")
+	if err := os.WriteFile(path, []byte("hello"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	folder := SyncFolder{Path: root, Provider: PROVIDER_DROPBOX, Account: ACCOUNT_PERSONAL, Discovery: DISCOVERY_DEFAULT}
+	watched := &watchedFolder{folder: folder, initialSync: newInitialSync(root, false, time.Now())}
+	write := func(process *procinfo.Process) filewatch.Change {
+		return filewatch.Change{Operation: filewatch.OPERATION_WRITE, Path: path, Size: 5, Timestamp: time.Now(), Process: process}
+	}
+
+	// 同期クライアント自身の書き込みとダウンロードした一時ファイルの配置は記録しない
+	monitor.handleChange(watched, write(&procinfo.Process{PID: 10, Executable: "/home/alice/.dropbox-dist/dropbox-lnx.x86_64-200.4.5/dropbox"}))
+	monitor.handleChange(watched, write(&procinfo.Process{PID: 11, CommandLine: "/usr/bin/rclone sync remote: ."}))
+	monitor.handleChange(watched, filewatch.Change{Operation: filewatch.OPERATION_RENAMED, Path: path, OldPath: filepath.Join(root, ".dropbox.cache", "tmp1"), Timestamp: time.Now()})
+	monitor.handleChange(watched, filewatch.Change{Operation: filewatch.OPERATION_DELETE, Path: path, Timestamp: time.Now()})
+
+	// ユーザーの書き込みは書き込んだプロセスと検査の結果を含めて記録
+	monitor.handleChange(watched, write(&procinfo.Process{PID: 12, Executable: "/usr/bin/cp", UID: 1000}))
+
+	events := testutil.WaitForEvents(t, monitor, 1)
+	time.Sleep(100 * time.Millisecond)
+	if events = monitor.GetEvents(); len(events) != 1 {
+		t.Fatalf("expected only the user write, got %+v", events)
+	}
+
+	event := events[0]
+	if event.Type != "file_write" || event.Severity != PERSONAL_FILE_OPERATION_SEVERITY {
+		t.Fatalf("unexpected event %+v", event)
+	}
+	if event.Data["channel"] != FILE_CHANNEL || event.Data["hash_status"] != inspection.HASH_STATUS_HASHED || event.Data["process_executable"] != "/usr/bin/cp" {
+		t.Fatalf("unexpected event data %+v", event.Data)
+	}
+}
+
+func TestHandleChangeDuringInitialSync(t *testing.T) {
+	monitor := newTestMonitor(t, map[string]interface{}{})
+
+	root := t.TempDir()
+	folder := SyncFolder{Path: root, Provider: PROVIDER_GOOGLE_DRIVE, Account: ACCOUNT_UNKNOWN, Discovery: DISCOVERY_DEFAULT}
+	watched := &watchedFolder{folder: folder, initialSync: newInitialSync(root, true, time.Now())}
+
+	monitor.handleChange(watched, filewatch.Change{Operation: filewatch.OPERATION_CREATE, Path: filepath.Join(root, "a.txt"), Timestamp: time.Now()})
+	monitor.handleChange(watched, filewatch.Change{Operation: filewatch.OPERATION_RENAMED, Path: filepath.Join(root, "b.txt"), OldPath: filepath.Join(root, "a.txt"), Timestamp: time.Now()})
+
+	if events := monitor.GetEvents(); len(events) != 0 {
+		t.Fatalf("file operations during initial sync were recorded: %+v", events)
+	}
+	if watched.initialSync.suppressed != 2 {
+		t.Fatalf("suppressed = %d, want 2", watched.initialSync.suppressed)
+	}
+}
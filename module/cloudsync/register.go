@@ -0,0 +1,40 @@
+package cloudsync
+
+import (
+	"github.com/mniyk/endpoint-security-and-monitoring-tools/internal/config"
+	"github.com/mniyk/endpoint-security-and-monitoring-tools/internal/inspection"
+	"github.com/mniyk/endpoint-security-and-monitoring-tools/module"
+)
+
+const REGISTRY_NAME = "cloud_sync_folder_monitoring"
+
+// モジュールをレジストリに登録
+func init() {
+	module.Register(module.Descriptor{
+		Name:        REGISTRY_NAME,
+		Description: "Tracking of files added to Dropbox, OneDrive and Google Drive sync folders",
+		Options: append([]module.OptionSpec{
+			{Name: "home_roots", Type: "array", Default: defaultHomeRoots, Description: "Directories containing the home directory of each local user"},
+			{Name: "folders", Type: "array", Default: []FolderConfig{}, Description: "Additional sync folders as objects with path (~/ is relative to each home), provider and account (personal or corporate)"},
+			{Name: "corporate_domains", Type: "array", Default: []string{}, Description: "Email domains of corporate accounts, accounts on consumer domains such as gmail.com are personal and the rest are unknown"},
+			{Name: "watch_max_depth", Type: "number", Default: DEFAULT_WATCH_MAX_DEPTH, Description: "Directories deeper than this are scanned periodically instead of watched (0 is unlimited)"},
+			{Name: "watch_max_directories", Type: "number", Default: DEFAULT_WATCH_MAX_DIRECTORIES, Description: "Maximum watched directories per sync folder, the rest are scanned periodically (0 is unlimited)"},
+			{Name: "watch_scan_interval", Type: "number", Default: DEFAULT_WATCH_SCAN_INTERVAL, Description: "Seconds between scans of directories that are not watched"},
+			{Name: "procfs_root", Type: "string", Default: DEFAULT_PROCFS_ROOT, Description: "Root of procfs"},
+			{Name: "ignore_client_writes", Type: "bool", Default: true, Description: "Identify the writing process with fanotify (Linux) and skip files written by the sync client"},
+			{Name: "client_executables", Type: "array", Default: []string{}, Description: "Executable names treated as sync clients in addition to the known clients of each provider"},
+		}, inspection.Options()...),
+		Platforms: []string{"windows", "linux", "darwin"},
+		Factory:   newModule,
+	})
+}
+
+// 設定からモジュールを生成
+func newModule(moduleConfig config.Config, deps module.Dependencies) (module.Module, error) {
+	monitorConfig, err := NewMonitorConfig(moduleConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewMonitor(monitorConfig, deps.UserInfo, deps.EventSink), nil
+}
@@ -0,0 +1,188 @@
+package module
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mniyk/endpoint-security-and-monitoring-tools/internal/config"
+)
+
+// テスト用のモジュール
+type fakeModule struct {
+	initErr  error
+	startErr error
+	stopErr  error
+	// nil以外の場合、Stopはctxの期限を無視してクローズされるまで戻らない
+	hang chan struct{}
+	// ハートビートを送信する監視ループ名
+	loops []string
+	// 最初の開始時のみハートビートが途絶える監視ループ名
+	stallOnce string
+	// 開始するたびに監視ループでパニック
+	panics bool
+
+	mu          sync.Mutex
+	cancel      context.CancelFunc
+	wg          sync.WaitGroup
+	initialized int
+	started     int
+}
+
+func (f *fakeModule) Initialize() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.initialized++
+	return f.initErr
+}
+
+func (f *fakeModule) Start(ctx context.Context) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.started++
+	if f.startErr != nil {
+		return f.startErr
+	}
+
+	ctx, f.cancel = context.WithCancel(ctx)
+	Go(ctx, &f.wg, func() {
+		if f.panics {
+			panic("fake failure")
+		}
+		<-ctx.Done()
+	})
+
+	for _, loop := range f.loops {
+		stall := loop == f.stallOnce && f.started == 1
+		Go(ctx, &f.wg, func() {
+			f.runLoop(ctx, loop, stall)
+		})
+	}
+
+	return nil
+}
+
+// ハートビートを送信する監視ループ（stallの場合は一度だけ送信して停止まで待機）
+func (f *fakeModule) runLoop(ctx context.Context, loop string, stall bool) {
+	heartbeat := StartHeartbeat(ctx, loop)
+	defer heartbeat.Stop()
+
+	ticker := time.NewTicker(10 * time.Millisecond)
+	defer ticker.Stop()
+
+	for {
+		heartbeat.Beat()
+		if stall {
+			<-ctx.Done()
+			return
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (f *fakeModule) Stop(ctx context.Context) error {
+	f.mu.Lock()
+	cancel := f.cancel
+	f.cancel = nil
+	f.mu.Unlock()
+
+	if cancel != nil {
+		cancel()
+	}
+	if f.stopErr != nil {
+		return f.stopErr
+	}
+	if f.hang != nil {
+		<-f.hang
+	}
+
+	return Wait(ctx, &f.wg)
+}
+
+func (f *fakeModule) GetEvents() []Event {
+	return nil
+}
+
+// Startの呼び出し回数を取得
+func (f *fakeModule) starts() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.started
+}
+
+// 有効なモジュールを登録したManagerを作成
+func newTestManager(t *testing.T, modules map[string]*fakeModule) *Manager {
+	t.Helper()
+
+	configs := &config.Configs{
+		Agent: config.AgentConfig{
+			ShutdownTimeout:   config.Duration(time.Second),
+			HeartbeatInterval: config.Duration(time.Hour),
+			Supervisor: config.SupervisorConfig{
+				MaxRestarts:    2,
+				RestartWindow:  config.Duration(time.Minute),
+				InitialBackoff: config.Duration(10 * time.Millisecond),
+				MaxBackoff:     config.Duration(40 * time.Millisecond),
+				StallTimeout:   config.Duration(200 * time.Millisecond),
+			},
+		},
+		Modules: make(map[string]config.Config),
+	}
+
+	manager := NewManager(configs, nil, nil)
+	for name, module := range modules {
+		configs.Modules[name] = config.Config{Enabled: true}
+		if err := manager.RegisterModule(name, module); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return manager
+}
+
+// 有効なすべてのモジュールを初期化して開始
+func startTestManager(t *testing.T, manager *Manager) {
+	t.Helper()
+
+	if errs := manager.InitializeAllModules(); len(errs) > 0 {
+		t.Fatalf("InitializeAllModules: %v", errs)
+	}
+	if errs := manager.StartAllModules(context.Background()); len(errs) > 0 {
+		t.Fatalf("StartAllModules: %v", errs)
+	}
+
+	t.Cleanup(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		manager.StopAllModules(ctx)
+	})
+}
+
+// モジュールの状態が期待した値になるまで待機
+func waitForState(t *testing.T, manager *Manager, name string, want State) {
+	t.Helper()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		state, err := manager.ModuleState(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if state == want {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("%s is %s, want %s", name, state, want)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
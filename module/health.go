@@ -0,0 +1,115 @@
+package module
+
+import (
+	"sync"
+	"time"
+)
+
+// モジュールの状態
+type State string
+
+const (
+	StateRegistered   State = "registered"
+	StateInitializing State = "initializing"
+	StateInitialized  State = "initialized"
+	StateStarting     State = "starting"
+	StateRunning      State = "running"
+	StateDegraded     State = "degraded"
+	StateStopping     State = "stopping"
+	StateStopped      State = "stopped"
+	StateFailed       State = "failed"
+)
+
+// モジュールの稼働状況の構造体
+type Health struct {
+	State        State                  `json:"state"`
+	LastScanTime time.Time              `json:"last_scan_time"`
+	ErrorCount   int64                  `json:"error_count"`
+	EventCount   int64                  `json:"event_count"`
+	LastError    string                 `json:"last_error,omitempty"`
+	Details      map[string]interface{} `json:"details,omitempty"`
+}
+
+// 稼働状況を報告できるモジュールが実装するメソッドを定義
+type HealthReporter interface {
+	Health() Health // 現在の稼働状況を取得
+}
+
+// モジュールの稼働状況を記録する構造体
+type HealthTracker struct {
+	mu     sync.Mutex
+	health Health
+}
+
+// 新しいHealthTrackerを作成
+func NewHealthTracker() *HealthTracker {
+	return &HealthTracker{
+		health: Health{
+			State:   StateStopped,
+			Details: make(map[string]interface{}),
+		},
+	}
+}
+
+// 状態を設定
+func (t *HealthTracker) SetState(state State) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.health.State = state
+}
+
+// スキャンの成功を記録（劣化状態から復帰）
+func (t *HealthTracker) MarkScan() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.health.LastScanTime = time.Now()
+	if t.health.State == StateDegraded {
+		t.health.State = StateRunning
+	}
+}
+
+// エラーを記録（稼働中の場合は劣化状態に移行）
+func (t *HealthTracker) RecordError(err error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.health.ErrorCount++
+	if err != nil {
+		t.health.LastError = err.Error()
+	}
+	if t.health.State == StateRunning {
+		t.health.State = StateDegraded
+	}
+}
+
+// イベントの検出を記録
+func (t *HealthTracker) RecordEvent() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.health.EventCount++
+}
+
+// モジュール固有の情報を設定
+func (t *HealthTracker) SetDetail(key string, value interface{}) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.health.Details[key] = value
+}
+
+// 稼働状況のコピーを取得
+func (t *HealthTracker) Snapshot() Health {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	snapshot := t.health
+	snapshot.Details = make(map[string]interface{}, len(t.health.Details))
+	for key, value := range t.health.Details {
+		snapshot.Details[key] = value
+	}
+
+	return snapshot
+}
@@ -0,0 +1,86 @@
+package module
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestHealthTracker(t *testing.T) {
+	tracker := NewHealthTracker()
+	if state := tracker.Snapshot().State; state != StateStopped {
+		t.Fatalf("new tracker is %s, want %s", state, StateStopped)
+	}
+
+	// 停止中のエラーでは劣化状態に移行しない
+	tracker.RecordError(errors.New("not started"))
+	if state := tracker.Snapshot().State; state != StateStopped {
+		t.Fatalf("error while stopped moved state to %s", state)
+	}
+
+	tracker.SetState(StateRunning)
+	tracker.RecordError(errors.New("scan failed"))
+	tracker.RecordError(nil)
+	tracker.RecordEvent()
+
+	health := tracker.Snapshot()
+	if health.State != StateDegraded || health.ErrorCount != 3 || health.EventCount != 1 || health.LastError != "scan failed" {
+		t.Fatalf("after errors: %+v", health)
+	}
+
+	// スキャンに成功すると稼働中に復帰
+	tracker.MarkScan()
+	health = tracker.Snapshot()
+	if health.State != StateRunning || health.LastScanTime.IsZero() {
+		t.Fatalf("after scan: %+v", health)
+	}
+}
+
+func TestHealthTrackerSnapshotCopiesDetails(t *testing.T) {
+	tracker := NewHealthTracker()
+	tracker.SetDetail("drives", 1)
+
+	snapshot := tracker.Snapshot()
+	snapshot.Details["drives"] = 5
+	tracker.SetDetail("mode", "hotplug")
+
+	if drives := tracker.Snapshot().Details["drives"]; drives != 1 {
+		t.Fatalf("snapshot shares details with tracker: drives = %v", drives)
+	}
+	if _, ok := snapshot.Details["mode"]; ok {
+		t.Fatal("detail set after the snapshot leaked into it")
+	}
+}
+
+// 稼働状況を報告するテスト用のモジュール
+type reportingModule struct {
+	*fakeModule
+	health *HealthTracker
+}
+
+func (r *reportingModule) Health() Health {
+	return r.health.Snapshot()
+}
+
+func TestStatus(t *testing.T) {
+	manager := newTestManager(t, nil)
+
+	health := NewHealthTracker()
+	health.SetState(StateRunning)
+	if err := manager.RegisterModule("reporting", &reportingModule{fakeModule: &fakeModule{}, health: health}); err != nil {
+		t.Fatal(err)
+	}
+	if err := manager.RegisterModule("silent", &fakeModule{}); err != nil {
+		t.Fatal(err)
+	}
+
+	statuses := manager.Status()
+	if len(statuses) != 2 || statuses[0].Name != "reporting" || statuses[1].Name != "silent" {
+		t.Fatalf("Status() = %+v, want reporting and silent in name order", statuses)
+	}
+	if statuses[0].Health == nil || statuses[0].Health.State != StateRunning {
+		t.Errorf("reporting health = %+v", statuses[0].Health)
+	}
+	if statuses[1].Health != nil || statuses[1].State != StateRegistered {
+		t.Errorf("silent status = %+v, want registered without health", statuses[1])
+	}
+}
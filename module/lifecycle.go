@@ -0,0 +1,22 @@
+package module
+
+import (
+	"context"
+	"sync"
+)
+
+// WaitGroupの完了をコンテキストの期限まで待機
+func Wait(ctx context.Context, wg *sync.WaitGroup) error {
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -1,26 +1,48 @@
 package module
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"log"
 	"sync"
 
 	"github.com/mniyk/endpoint-security-and-monitoring-tools/internal/config"
+	"github.com/mniyk/endpoint-security-and-monitoring-tools/internal/userinfo"
+)
+
+var (
+	// 期限内に停止しなかったモジュールのエラー
+	ErrStopTimeout = errors.New("module did not stop before the shutdown deadline")
+	// 現在の状態では要求された操作ができない場合のエラー
+	ErrInvalidTransition = errors.New("invalid module state transition")
 )
 
 // モジュール管理の構造体
 type Manager struct {
-	Modules       map[string]Module
-	activeModules map[string]bool
-	configs       *config.Configs
-	mu            sync.RWMutex
+	Modules          map[string]Module
+	states           map[string]State
+	configs          *config.Configs
+	mu               sync.RWMutex
+	userInfo         *userinfo.UserInfo
+	eventSink        EventSink
+	supervision      map[string]*supervisionState
+	supervisionMu    sync.Mutex
+	generations      int // 監視下で開始した回数
+	supervisorCtx    context.Context
+	supervisorCancel context.CancelFunc
+	supervisorWg     sync.WaitGroup
 }
 
 // 新しいManagerを作成
-func NewManager(configs *config.Configs) *Manager {
+func NewManager(configs *config.Configs, userInfo *userinfo.UserInfo, eventSink EventSink) *Manager {
 	return &Manager{
-		Modules:       make(map[string]Module),
-		activeModules: make(map[string]bool),
-		configs:       configs,
+		Modules:     make(map[string]Module),
+		states:      make(map[string]State),
+		configs:     configs,
+		userInfo:    userInfo,
+		eventSink:   eventSink,
+		supervision: make(map[string]*supervisionState),
 	}
 }
 
@@ -34,74 +56,318 @@ func (m *Manager) RegisterModule(name string, module Module) error {
 	}
 
 	m.Modules[name] = module
+	m.states[name] = StateRegistered
 	return nil
 }
 
-// すべてのモジュールを初期化
-func (m *Manager) InitializeAllModules() map[string]error {
-	errors := make(map[string]error)
+// モジュールの状態を取得
+func (m *Manager) ModuleState(name string) (State, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	state, ok := m.states[name]
+	if !ok {
+		return "", fmt.Errorf("module %s not registered", name)
+	}
 
+	return state, nil
+}
+
+// 現在の状態を検証して次の状態に遷移
+func (m *Manager) transition(name string, to State, from ...State) (Module, error) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
-	for name, module := range m.Modules {
-		if moduleConfig, ok := m.configs.Modules[name]; ok && moduleConfig.Enabled {
-			err := module.Initialize()
-			if err != nil {
-				errors[name] = err
-				continue
-			}
-			m.activeModules[name] = true
+	module, ok := m.Modules[name]
+	if !ok {
+		return nil, fmt.Errorf("module %s not registered", name)
+	}
+
+	current := m.states[name]
+	for _, allowed := range from {
+		if current == allowed {
+			m.states[name] = to
+			return module, nil
 		}
 	}
 
-	return errors
+	return nil, fmt.Errorf("%w: %s is %s, cannot move to %s", ErrInvalidTransition, name, current, to)
 }
 
-// すべてのモジュールを開始
-func (m *Manager) StartAllModules() map[string]error {
-	errors := make(map[string]error)
+// 状態を設定
+func (m *Manager) setState(name string, state State) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.states[name] = state
+}
 
+// 指定された状態のモジュール名を取得
+func (m *Manager) namesInState(state State) []string {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
 
-	for name, active := range m.activeModules {
-		if active {
-			module := m.Modules[name]
-			err := module.Start()
-			if err != nil {
-				errors[name] = err
-			}
+	var names []string
+	for name, current := range m.states {
+		if current == state {
+			names = append(names, name)
+		}
+	}
+
+	return names
+}
+
+// 設定で有効なすべてのモジュールを初期化
+func (m *Manager) InitializeAllModules() map[string]error {
+	errors := make(map[string]error)
+
+	m.mu.RLock()
+	var names []string
+	for name := range m.Modules {
+		if moduleConfig, ok := m.configs.Modules[name]; ok && moduleConfig.Enabled {
+			names = append(names, name)
+		}
+	}
+	m.mu.RUnlock()
+
+	for _, name := range names {
+		if err := m.initializeModule(name); err != nil {
+			errors[name] = err
 		}
 	}
 
 	return errors
 }
 
-// すべてのアクティブなモジュールを停止
-func (m *Manager) StopAllModules() map[string]error {
+// モジュールを初期化
+func (m *Manager) initializeModule(name string) error {
+	module, err := m.transition(name, StateInitializing, StateRegistered, StateStopped, StateFailed)
+	if err != nil {
+		return err
+	}
+
+	if err := module.Initialize(); err != nil {
+		m.setState(name, StateFailed)
+		return err
+	}
+
+	m.setState(name, StateInitialized)
+	return nil
+}
+
+// 初期化済みのすべてのモジュールを監視下で開始
+func (m *Manager) StartAllModules(ctx context.Context) map[string]error {
 	errors := make(map[string]error)
 
+	// モジュールの監視を開始
+	supervisorCtx, supervisorCancel := context.WithCancel(ctx)
+	m.mu.Lock()
+	m.supervisorCtx, m.supervisorCancel = supervisorCtx, supervisorCancel
+	m.mu.Unlock()
+
+	m.supervisorWg.Add(1)
+	go func() {
+		defer m.supervisorWg.Done()
+		m.watchStalls(supervisorCtx)
+	}()
+
+	// 稼働状況の定期送信を開始
+	m.supervisorWg.Add(1)
+	go func() {
+		defer m.supervisorWg.Done()
+		m.sendHeartbeats(supervisorCtx)
+	}()
+
+	for _, name := range m.namesInState(StateInitialized) {
+		if err := m.startModule(name); err != nil {
+			errors[name] = err
+		}
+	}
+
+	return errors
+}
+
+// 監視者のコンテキストを取得（StartAllModulesの前はnil）
+func (m *Manager) supervisorContext() context.Context {
 	m.mu.RLock()
-	modules := make(map[string]Module)
+	defer m.mu.RUnlock()
+
+	return m.supervisorCtx
+}
+
+// 初期化済みのモジュールを監視下で開始
+func (m *Manager) startModule(name string) error {
+	module, err := m.transition(name, StateStarting, StateInitialized)
+	if err != nil {
+		return err
+	}
+
+	if err := m.startSupervised(name, module); err != nil {
+		m.setState(name, StateFailed)
+		return err
+	}
 
-	for name, active := range m.activeModules {
-		if active {
-			modules[name] = m.Modules[name]
+	m.setState(name, StateRunning)
+	return nil
+}
+
+// 停止中のモジュールを再初期化して開始
+func (m *Manager) StartModule(name string) error {
+	if ctx := m.supervisorContext(); ctx == nil || ctx.Err() != nil {
+		return fmt.Errorf("cannot start %s: manager is not running", name)
+	}
+
+	if err := m.initializeModule(name); err != nil {
+		return err
+	}
+
+	if err := m.startModule(name); err != nil {
+		return err
+	}
+
+	log.Printf("[%s] Started module %s", SUPERVISOR_NAME, name)
+	return nil
+}
+
+// 実行中のモジュールを停止（ctxの期限まで待機）
+func (m *Manager) StopModule(ctx context.Context, name string) error {
+	if m.isRestarting(name) {
+		return fmt.Errorf("%w: %s is being restarted by the supervisor", ErrInvalidTransition, name)
+	}
+
+	if err := m.stopModule(ctx, name); err != nil {
+		return err
+	}
+
+	log.Printf("[%s] Stopped module %s", SUPERVISOR_NAME, name)
+	return nil
+}
+
+// 実行中のモジュールを停止して再度開始
+func (m *Manager) RestartModule(ctx context.Context, name string) error {
+	state, err := m.ModuleState(name)
+	if err != nil {
+		return err
+	}
+
+	if state == StateRunning {
+		if err := m.StopModule(ctx, name); err != nil {
+			return err
 		}
 	}
+
+	return m.StartModule(name)
+}
+
+// モジュールを停止して状態を更新
+func (m *Manager) stopModule(ctx context.Context, name string) error {
+	module, err := m.transition(name, StateStopping, StateRunning)
+	if err != nil {
+		return err
+	}
+
+	// 意図的な停止のため監視対象から外す
+	m.supervisionMu.Lock()
+	delete(m.supervision, name)
+	m.supervisionMu.Unlock()
+
+	if err := module.Stop(ctx); err != nil {
+		m.setState(name, StateFailed)
+		if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled) {
+			return fmt.Errorf("%w: %v", ErrStopTimeout, err)
+		}
+		return err
+	}
+
+	m.setState(name, StateStopped)
+	return nil
+}
+
+// すべての実行中のモジュールを並行して停止（ctxの期限まで待機）
+func (m *Manager) StopAllModules(ctx context.Context) map[string]error {
+	stopErrors := make(map[string]error)
+
+	// 再起動処理を終了してから停止
+	m.mu.RLock()
+	supervisorCancel := m.supervisorCancel
 	m.mu.RUnlock()
+	if supervisorCancel != nil {
+		supervisorCancel()
+		if err := Wait(ctx, &m.supervisorWg); err != nil {
+			log.Printf("[%s] Restart in progress did not finish: %v", SUPERVISOR_NAME, err)
+		}
+	}
+
+	names := m.namesInState(StateRunning)
+
+	type stopResult struct {
+		name string
+		err  error
+	}
+
+	results := make(chan stopResult, len(names))
+	for _, name := range names {
+		go func(name string) {
+			results <- stopResult{name: name, err: m.stopModule(ctx, name)}
+		}(name)
+	}
+
+	// すべてのモジュールの停止または期限切れまで待機
+	pending := make(map[string]bool, len(names))
+	for _, name := range names {
+		pending[name] = true
+	}
+
+	for len(pending) > 0 {
+		select {
+		case result := <-results:
+			delete(pending, result.name)
+			if result.err != nil {
+				stopErrors[result.name] = result.err
+			}
+		case <-ctx.Done():
+			// 期限内に応答しなかったモジュールを失敗として報告
+			for name := range pending {
+				m.setState(name, StateFailed)
+				stopErrors[name] = fmt.Errorf("%w: %v", ErrStopTimeout, ctx.Err())
+			}
+			return stopErrors
+		}
+	}
 
-	for name, module := range modules {
-		err := module.Stop()
+	return stopErrors
+}
+
+// 読み込み直した設定の有効/無効をモジュールに反映（オプションの変更は再起動するまで反映されない）
+func (m *Manager) ApplyConfig(ctx context.Context, configs *config.Configs) map[string]error {
+	applyErrors := make(map[string]error)
+
+	m.mu.Lock()
+	m.configs.Modules = configs.Modules
+	names := make([]string, 0, len(m.Modules))
+	for name := range m.Modules {
+		names = append(names, name)
+	}
+	m.mu.Unlock()
+
+	for _, name := range names {
+		state, err := m.ModuleState(name)
 		if err != nil {
-			errors[name] = err
+			continue
 		}
 
-		m.mu.Lock()
-		m.activeModules[name] = false
-		m.mu.Unlock()
+		enabled := configs.Modules[name].Enabled
+		switch {
+		case enabled && (state == StateRegistered || state == StateStopped):
+			if err := m.StartModule(name); err != nil {
+				applyErrors[name] = err
+			}
+		case !enabled && state == StateRunning:
+			if err := m.StopModule(ctx, name); err != nil {
+				applyErrors[name] = err
+			}
+		}
 	}
 
-	return errors
+	return applyErrors
 }
@@ -0,0 +1,145 @@
+package module
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/mniyk/endpoint-security-and-monitoring-tools/internal/config"
+)
+
+func TestStopAllModules(t *testing.T) {
+	hang := make(chan struct{})
+	defer close(hang)
+
+	manager := newTestManager(t, map[string]*fakeModule{
+		"responsive": {},
+		"stuck":      {hang: hang},
+	})
+
+	if errs := manager.InitializeAllModules(); len(errs) > 0 {
+		t.Fatalf("InitializeAllModules: %v", errs)
+	}
+	if errs := manager.StartAllModules(context.Background()); len(errs) > 0 {
+		t.Fatalf("StartAllModules: %v", errs)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	errs := manager.StopAllModules(ctx)
+	if len(errs) != 1 || !errors.Is(errs["stuck"], ErrStopTimeout) {
+		t.Fatalf("StopAllModules errors = %v, want only stuck to time out", errs)
+	}
+
+	// 期限内に停止しなかったモジュールはStateStoppingのまま残さない
+	want := map[string]State{"responsive": StateStopped, "stuck": StateFailed}
+	for name, state := range want {
+		if got, _ := manager.ModuleState(name); got != state {
+			t.Errorf("%s is %s, want %s", name, got, state)
+		}
+	}
+}
+
+func TestTransition(t *testing.T) {
+	manager := newTestManager(t, map[string]*fakeModule{"usb": {}})
+
+	tests := []struct {
+		name    string
+		current State
+		to      State
+		from    []State
+		allowed bool
+	}{
+		{"initialize registered", StateRegistered, StateInitializing, []State{StateRegistered, StateStopped, StateFailed}, true},
+		{"initialize failed", StateFailed, StateInitializing, []State{StateRegistered, StateStopped, StateFailed}, true},
+		{"initialize running", StateRunning, StateInitializing, []State{StateRegistered, StateStopped, StateFailed}, false},
+		{"start initialized", StateInitialized, StateStarting, []State{StateInitialized}, true},
+		{"start stopped", StateStopped, StateStarting, []State{StateInitialized}, false},
+		{"stop running", StateRunning, StateStopping, []State{StateRunning}, true},
+		{"stop stopping", StateStopping, StateStopping, []State{StateRunning}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			manager.setState("usb", tt.current)
+
+			_, err := manager.transition("usb", tt.to, tt.from...)
+			state, _ := manager.ModuleState("usb")
+
+			if tt.allowed {
+				if err != nil || state != tt.to {
+					t.Fatalf("transition: err %v, state %s, want %s", err, state, tt.to)
+				}
+				return
+			}
+			// 拒否された場合は状態を変更しない
+			if !errors.Is(err, ErrInvalidTransition) || state != tt.current {
+				t.Fatalf("transition: err %v, state %s, want ErrInvalidTransition and %s", err, state, tt.current)
+			}
+		})
+	}
+
+	if _, err := manager.transition("unknown", StateStopping, StateRunning); err == nil {
+		t.Fatal("transition of an unregistered module succeeded")
+	}
+}
+
+func TestStopAndStartModule(t *testing.T) {
+	usb := &fakeModule{}
+	manager := newTestManager(t, map[string]*fakeModule{"usb": usb})
+	startTestManager(t, manager)
+
+	if err := manager.StopModule(context.Background(), "usb"); err != nil {
+		t.Fatalf("StopModule: %v", err)
+	}
+	if err := manager.StopModule(context.Background(), "usb"); !errors.Is(err, ErrInvalidTransition) {
+		t.Fatalf("second StopModule = %v, want ErrInvalidTransition", err)
+	}
+
+	// 停止したモジュールは再初期化して開始
+	if err := manager.StartModule("usb"); err != nil {
+		t.Fatalf("StartModule: %v", err)
+	}
+	if started := usb.starts(); started != 2 {
+		t.Fatalf("usb started %d times, want 2", started)
+	}
+	waitForState(t, manager, "usb", StateRunning)
+}
+
+func TestApplyConfig(t *testing.T) {
+	usb, printer := &fakeModule{}, &fakeModule{}
+	manager := newTestManager(t, map[string]*fakeModule{"usb": usb, "printer": printer})
+
+	// printerは無効な状態で起動
+	manager.configs.Modules["printer"] = config.Config{Enabled: false}
+	startTestManager(t, manager)
+	waitForState(t, manager, "printer", StateRegistered)
+
+	errs := manager.ApplyConfig(context.Background(), &config.Configs{
+		Modules: map[string]config.Config{
+			"usb":     {Enabled: false},
+			"printer": {Enabled: true},
+		},
+	})
+	if len(errs) > 0 {
+		t.Fatalf("ApplyConfig: %v", errs)
+	}
+
+	waitForState(t, manager, "usb", StateStopped)
+	waitForState(t, manager, "printer", StateRunning)
+
+	// 変更がない場合は何もしない
+	if errs := manager.ApplyConfig(context.Background(), &config.Configs{
+		Modules: map[string]config.Config{
+			"usb":     {Enabled: false},
+			"printer": {Enabled: true},
+		},
+	}); len(errs) > 0 {
+		t.Fatalf("second ApplyConfig: %v", errs)
+	}
+	if usb.starts() != 1 || printer.starts() != 1 {
+		t.Fatalf("starts: usb %d, printer %d, want 1 each", usb.starts(), printer.starts())
+	}
+}
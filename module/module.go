@@ -1,9 +1,11 @@
 package module
 
+import "context"
+
 // 実装すべきメソッドを定義
 type Module interface {
-	Initialize() error  // モジュールの初期化
-	Start() error       // モニタリングの開始
-	Stop() error        // モニタリングの停止
-	GetEvents() []Event // モジュールが検出したイベントを取得
+	Initialize() error               // モジュールの初期化
+	Start(ctx context.Context) error // モニタリングの開始（ctxのキャンセルで停止）
+	Stop(ctx context.Context) error  // モニタリングの停止（ctxの期限まで終了を待機）
+	GetEvents() []Event              // モジュールが検出したイベントを取得
 }
@@ -0,0 +1,194 @@
+package mtp
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/mniyk/endpoint-security-and-monitoring-tools/internal/mountinfo"
+	"github.com/mniyk/endpoint-security-and-monitoring-tools/internal/sysfs"
+)
+
+const (
+	PROTOCOL_MTP = "mtp"
+	PROTOCOL_PTP = "ptp"
+
+	STILL_IMAGE_CLASS     = "06" // PTP（MTPはPTPの拡張）
+	VENDOR_SPECIFIC_CLASS = "ff" // インターフェース名が"MTP"の場合のみ対象
+	GVFS_FUSE_TYPE        = "fuse.gvfsd-fuse"
+)
+
+// gvfsのマウント内のデバイスのディレクトリの接頭辞とプロトコル
+var gvfsPrefixes = map[string]string{
+	"mtp:":     PROTOCOL_MTP,
+	"gphoto2:": PROTOCOL_PTP,
+}
+
+// MTP/PTPデバイスの識別情報の構造体
+type Device struct {
+	Protocol       string // "mtp" "ptp"
+	VendorID       string
+	ProductID      string
+	Manufacturer   string
+	Product        string
+	Serial         string
+	BusPath        string // 例: "2-1.4"
+	BusNum         string // gvfsの "[usb:002,005]" 形式との照合用
+	DevNum         string
+	InterfaceClass string
+}
+
+// イベントデータにデバイスの識別情報を追加（USBのファイル操作イベントと同じ項目名）
+func (d *Device) addTo(data map[string]interface{}) {
+	if d == nil {
+		return
+	}
+
+	data["protocol"] = d.Protocol
+	data["vendor_id"] = d.VendorID
+	data["product_id"] = d.ProductID
+	data["manufacturer"] = d.Manufacturer
+	data["product"] = d.Product
+	data["serial"] = d.Serial
+	data["bus_path"] = d.BusPath
+	data["interface_class"] = d.InterfaceClass
+}
+
+// デバイスのファイルシステムとしてマウントされたストレージの構造体
+type Storage struct {
+	ID       string // gvfsのディレクトリ名またはマウントポイント
+	Root     string
+	Protocol string
+	FSType   string
+	Device   *Device // 対応するデバイス（特定できない場合はnil）
+}
+
+// sysfsからMTP/PTPのインターフェースを持つUSBデバイスを列挙（キーはバスパス）
+func listDevices(sysfsRoot string) (map[string]*Device, error) {
+	devicesRoot := filepath.Join(sysfsRoot, "bus", "usb", "devices")
+	entries, err := os.ReadDir(devicesRoot)
+	if err != nil {
+		return nil, fmt.Errorf("failed reading %s: %w", devicesRoot, err)
+	}
+
+	devices := make(map[string]*Device)
+	for _, entry := range entries {
+		// インターフェース（例: 2-1.4:1.0）のみを確認
+		busPath, _, isInterface := strings.Cut(entry.Name(), ":")
+		if !isInterface {
+			continue
+		}
+
+		interfacePath := filepath.Join(devicesRoot, entry.Name())
+		class := strings.ToLower(sysfs.ReadValue(filepath.Join(interfacePath, "bInterfaceClass")))
+		name := sysfs.ReadValue(filepath.Join(interfacePath, "interface"))
+
+		protocol := ""
+		switch {
+		case strings.Contains(strings.ToUpper(name), "MTP") && (class == STILL_IMAGE_CLASS || class == VENDOR_SPECIFIC_CLASS):
+			protocol = PROTOCOL_MTP
+		case class == STILL_IMAGE_CLASS:
+			protocol = PROTOCOL_PTP
+		default:
+			continue
+		}
+
+		devicePath := filepath.Join(devicesRoot, busPath)
+		devices[busPath] = &Device{
+			Protocol:       protocol,
+			VendorID:       sysfs.ReadValue(filepath.Join(devicePath, "idVendor")),
+			ProductID:      sysfs.ReadValue(filepath.Join(devicePath, "idProduct")),
+			Manufacturer:   sysfs.ReadValue(filepath.Join(devicePath, "manufacturer")),
+			Product:        sysfs.ReadValue(filepath.Join(devicePath, "product")),
+			Serial:         sysfs.ReadValue(filepath.Join(devicePath, "serial")),
+			BusPath:        busPath,
+			BusNum:         fmt.Sprintf("%03s", sysfs.ReadValue(filepath.Join(devicePath, "busnum"))),
+			DevNum:         fmt.Sprintf("%03s", sysfs.ReadValue(filepath.Join(devicePath, "devnum"))),
+			InterfaceClass: class,
+		}
+	}
+
+	return devices, nil
+}
+
+// gvfsとFUSEでマウントされたMTP/PTPのストレージを列挙（キーはルートのパス、mountinfoを読み込めない場合はnil）
+func listStorages(mountinfoPath string, fuseTypes []string, devices map[string]*Device) (map[string]Storage, []error) {
+	storages := make(map[string]Storage)
+
+	mounts, err := mountinfo.ReadFile(mountinfoPath)
+	if err != nil {
+		return nil, []error{fmt.Errorf("failed reading mountinfo: %w", err)}
+	}
+
+	var errs []error
+	for _, mount := range mounts {
+		switch {
+		case slices.Contains(fuseTypes, mount.FSType):
+			// jmtpfsなどはマウントポイントがデバイスのルート
+			storages[mount.MountPoint] = Storage{
+				ID:       mount.MountPoint,
+				Root:     mount.MountPoint,
+				Protocol: PROTOCOL_MTP,
+				FSType:   mount.FSType,
+				Device:   matchDevice(mount.Source, PROTOCOL_MTP, devices),
+			}
+
+		case mount.FSType == GVFS_FUSE_TYPE:
+			// gvfsはユーザーのランタイムディレクトリ（/run/user/<uid>/gvfs）にデバイスごとのディレクトリを作成
+			// allow_otherなしでマウントされている場合は他のユーザー（rootを含む）から読み込めない
+			entries, err := os.ReadDir(mount.MountPoint)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("failed reading gvfs mount %s: %w", mount.MountPoint, err))
+				continue
+			}
+
+			for _, entry := range entries {
+				for prefix, protocol := range gvfsPrefixes {
+					if !strings.HasPrefix(entry.Name(), prefix) {
+						continue
+					}
+					root := filepath.Join(mount.MountPoint, entry.Name())
+					storages[root] = Storage{
+						ID:       entry.Name(),
+						Root:     root,
+						Protocol: protocol,
+						FSType:   mount.FSType,
+						Device:   matchDevice(entry.Name(), protocol, devices),
+					}
+				}
+			}
+		}
+	}
+
+	return storages, errs
+}
+
+// ストレージの名前からデバイスを特定（シリアル番号かバス番号で照合し、候補が1台のみの場合はそのデバイス）
+// 例: "mtp:host=Google_Pixel_7_2A111FDH200ABC" "mtp:host=%5Busb%3A002%2C005%5D"
+func matchDevice(name string, protocol string, devices map[string]*Device) *Device {
+	if decoded, err := url.PathUnescape(name); err == nil {
+		name = decoded
+	}
+
+	var candidates []*Device
+	for _, device := range devices {
+		if device.Serial != "" && strings.Contains(name, device.Serial) {
+			return device
+		}
+		if strings.Contains(name, fmt.Sprintf("[usb:%s,%s]", device.BusNum, device.DevNum)) {
+			return device
+		}
+		if device.Protocol == protocol {
+			candidates = append(candidates, device)
+		}
+	}
+
+	if len(candidates) == 1 {
+		return candidates[0]
+	}
+
+	return nil
+}
@@ -0,0 +1,94 @@
+package mtp
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/mniyk/endpoint-security-and-monitoring-tools/internal/testutil"
+)
+
+// USBデバイスとインターフェースを偽のsysfsに作成
+func writeUSBDevice(t *testing.T, sysfsRoot string, busPath string, device map[string]string, class string, name string) {
+	t.Helper()
+
+	devicesRoot := filepath.Join(sysfsRoot, "bus", "usb", "devices")
+	testutil.WriteFiles(t, filepath.Join(devicesRoot, busPath), device)
+	testutil.WriteFiles(t, filepath.Join(devicesRoot, busPath+":1.0"), map[string]string{
+		"bInterfaceClass": class + "\n",
+		"interface":       name + "\n",
+	})
+}
+
+func TestListDevices(t *testing.T) {
+	sysfsRoot := t.TempDir()
+	writeUSBDevice(t, sysfsRoot, "2-1", map[string]string{
+		"idVendor": "18d1\n", "idProduct": "4ee1\n", "manufacturer": "Google\n", "product": "Pixel 7\n",
+		"serial": "2A111FDH200ABC\n", "busnum": "2\n", "devnum": "5\n",
+	}, "06", "MTP")
+	writeUSBDevice(t, sysfsRoot, "2-2", map[string]string{"idVendor": "04a9\n", "busnum": "2\n", "devnum": "7\n"}, "06", "")
+	writeUSBDevice(t, sysfsRoot, "3-1", map[string]string{"idVendor": "04e8\n", "busnum": "3\n", "devnum": "2\n"}, "FF", "MTP")
+	// ベンダー固有のクラスはインターフェース名がMTPの場合のみ、大容量記憶装置は対象外
+	writeUSBDevice(t, sysfsRoot, "3-2", map[string]string{"idVendor": "0bda\n"}, "ff", "ADB Interface")
+	writeUSBDevice(t, sysfsRoot, "3-3", map[string]string{"idVendor": "0781\n"}, "08", "Mass Storage")
+
+	devices, err := listDevices(sysfsRoot)
+	if err != nil {
+		t.Fatalf("listDevices: %v", err)
+	}
+	if len(devices) != 3 {
+		t.Fatalf("listDevices found %d devices, want 3: %v", len(devices), devices)
+	}
+
+	tests := []struct {
+		busPath  string
+		protocol string
+		class    string
+	}{
+		{"2-1", PROTOCOL_MTP, STILL_IMAGE_CLASS},
+		{"2-2", PROTOCOL_PTP, STILL_IMAGE_CLASS},
+		{"3-1", PROTOCOL_MTP, VENDOR_SPECIFIC_CLASS},
+	}
+	for _, tt := range tests {
+		device, exists := devices[tt.busPath]
+		if !exists {
+			t.Errorf("device %s was not detected", tt.busPath)
+			continue
+		}
+		if device.Protocol != tt.protocol || device.InterfaceClass != tt.class {
+			t.Errorf("device %s protocol %s class %s, want %s and %s", tt.busPath, device.Protocol, device.InterfaceClass, tt.protocol, tt.class)
+		}
+	}
+
+	pixel := devices["2-1"]
+	if pixel.VendorID != "18d1" || pixel.Serial != "2A111FDH200ABC" || pixel.BusNum != "002" || pixel.DevNum != "005" {
+		t.Errorf("unexpected device %+v", pixel)
+	}
+
+	if _, err := listDevices(filepath.Join(sysfsRoot, "missing")); err == nil {
+		t.Error("listDevices without sysfs succeeded")
+	}
+}
+
+func TestMatchDevice(t *testing.T) {
+	pixel := &Device{Protocol: PROTOCOL_MTP, Serial: "2A111FDH200ABC", BusNum: "002", DevNum: "005"}
+	galaxy := &Device{Protocol: PROTOCOL_MTP, BusNum: "003", DevNum: "002"}
+	camera := &Device{Protocol: PROTOCOL_PTP, BusNum: "002", DevNum: "007"}
+	devices := map[string]*Device{"2-1": pixel, "3-1": galaxy, "2-2": camera}
+
+	tests := []struct {
+		name     string
+		protocol string
+		want     *Device
+	}{
+		{"mtp:host=Google_Pixel_7_2A111FDH200ABC", PROTOCOL_MTP, pixel},
+		{"mtp:host=%5Busb%3A003%2C002%5D", PROTOCOL_MTP, galaxy},
+		// 候補が1台のみの場合はそのデバイス
+		{"gphoto2:host=Canon_EOS", PROTOCOL_PTP, camera},
+		{"mtp:host=Unknown", PROTOCOL_MTP, nil},
+	}
+	for _, tt := range tests {
+		if got := matchDevice(tt.name, tt.protocol, devices); got != tt.want {
+			t.Errorf("matchDevice(%s) = %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+}
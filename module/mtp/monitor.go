@@ -0,0 +1,394 @@
+package mtp
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mniyk/endpoint-security-and-monitoring-tools/internal/config"
+	"github.com/mniyk/endpoint-security-and-monitoring-tools/internal/filewatch"
+	"github.com/mniyk/endpoint-security-and-monitoring-tools/internal/inspection"
+	"github.com/mniyk/endpoint-security-and-monitoring-tools/internal/userinfo"
+	"github.com/mniyk/endpoint-security-and-monitoring-tools/module"
+)
+
+const (
+	MONITOR_INTERVAL              = 5 * time.Second
+	MODULE_NAME                   = "MTP Transfer Monitoring"
+	DEFAULT_SYSFS_ROOT            = "/sys"
+	DEFAULT_PROCFS_ROOT           = "/proc"
+	DEFAULT_WATCH_MAX_DEPTH       = 8
+	DEFAULT_WATCH_MAX_DIRECTORIES = 2048
+	DEFAULT_WATCH_SCAN_INTERVAL   = 300 // 秒（MTPの列挙は遅いため長めにする）
+	CONNECTED_DEVICE_SEVERITY     = 5
+	DISCONNECTED_DEVICE_SEVERITY  = 3
+	FILE_OPERATION_SEVERITY       = 5
+	FILE_CHANNEL                  = "mtp" // ファイル操作イベントの持ち出し経路
+)
+
+// 既定でMTPのストレージとして扱うFUSEのファイルシステム
+var defaultFuseTypes = []string{"fuse.jmtpfs", "fuse.simple-mtpfs", "fuse.go-mtpfs", "fuse.aft-mtp-mount"}
+
+// MTP Transfer Monitoringの設定の構造体
+type MonitorConfig struct {
+	SysfsRoot           string   `json:"sysfs_root"`            // sysfsを読み込むルート
+	ProcfsRoot          string   `json:"procfs_root"`           // procfsを読み込むルート
+	FuseTypes           []string `json:"fuse_types"`            // MTPのストレージとして扱うFUSEのファイルシステム（gvfsは常に対象）
+	WatchMaxDepth       int      `json:"watch_max_depth"`       // 監視するディレクトリの深さの上限（0の場合は無制限）
+	WatchMaxDirectories int      `json:"watch_max_directories"` // ストレージごとに監視するディレクトリ数の上限（0の場合は無制限）
+	WatchScanInterval   int      `json:"watch_scan_interval"`   // 監視できない部分木をスキャンする間隔（秒）
+
+	inspection.Config // 書き込まれたファイルのハッシュ計算、内容検査と元ファイルの照合
+}
+
+// 新しいMonitorConfigを作成
+func NewMonitorConfig(moduleConfig config.Config) (*MonitorConfig, error) {
+	inspectionConfig, err := inspection.NewConfig(moduleConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	return &MonitorConfig{
+		SysfsRoot:           moduleConfig.String("sysfs_root", DEFAULT_SYSFS_ROOT),
+		ProcfsRoot:          moduleConfig.String("procfs_root", DEFAULT_PROCFS_ROOT),
+		FuseTypes:           moduleConfig.Strings("fuse_types", defaultFuseTypes),
+		WatchMaxDepth:       moduleConfig.Int("watch_max_depth", DEFAULT_WATCH_MAX_DEPTH),
+		WatchMaxDirectories: moduleConfig.Int("watch_max_directories", DEFAULT_WATCH_MAX_DIRECTORIES),
+		WatchScanInterval:   moduleConfig.Int("watch_scan_interval", DEFAULT_WATCH_SCAN_INTERVAL),
+		Config:              inspectionConfig,
+	}, nil
+}
+
+// 監視中のストレージの構造体
+type watchedStorage struct {
+	storage Storage
+	watcher *filewatch.Watcher
+	cancel  context.CancelFunc
+}
+
+// 監視のための構造体
+type Monitor struct {
+	events          []module.Event
+	config          MonitorConfig
+	cancel          context.CancelFunc
+	lifecycleMu     sync.Mutex
+	wg              sync.WaitGroup
+	eventsMu        sync.RWMutex
+	devices         map[string]*Device
+	storages        map[string]*watchedStorage
+	storageErrors   map[string]bool // 同じエラーを繰り返し記録しないため
+	inspector       *inspection.Inspector
+	userInfo        *userinfo.UserInfo
+	eventDispatcher module.EventSink
+	health          *module.HealthTracker
+}
+
+// 新しいMonitorを作成
+func NewMonitor(config *MonitorConfig, userInfo *userinfo.UserInfo, eventDispatcher module.EventSink) *Monitor {
+	return &Monitor{
+		config:          *config,
+		events:          make([]module.Event, 0),
+		devices:         make(map[string]*Device),
+		storages:        make(map[string]*watchedStorage),
+		storageErrors:   make(map[string]bool),
+		userInfo:        userInfo,
+		eventDispatcher: eventDispatcher,
+		health:          module.NewHealthTracker(),
+	}
+}
+
+// モジュールを初期化
+func (m *Monitor) Initialize() error {
+	m.health.SetState(module.StateInitializing)
+	m.devices = make(map[string]*Device)
+	m.storages = make(map[string]*watchedStorage)
+	m.storageErrors = make(map[string]bool)
+	log.Printf("[%s] Initialize...", MODULE_NAME)
+
+	if m.config.WatchMaxDepth < 0 || m.config.WatchMaxDirectories < 0 {
+		return fmt.Errorf("invalid watch limits: depth %d, directories %d", m.config.WatchMaxDepth, m.config.WatchMaxDirectories)
+	}
+	if m.config.WatchScanInterval <= 0 {
+		return fmt.Errorf("watch_scan_interval must be positive: %d", m.config.WatchScanInterval)
+	}
+
+	// ハッシュ計算、内容検査と元ファイルの照合を作成
+	inspector, err := inspection.NewInspector(MODULE_NAME, m.config.Config, m.health)
+	if err != nil {
+		return err
+	}
+	m.inspector = inspector
+
+	return nil
+}
+
+// モニタリングを開始
+func (m *Monitor) Start(ctx context.Context) error {
+	m.lifecycleMu.Lock()
+	defer m.lifecycleMu.Unlock()
+
+	if m.cancel != nil {
+		return fmt.Errorf("%s already started", MODULE_NAME)
+	}
+
+	log.Printf("[%s] Start...", MODULE_NAME)
+
+	ctx, m.cancel = context.WithCancel(ctx)
+	m.health.SetState(module.StateRunning)
+
+	// ハッシュ計算と内容検査のワーカー、元ファイルの索引の更新を開始
+	if m.inspector.Enabled() {
+		m.inspector.Start(ctx, &m.wg)
+	}
+
+	// デバイスとストレージの監視を開始
+	module.Go(ctx, &m.wg, func() {
+		m.startDeviceMonitoring(ctx)
+	})
+
+	return nil
+}
+
+// 監視の継続的なループを実行
+func (m *Monitor) startDeviceMonitoring(ctx context.Context) {
+	ticker := time.NewTicker(MONITOR_INTERVAL)
+	defer ticker.Stop()
+
+	// 監視者にループを登録
+	heartbeat := module.StartHeartbeat(ctx, "device_monitoring")
+	defer heartbeat.Stop()
+
+	for {
+		// 監視者へ生存を通知
+		heartbeat.Beat()
+
+		m.scanDevices()
+		m.scanStorages(ctx)
+		m.health.MarkScan()
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// MTP/PTPデバイスの接続と切断を検出
+func (m *Monitor) scanDevices() {
+	devices, err := listDevices(m.config.SysfsRoot)
+	if err != nil {
+		log.Printf("[%s] Failed enumerating devices: %v\n", MODULE_NAME, err)
+		m.health.RecordError(err)
+		return
+	}
+
+	for busPath, device := range devices {
+		if _, exists := m.devices[busPath]; !exists {
+			log.Printf("[%s] Connected %s device %s %s (%s)\n", MODULE_NAME, strings.ToUpper(device.Protocol), device.Manufacturer, device.Product, busPath)
+			data := m.deviceEventData(device)
+			m.addEvent("mtp_device_connected", CONNECTED_DEVICE_SEVERITY, data)
+		}
+	}
+
+	for busPath, device := range m.devices {
+		if _, exists := devices[busPath]; !exists {
+			log.Printf("[%s] Disconnected %s device %s %s (%s)\n", MODULE_NAME, strings.ToUpper(device.Protocol), device.Manufacturer, device.Product, busPath)
+			data := m.deviceEventData(device)
+			m.addEvent("mtp_device_disconnected", DISCONNECTED_DEVICE_SEVERITY, data)
+		}
+	}
+
+	m.devices = devices
+	m.health.SetDetail("connected_devices", len(m.devices))
+}
+
+// マウントされたストレージの監視を開始または終了
+func (m *Monitor) scanStorages(ctx context.Context) {
+	storages, errs := listStorages(filepath.Join(m.config.ProcfsRoot, "self", "mountinfo"), m.config.FuseTypes, m.devices)
+
+	// 読み込めないgvfsのマウントは初回のみ記録
+	for _, err := range errs {
+		if !m.storageErrors[err.Error()] {
+			m.storageErrors[err.Error()] = true
+			log.Printf("[%s] %v\n", MODULE_NAME, err)
+			m.health.RecordError(err)
+		}
+	}
+
+	// マウント情報を読み込めない場合は監視中のストレージを維持
+	if storages == nil {
+		return
+	}
+
+	for root, storage := range storages {
+		if _, exists := m.storages[root]; exists {
+			continue
+		}
+
+		log.Printf("[%s] Starting file monitoring for %s storage %s\n", MODULE_NAME, strings.ToUpper(storage.Protocol), root)
+
+		watchCtx, cancel := context.WithCancel(ctx)
+		watched := &watchedStorage{
+			storage: storage,
+			watcher: filewatch.NewWatcher(MODULE_NAME, filewatch.Limits{
+				MaxDepth:       m.config.WatchMaxDepth,
+				MaxDirectories: m.config.WatchMaxDirectories,
+				ScanInterval:   time.Duration(m.config.WatchScanInterval) * time.Second,
+			}, m.health),
+			cancel: cancel,
+		}
+		m.storages[root] = watched
+
+		module.Go(ctx, &m.wg, func() {
+			err := watched.watcher.Run(watchCtx, &m.wg, []string{storage.Root}, filewatch.Hooks{}, func(change filewatch.Change) {
+				m.completeFileOperation(storage, change)
+			})
+			if err != nil {
+				log.Printf("[%s] Failed monitoring storage %s: %v\n", MODULE_NAME, storage.Root, err)
+				m.health.RecordError(err)
+			}
+			log.Printf("[%s] Stopped file monitoring for storage %s\n", MODULE_NAME, storage.Root)
+		})
+	}
+
+	for root, watched := range m.storages {
+		if _, exists := storages[root]; !exists {
+			watched.cancel()
+			delete(m.storages, root)
+		}
+	}
+
+	// 監視の対象範囲を集計
+	var coverage filewatch.Coverage
+	for _, watched := range m.storages {
+		coverage.Add(watched.watcher.Coverage())
+	}
+	coverage.SetDetails(m.health)
+	m.health.SetDetail("watched_storages", len(m.storages))
+}
+
+// 確定したファイル操作を記録（作成と書き込みはハッシュ計算と内容検査をしてから記録）
+func (m *Monitor) completeFileOperation(storage Storage, change filewatch.Change) {
+	if change.Operation != filewatch.OPERATION_CREATE && change.Operation != filewatch.OPERATION_WRITE {
+		m.logFileOperation(storage, change, inspection.Result{})
+		return
+	}
+
+	// 書き込みが落ち着く前に確定した場合は検査しない
+	if change.Interrupted {
+		m.logFileOperation(storage, change, m.inspector.Skipped(inspection.STATUS_NOT_SETTLED))
+		return
+	}
+
+	m.inspector.Submit(change.Path, func(result inspection.Result) {
+		m.logFileOperation(storage, change, result)
+	})
+}
+
+// ファイル操作を記録
+func (m *Monitor) logFileOperation(storage Storage, change filewatch.Change, result inspection.Result) {
+	log.Printf(
+		"[%s] Operation: %s User: %s Host: %s Storage: %s Path: %s Size: %d Time: %s\n",
+		MODULE_NAME,
+		strings.ToUpper(change.Operation),
+		m.userInfo.UserName,
+		m.userInfo.HostName,
+		storage.ID,
+		change.Path,
+		change.Size,
+		change.Timestamp.Format("2006-01-02 15:04:05"),
+	)
+
+	data := change.EventData(storage.ID, m.userInfo)
+	data["channel"] = FILE_CHANNEL
+	data["protocol"] = storage.Protocol
+	data["mount_path"] = storage.Root
+	data["file_system"] = storage.FSType
+	storage.Device.addTo(data)
+	result.AddTo(data)
+
+	m.addEvent("file_"+change.Operation, FILE_OPERATION_SEVERITY, data)
+}
+
+// デバイスの接続と切断のイベントデータを作成
+func (m *Monitor) deviceEventData(device *Device) map[string]interface{} {
+	data := map[string]interface{}{
+		"user":      m.userInfo.UserName,
+		"host":      m.userInfo.HostName,
+		"timestamp": time.Now().Format(time.RFC3339),
+	}
+	device.addTo(data)
+
+	return data
+}
+
+// モニタリングを停止
+func (m *Monitor) Stop(ctx context.Context) error {
+	m.lifecycleMu.Lock()
+	cancel := m.cancel
+	m.cancel = nil
+	m.lifecycleMu.Unlock()
+
+	// 停止済みの場合は何もしない
+	if cancel == nil {
+		return nil
+	}
+
+	log.Printf("[%s] Stop...", MODULE_NAME)
+
+	// デバイスの監視とすべてのファイル監視を終了
+	cancel()
+
+	// 監視ゴルーチンの終了を待機
+	if err := module.Wait(ctx, &m.wg); err != nil {
+		return fmt.Errorf("%s did not stop: %w", MODULE_NAME, err)
+	}
+
+	m.health.SetState(module.StateStopped)
+
+	return nil
+}
+
+// モジュールの稼働状況を取得
+func (m *Monitor) Health() module.Health {
+	return m.health.Snapshot()
+}
+
+// モジュールが検出したイベントを取得
+func (m *Monitor) GetEvents() []module.Event {
+	m.eventsMu.RLock()
+	defer m.eventsMu.RUnlock()
+
+	// イベントのコピーを返す（オリジナルが変更されないように）
+	eventsCopy := make([]module.Event, len(m.events))
+	copy(eventsCopy, m.events)
+
+	return eventsCopy
+}
+
+// 新しいイベントを追加
+func (m *Monitor) addEvent(eventType string, severity int, data map[string]interface{}) {
+	event := module.Event{
+		ID:        fmt.Sprintf("mtp-%d", time.Now().UnixNano()),
+		Timestamp: time.Now(),
+		Type:      eventType,
+		Severity:  severity,
+		Data:      data,
+	}
+
+	m.eventsMu.Lock()
+	m.events = append(m.events, event)
+	m.eventsMu.Unlock()
+
+	m.health.RecordEvent()
+
+	log.Printf("[%s] Detection new event: %s Importance: %d\n", MODULE_NAME, eventType, severity)
+
+	// イベントをsenderに送信（非同期）
+	go m.eventDispatcher.Add(event)
+}
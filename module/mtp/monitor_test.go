@@ -0,0 +1,62 @@
+package mtp
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/mniyk/endpoint-security-and-monitoring-tools/internal/config"
+	"github.com/mniyk/endpoint-security-and-monitoring-tools/internal/filewatch"
+	"github.com/mniyk/endpoint-security-and-monitoring-tools/internal/inspection"
+	"github.com/mniyk/endpoint-security-and-monitoring-tools/internal/testutil"
+	"github.com/mniyk/endpoint-security-and-monitoring-tools/internal/userinfo"
+)
+
+func TestCompleteFileOperation(t *testing.T) {
+	monitorConfig, err := NewMonitorConfig(config.Config{Options: map[string]interface{}{}})
+	if err != nil {
+		t.Fatalf("NewMonitorConfig: %v", err)
+	}
+	monitor := NewMonitor(monitorConfig, &userinfo.UserInfo{UserName: "alice", HostName: "pc"}, testutil.DiscardSink{})
+	if err := monitor.Initialize(); err != nil {
+		t.Fatalf("Initialize: %v", err)
+	}
+
+	testutil.StartInspector(t, monitor.inspector, &monitor.wg)
+
+	root := t.TempDir()
+	path := filepath.Join(root, "DCIM", "photo.jpg")
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte("hello"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	storage := Storage{ID: "mtp:host=Pixel", Root: root, Protocol: "mtp", FSType: "fuse.gvfsd-fuse"}
+	monitor.completeFileOperation(storage, filewatch.Change{Operation: filewatch.OPERATION_CREATE, Path: path, Size: 5, Timestamp: time.Now()})
+	monitor.completeFileOperation(storage, filewatch.Change{Operation: filewatch.OPERATION_DELETE, Path: path, Timestamp: time.Now()})
+
+	events := testutil.WaitForEvents(t, monitor, 2)
+	byType := testutil.EventsByType(events)
+
+	created, ok := byType["file_create"]
+	if !ok {
+		t.Fatalf("no file_create event in %+v", events)
+	}
+	if created.Data["channel"] != FILE_CHANNEL || created.Data["hash_status"] != inspection.HASH_STATUS_HASHED {
+		t.Fatalf("unexpected file_create data %+v", created.Data)
+	}
+	if created.Data["sha256"] != "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824" {
+		t.Fatalf("unexpected sha256 %v", created.Data["sha256"])
+	}
+
+	deleted, ok := byType["file_delete"]
+	if !ok || deleted.Data["channel"] != FILE_CHANNEL {
+		t.Fatalf("unexpected file_delete event %+v", deleted)
+	}
+	if _, hashed := deleted.Data["hash_status"]; hashed {
+		t.Fatal("deleted file was inspected")
+	}
+}
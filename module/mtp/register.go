@@ -0,0 +1,36 @@
+package mtp
+
+import (
+	"github.com/mniyk/endpoint-security-and-monitoring-tools/internal/config"
+	"github.com/mniyk/endpoint-security-and-monitoring-tools/internal/inspection"
+	"github.com/mniyk/endpoint-security-and-monitoring-tools/module"
+)
+
+const REGISTRY_NAME = "mtp_transfer_monitoring"
+
+// モジュールをレジストリに登録
+func init() {
+	module.Register(module.Descriptor{
+		Name:        REGISTRY_NAME,
+		Description: "Tracking of file transfers to phones and cameras over MTP/PTP",
+		Options: append([]module.OptionSpec{
+			{Name: "sysfs_root", Type: "string", Default: DEFAULT_SYSFS_ROOT, Description: "Root of sysfs"},
+			{Name: "procfs_root", Type: "string", Default: DEFAULT_PROCFS_ROOT, Description: "Root of procfs"},
+			{Name: "fuse_types", Type: "array", Default: defaultFuseTypes, Description: "FUSE file systems treated as MTP storage in addition to gvfs"},
+			{Name: "watch_max_depth", Type: "number", Default: DEFAULT_WATCH_MAX_DEPTH, Description: "Directories deeper than this are scanned periodically instead of watched (0 is unlimited)"},
+			{Name: "watch_max_directories", Type: "number", Default: DEFAULT_WATCH_MAX_DIRECTORIES, Description: "Maximum watched directories per storage, the rest are scanned periodically (0 is unlimited)"},
+			{Name: "watch_scan_interval", Type: "number", Default: DEFAULT_WATCH_SCAN_INTERVAL, Description: "Seconds between scans of directories that are not watched"},
+		}, inspection.Options()...),
+		Platforms: []string{"linux"},
+		Factory:   newModule,
+	})
+}
+
+// 設定からモジュールを生成
+func newModule(moduleConfig config.Config, deps module.Dependencies) (module.Module, error) {
+	monitorConfig, err := NewMonitorConfig(moduleConfig)
+	if err != nil {
+		return nil, err
+	}
+	return NewMonitor(monitorConfig, deps.UserInfo, deps.EventSink), nil
+}
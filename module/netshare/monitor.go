@@ -0,0 +1,395 @@
+package netshare
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mniyk/endpoint-security-and-monitoring-tools/internal/config"
+	"github.com/mniyk/endpoint-security-and-monitoring-tools/internal/filewatch"
+	"github.com/mniyk/endpoint-security-and-monitoring-tools/internal/inspection"
+	"github.com/mniyk/endpoint-security-and-monitoring-tools/internal/userinfo"
+	"github.com/mniyk/endpoint-security-and-monitoring-tools/module"
+)
+
+const (
+	MONITOR_INTERVAL                  = 5 * time.Second
+	MODULE_NAME                       = "Network Share Transfer Monitoring"
+	DEFAULT_PROCFS_ROOT               = "/proc"
+	DEFAULT_WATCH_MAX_DEPTH           = 6
+	DEFAULT_WATCH_MAX_DIRECTORIES     = 2048
+	DEFAULT_WATCH_SCAN_INTERVAL       = 600 // 秒（ネットワーク越しのスキャンは遅いため長めにする）
+	MOUNTED_SHARE_SEVERITY            = 2
+	MOUNTED_PERSONAL_SHARE_SEVERITY   = 4
+	UNMOUNTED_SHARE_SEVERITY          = 2
+	FILE_OPERATION_SEVERITY           = 5
+	CORPORATE_FILE_OPERATION_SEVERITY = 2
+	FILE_CHANNEL                      = "network_share" // ファイル操作イベントの持ち出し経路
+)
+
+// 既定で監視するネットワークファイルシステム
+var defaultFileSystems = []string{"cifs", "smb3", "nfs", "nfs4", "fuse.sshfs"}
+
+// Network Share Transfer Monitoringの設定の構造体
+type MonitorConfig struct {
+	ProcfsRoot          string   `json:"procfs_root"`           // procfsを読み込むルート
+	FileSystems         []string `json:"file_systems"`          // ネットワークファイルシステムとして扱うファイルシステム
+	CorporateServers    []string `json:"corporate_servers"`     // 社内のサーバー（ホスト名のパターンまたはCIDR）
+	WatchCorporate      bool     `json:"watch_corporate"`       // 社内のサーバーの共有も監視するかどうか
+	WatchMaxDepth       int      `json:"watch_max_depth"`       // 監視するディレクトリの深さの上限（0の場合は無制限）
+	WatchMaxDirectories int      `json:"watch_max_directories"` // 共有ごとに監視するディレクトリ数の上限（0の場合は無制限）
+	WatchScanInterval   int      `json:"watch_scan_interval"`   // 監視できない部分木をスキャンする間隔（秒）
+
+	inspection.Config // 書き込まれたファイルのハッシュ計算、内容検査と元ファイルの照合
+}
+
+// 新しいMonitorConfigを作成
+func NewMonitorConfig(moduleConfig config.Config) (*MonitorConfig, error) {
+	inspectionConfig, err := inspection.NewConfig(moduleConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	return &MonitorConfig{
+		ProcfsRoot:          moduleConfig.String("procfs_root", DEFAULT_PROCFS_ROOT),
+		FileSystems:         moduleConfig.Strings("file_systems", defaultFileSystems),
+		CorporateServers:    moduleConfig.Strings("corporate_servers", nil),
+		WatchCorporate:      moduleConfig.Bool("watch_corporate", false),
+		WatchMaxDepth:       moduleConfig.Int("watch_max_depth", DEFAULT_WATCH_MAX_DEPTH),
+		WatchMaxDirectories: moduleConfig.Int("watch_max_directories", DEFAULT_WATCH_MAX_DIRECTORIES),
+		WatchScanInterval:   moduleConfig.Int("watch_scan_interval", DEFAULT_WATCH_SCAN_INTERVAL),
+		Config:              inspectionConfig,
+	}, nil
+}
+
+// 監視中の共有の構造体
+type watchedShare struct {
+	share   Share
+	watcher *filewatch.Watcher
+	cancel  context.CancelFunc
+}
+
+// 監視のための構造体
+type Monitor struct {
+	events          []module.Event
+	config          MonitorConfig
+	cancel          context.CancelFunc
+	lifecycleMu     sync.Mutex
+	wg              sync.WaitGroup
+	eventsMu        sync.RWMutex
+	shares          map[string]Share
+	watches         map[string]*watchedShare
+	inspector       *inspection.Inspector
+	userInfo        *userinfo.UserInfo
+	eventDispatcher module.EventSink
+	health          *module.HealthTracker
+}
+
+// 新しいMonitorを作成
+func NewMonitor(config *MonitorConfig, userInfo *userinfo.UserInfo, eventDispatcher module.EventSink) *Monitor {
+	return &Monitor{
+		config:          *config,
+		events:          make([]module.Event, 0),
+		shares:          make(map[string]Share),
+		watches:         make(map[string]*watchedShare),
+		userInfo:        userInfo,
+		eventDispatcher: eventDispatcher,
+		health:          module.NewHealthTracker(),
+	}
+}
+
+// モジュールを初期化
+func (m *Monitor) Initialize() error {
+	m.health.SetState(module.StateInitializing)
+	m.shares = make(map[string]Share)
+	m.watches = make(map[string]*watchedShare)
+	log.Printf("[%s] Initialize...", MODULE_NAME)
+
+	if m.config.WatchMaxDepth < 0 || m.config.WatchMaxDirectories < 0 {
+		return fmt.Errorf("invalid watch limits: depth %d, directories %d", m.config.WatchMaxDepth, m.config.WatchMaxDirectories)
+	}
+	if m.config.WatchScanInterval <= 0 {
+		return fmt.Errorf("watch_scan_interval must be positive: %d", m.config.WatchScanInterval)
+	}
+	if len(m.config.CorporateServers) == 0 {
+		log.Printf("[%s] No corporate servers configured, all network shares are treated as non-corporate\n", MODULE_NAME)
+	}
+
+	// ハッシュ計算、内容検査と元ファイルの照合を作成
+	inspector, err := inspection.NewInspector(MODULE_NAME, m.config.Config, m.health)
+	if err != nil {
+		return err
+	}
+	m.inspector = inspector
+
+	return nil
+}
+
+// モニタリングを開始
+func (m *Monitor) Start(ctx context.Context) error {
+	m.lifecycleMu.Lock()
+	defer m.lifecycleMu.Unlock()
+
+	if m.cancel != nil {
+		return fmt.Errorf("%s already started", MODULE_NAME)
+	}
+
+	log.Printf("[%s] Start...", MODULE_NAME)
+
+	ctx, m.cancel = context.WithCancel(ctx)
+	m.health.SetState(module.StateRunning)
+
+	// ハッシュ計算と内容検査のワーカー、元ファイルの索引の更新を開始
+	if m.inspector.Enabled() {
+		m.inspector.Start(ctx, &m.wg)
+	}
+
+	// マウントの監視を開始
+	module.Go(ctx, &m.wg, func() {
+		m.startMountMonitoring(ctx)
+	})
+
+	return nil
+}
+
+// 監視の継続的なループを実行
+func (m *Monitor) startMountMonitoring(ctx context.Context) {
+	ticker := time.NewTicker(MONITOR_INTERVAL)
+	defer ticker.Stop()
+
+	// 監視者にループを登録
+	heartbeat := module.StartHeartbeat(ctx, "mount_monitoring")
+	defer heartbeat.Stop()
+
+	for {
+		// 監視者へ生存を通知
+		heartbeat.Beat()
+
+		m.scanShares(ctx)
+		m.health.MarkScan()
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// ネットワークファイルシステムのマウントとアンマウントを検出し、ファイル監視を開始または終了
+func (m *Monitor) scanShares(ctx context.Context) {
+	shares, err := listShares(filepath.Join(m.config.ProcfsRoot, "self", "mountinfo"), m.config.FileSystems, m.config.CorporateServers)
+	if err != nil {
+		// 監視中の共有を維持
+		log.Printf("[%s] %v\n", MODULE_NAME, err)
+		m.health.RecordError(err)
+		return
+	}
+
+	for mountPoint, share := range shares {
+		if _, exists := m.shares[mountPoint]; exists {
+			continue
+		}
+
+		log.Printf("[%s] Mounted %s share %s on %s Corporate: %t\n", MODULE_NAME, share.FSType, share.Source, mountPoint, share.Corporate)
+		severity := MOUNTED_SHARE_SEVERITY
+		if !share.Corporate {
+			severity = MOUNTED_PERSONAL_SHARE_SEVERITY
+		}
+		m.addEvent("share_mounted", severity, m.shareEventData(share))
+
+		if share.Corporate && !m.config.WatchCorporate {
+			continue
+		}
+		m.startShareWatch(ctx, share)
+	}
+
+	for mountPoint, share := range m.shares {
+		if _, exists := shares[mountPoint]; exists {
+			continue
+		}
+
+		log.Printf("[%s] Unmounted %s share %s from %s\n", MODULE_NAME, share.FSType, share.Source, mountPoint)
+		m.addEvent("share_unmounted", UNMOUNTED_SHARE_SEVERITY, m.shareEventData(share))
+
+		if watched, exists := m.watches[mountPoint]; exists {
+			watched.cancel()
+			delete(m.watches, mountPoint)
+		}
+	}
+
+	m.shares = shares
+
+	// 監視の対象範囲を集計
+	var coverage filewatch.Coverage
+	for _, watched := range m.watches {
+		coverage.Add(watched.watcher.Coverage())
+	}
+	coverage.SetDetails(m.health)
+
+	corporateCount := 0
+	for _, share := range shares {
+		if share.Corporate {
+			corporateCount++
+		}
+	}
+	m.health.SetDetail("network_shares", len(shares))
+	m.health.SetDetail("corporate_shares", corporateCount)
+	m.health.SetDetail("watched_shares", len(m.watches))
+}
+
+// 共有のファイル監視を開始
+func (m *Monitor) startShareWatch(ctx context.Context, share Share) {
+	log.Printf("[%s] Starting file monitoring for share %s\n", MODULE_NAME, share.MountPoint)
+
+	watchCtx, cancel := context.WithCancel(ctx)
+	watched := &watchedShare{
+		share: share,
+		watcher: filewatch.NewWatcher(MODULE_NAME, filewatch.Limits{
+			MaxDepth:       m.config.WatchMaxDepth,
+			MaxDirectories: m.config.WatchMaxDirectories,
+			ScanInterval:   time.Duration(m.config.WatchScanInterval) * time.Second,
+		}, m.health),
+		cancel: cancel,
+	}
+	m.watches[share.MountPoint] = watched
+
+	// inotifyはこの端末からの変更のみ通知するため、他の端末によるサーバー上の変更は記録されない
+	module.Go(ctx, &m.wg, func() {
+		err := watched.watcher.Run(watchCtx, &m.wg, []string{share.MountPoint}, filewatch.Hooks{}, func(change filewatch.Change) {
+			m.completeFileOperation(share, change)
+		})
+		if err != nil {
+			log.Printf("[%s] Failed monitoring share %s: %v\n", MODULE_NAME, share.MountPoint, err)
+			m.health.RecordError(err)
+		}
+		log.Printf("[%s] Stopped file monitoring for share %s\n", MODULE_NAME, share.MountPoint)
+	})
+}
+
+// 確定したファイル操作を記録（作成と書き込みはハッシュ計算と内容検査をしてから記録）
+func (m *Monitor) completeFileOperation(share Share, change filewatch.Change) {
+	if change.Operation != filewatch.OPERATION_CREATE && change.Operation != filewatch.OPERATION_WRITE {
+		m.logFileOperation(share, change, inspection.Result{})
+		return
+	}
+
+	// 書き込みが落ち着く前に確定した場合は検査しない
+	if change.Interrupted {
+		m.logFileOperation(share, change, m.inspector.Skipped(inspection.STATUS_NOT_SETTLED))
+		return
+	}
+
+	m.inspector.Submit(change.Path, func(result inspection.Result) {
+		m.logFileOperation(share, change, result)
+	})
+}
+
+// ファイル操作を記録（USBのファイル操作イベントと同じ項目に共有の情報を追加）
+func (m *Monitor) logFileOperation(share Share, change filewatch.Change, result inspection.Result) {
+	log.Printf(
+		"[%s] Operation: %s User: %s Host: %s Share: %s Path: %s Size: %d Time: %s\n",
+		MODULE_NAME,
+		strings.ToUpper(change.Operation),
+		m.userInfo.UserName,
+		m.userInfo.HostName,
+		share.Source,
+		change.Path,
+		change.Size,
+		change.Timestamp.Format("2006-01-02 15:04:05"),
+	)
+
+	data := change.EventData(share.MountPoint, m.userInfo)
+	data["channel"] = FILE_CHANNEL
+	share.addTo(data)
+	result.AddTo(data)
+
+	severity := FILE_OPERATION_SEVERITY
+	if share.Corporate {
+		severity = CORPORATE_FILE_OPERATION_SEVERITY
+	}
+
+	m.addEvent("file_"+change.Operation, severity, data)
+}
+
+// 共有のマウントとアンマウントのイベントデータを作成
+func (m *Monitor) shareEventData(share Share) map[string]interface{} {
+	data := map[string]interface{}{
+		"user":      m.userInfo.UserName,
+		"host":      m.userInfo.HostName,
+		"timestamp": time.Now().Format(time.RFC3339),
+	}
+	share.addTo(data)
+
+	return data
+}
+
+// モニタリングを停止
+func (m *Monitor) Stop(ctx context.Context) error {
+	m.lifecycleMu.Lock()
+	cancel := m.cancel
+	m.cancel = nil
+	m.lifecycleMu.Unlock()
+
+	// 停止済みの場合は何もしない
+	if cancel == nil {
+		return nil
+	}
+
+	log.Printf("[%s] Stop...", MODULE_NAME)
+
+	// マウントの監視とすべてのファイル監視を終了
+	cancel()
+
+	// 監視ゴルーチンの終了を待機
+	if err := module.Wait(ctx, &m.wg); err != nil {
+		return fmt.Errorf("%s did not stop: %w", MODULE_NAME, err)
+	}
+
+	m.health.SetState(module.StateStopped)
+
+	return nil
+}
+
+// モジュールの稼働状況を取得
+func (m *Monitor) Health() module.Health {
+	return m.health.Snapshot()
+}
+
+// モジュールが検出したイベントを取得
+func (m *Monitor) GetEvents() []module.Event {
+	m.eventsMu.RLock()
+	defer m.eventsMu.RUnlock()
+
+	// イベントのコピーを返す（オリジナルが変更されないように）
+	eventsCopy := make([]module.Event, len(m.events))
+	copy(eventsCopy, m.events)
+
+	return eventsCopy
+}
+
+// 新しいイベントを追加
+func (m *Monitor) addEvent(eventType string, severity int, data map[string]interface{}) {
+	event := module.Event{
+		ID:        fmt.Sprintf("share-%d", time.Now().UnixNano()),
+		Timestamp: time.Now(),
+		Type:      eventType,
+		Severity:  severity,
+		Data:      data,
+	}
+
+	m.eventsMu.Lock()
+	m.events = append(m.events, event)
+	m.eventsMu.Unlock()
+
+	m.health.RecordEvent()
+
+	log.Printf("[%s] Detection new event: %s Importance: %d\n", MODULE_NAME, eventType, severity)
+
+	// イベントをsenderに送信（非同期）
+	go m.eventDispatcher.Add(event)
+}
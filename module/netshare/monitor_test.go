@@ -0,0 +1,60 @@
+package netshare
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/mniyk/endpoint-security-and-monitoring-tools/internal/config"
+	"github.com/mniyk/endpoint-security-and-monitoring-tools/internal/filewatch"
+	"github.com/mniyk/endpoint-security-and-monitoring-tools/internal/inspection"
+	"github.com/mniyk/endpoint-security-and-monitoring-tools/internal/testutil"
+	"github.com/mniyk/endpoint-security-and-monitoring-tools/internal/userinfo"
+)
+
+func TestCompleteFileOperation(t *testing.T) {
+	monitorConfig, err := NewMonitorConfig(config.Config{Options: map[string]interface{}{}})
+	if err != nil {
+		t.Fatalf("NewMonitorConfig: %v", err)
+	}
+	monitor := NewMonitor(monitorConfig, &userinfo.UserInfo{UserName: "alice", HostName: "pc"}, testutil.DiscardSink{})
+	if err := monitor.Initialize(); err != nil {
+		t.Fatalf("Initialize: %v", err)
+	}
+
+	testutil.StartInspector(t, monitor.inspector, &monitor.wg)
+
+	root := t.TempDir()
+	path := filepath.Join(root, "report.txt")
+	if err := os.WriteFile(path, []byte("hello"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	share := Share{MountPoint: root, FSType: "cifs", Source: "//nas.example.net/public", Server: "nas.example.net", RemotePath: "/public"}
+	monitor.completeFileOperation(share, filewatch.Change{Operation: filewatch.OPERATION_WRITE, Path: path, Size: 5, Timestamp: time.Now()})
+	share.Corporate = true
+	monitor.completeFileOperation(share, filewatch.Change{Operation: filewatch.OPERATION_DELETE, Path: path, Timestamp: time.Now()})
+
+	events := testutil.WaitForEvents(t, monitor, 2)
+	byType := testutil.EventsByType(events)
+
+	written, ok := byType["file_write"]
+	if !ok {
+		t.Fatalf("no file_write event in %+v", events)
+	}
+	if written.Data["channel"] != FILE_CHANNEL || written.Data["hash_status"] != inspection.HASH_STATUS_HASHED {
+		t.Fatalf("unexpected file_write data %+v", written.Data)
+	}
+	if written.Severity != FILE_OPERATION_SEVERITY || written.Data["server"] != "nas.example.net" {
+		t.Fatalf("unexpected file_write event %+v", written)
+	}
+
+	deleted, ok := byType["file_delete"]
+	if !ok || deleted.Data["channel"] != FILE_CHANNEL || deleted.Severity != CORPORATE_FILE_OPERATION_SEVERITY {
+		t.Fatalf("unexpected file_delete event %+v", deleted)
+	}
+	if _, hashed := deleted.Data["hash_status"]; hashed {
+		t.Fatal("deleted file was inspected")
+	}
+}
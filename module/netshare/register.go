@@ -0,0 +1,37 @@
+package netshare
+
+import (
+	"github.com/mniyk/endpoint-security-and-monitoring-tools/internal/config"
+	"github.com/mniyk/endpoint-security-and-monitoring-tools/internal/inspection"
+	"github.com/mniyk/endpoint-security-and-monitoring-tools/module"
+)
+
+const REGISTRY_NAME = "network_share_transfer_monitoring"
+
+// モジュールをレジストリに登録
+func init() {
+	module.Register(module.Descriptor{
+		Name:        REGISTRY_NAME,
+		Description: "Tracking of file transfers to SMB/NFS shares and SSHFS mounts",
+		Options: append([]module.OptionSpec{
+			{Name: "procfs_root", Type: "string", Default: DEFAULT_PROCFS_ROOT, Description: "Root of procfs"},
+			{Name: "file_systems", Type: "array", Default: defaultFileSystems, Description: "File system types treated as network shares"},
+			{Name: "corporate_servers", Type: "array", Default: []string{}, Description: "Corporate servers as host name patterns (e.g. *.corp.example.com) or CIDRs, shares on other servers are non-corporate"},
+			{Name: "watch_corporate", Type: "bool", Default: false, Description: "Also watch shares on corporate servers"},
+			{Name: "watch_max_depth", Type: "number", Default: DEFAULT_WATCH_MAX_DEPTH, Description: "Directories deeper than this are scanned periodically instead of watched (0 is unlimited)"},
+			{Name: "watch_max_directories", Type: "number", Default: DEFAULT_WATCH_MAX_DIRECTORIES, Description: "Maximum watched directories per share, the rest are scanned periodically (0 is unlimited)"},
+			{Name: "watch_scan_interval", Type: "number", Default: DEFAULT_WATCH_SCAN_INTERVAL, Description: "Seconds between scans of directories that are not watched"},
+		}, inspection.Options()...),
+		Platforms: []string{"linux"},
+		Factory:   newModule,
+	})
+}
+
+// 設定からモジュールを生成
+func newModule(moduleConfig config.Config, deps module.Dependencies) (module.Module, error) {
+	monitorConfig, err := NewMonitorConfig(moduleConfig)
+	if err != nil {
+		return nil, err
+	}
+	return NewMonitor(monitorConfig, deps.UserInfo, deps.EventSink), nil
+}
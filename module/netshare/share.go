@@ -0,0 +1,115 @@
+package netshare
+
+import (
+	"fmt"
+	"net"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/mniyk/endpoint-security-and-monitoring-tools/internal/mountinfo"
+)
+
+// マウントされたネットワークファイルシステムの構造体
+type Share struct {
+	MountPoint string
+	FSType     string
+	Source     string // 例: "//fileserver/share" "nas:/export" "user@host:/home/user"
+	Server     string
+	RemotePath string
+	Corporate  bool // サーバーが許可リストに含まれる
+}
+
+// イベントデータに共有の情報を追加
+func (s Share) addTo(data map[string]interface{}) {
+	data["mount_path"] = s.MountPoint
+	data["file_system"] = s.FSType
+	data["share"] = s.Source
+	data["server"] = s.Server
+	data["remote_path"] = s.RemotePath
+	data["corporate"] = s.Corporate
+}
+
+// mountinfoからネットワークファイルシステムのマウントを列挙（キーはマウントポイント）
+func listShares(mountinfoPath string, fsTypes []string, corporateServers []string) (map[string]Share, error) {
+	mounts, err := mountinfo.ReadFile(mountinfoPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed reading mountinfo: %w", err)
+	}
+
+	shares := make(map[string]Share)
+	for _, mount := range mounts {
+		if !slices.Contains(fsTypes, mount.FSType) {
+			continue
+		}
+
+		server, remotePath := parseSource(mount.Source)
+		shares[mount.MountPoint] = Share{
+			MountPoint: mount.MountPoint,
+			FSType:     mount.FSType,
+			Source:     mount.Source,
+			Server:     server,
+			RemotePath: remotePath,
+			Corporate:  isCorporateServer(server, corporateServers),
+		}
+	}
+
+	return shares, nil
+}
+
+// マウント元からサーバーと共有のパスを取得
+// 例: "//fileserver/share/dir" "nas:/export" "[fd00::1]:/export" "user@host:/home/user"
+func parseSource(source string) (string, string) {
+	// SMB/CIFS（"\\server\share" の形式も可）
+	if strings.HasPrefix(source, "//") || strings.HasPrefix(source, `\\`) {
+		server, remotePath, _ := strings.Cut(strings.ReplaceAll(source, `\`, "/")[2:], "/")
+		return server, "/" + remotePath
+	}
+
+	// NFSとSSHFS（"host:path"）
+	hostPart, remotePath, found := "", source, false
+	if strings.HasPrefix(source, "[") {
+		if end := strings.Index(source, "]:"); end >= 0 {
+			hostPart, remotePath, found = source[1:end], source[end+2:], true
+		}
+	} else {
+		hostPart, remotePath, found = strings.Cut(source, ":")
+	}
+	if !found {
+		return "", source
+	}
+
+	// SSHFSのユーザー名
+	if _, host, hasUser := strings.Cut(hostPart, "@"); hasUser {
+		hostPart = host
+	}
+
+	return hostPart, remotePath
+}
+
+// サーバーが許可リストに含まれるかを確認（ホスト名のパターンまたはCIDR）
+// 例: "*.corp.example.com" "fileserver" "10.0.0.0/8"
+func isCorporateServer(server string, corporateServers []string) bool {
+	if server == "" {
+		return false
+	}
+
+	server = strings.ToLower(server)
+	ip := net.ParseIP(server)
+	for _, pattern := range corporateServers {
+		pattern = strings.ToLower(pattern)
+
+		if _, network, err := net.ParseCIDR(pattern); err == nil {
+			if ip != nil && network.Contains(ip) {
+				return true
+			}
+			continue
+		}
+
+		if matched, err := path.Match(pattern, server); err == nil && matched {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,59 @@
+package netshare
+
+import "testing"
+
+func TestParseSource(t *testing.T) {
+	tests := []struct {
+		source     string
+		server     string
+		remotePath string
+	}{
+		{"//fileserver/share/dir", "fileserver", "/share/dir"},
+		{"//srv/share", "srv", "/share"},
+		{`\\srv\share\dir`, "srv", "/share/dir"},
+		{`\\srv`, "srv", "/"},
+		{"nas:/export", "nas", "/export"},
+		{"[fd00::1]:/export", "fd00::1", "/export"},
+		{"[v6]:/x", "v6", "/x"},
+		{"user@host:/home/user", "host", "/home/user"},
+		{"user@host:", "host", ""},
+		{"[fd00::1]/export", "", "[fd00::1]/export"},
+		{"tmpfs", "", "tmpfs"},
+	}
+
+	for _, tt := range tests {
+		server, remotePath := parseSource(tt.source)
+		if server != tt.server || remotePath != tt.remotePath {
+			t.Errorf("parseSource(%q) = %q, %q, want %q, %q", tt.source, server, remotePath, tt.server, tt.remotePath)
+		}
+	}
+}
+
+func TestIsCorporateServer(t *testing.T) {
+	corporateServers := []string{"*.Corp.example.com", "fileserver", "10.0.0.0/8", "fd00::/8", "[invalid"}
+
+	tests := []struct {
+		server string
+		want   bool
+	}{
+		{"nas.corp.example.com", true},
+		{"NAS.CORP.EXAMPLE.COM", true},
+		{"corp.example.com", false},
+		{"nas.corp.example.com.evil.net", false},
+		{"fileserver", true},
+		{"fileserver2", false},
+		{"10.1.2.3", true},
+		{"11.1.2.3", false},
+		{"fd00::1", true},
+		{"fe80::1", false},
+		// CIDRはIPアドレスのサーバーのみ一致
+		{"10.example.com", false},
+		{"", false},
+	}
+
+	for _, tt := range tests {
+		if got := isCorporateServer(tt.server, corporateServers); got != tt.want {
+			t.Errorf("isCorporateServer(%q) = %v, want %v", tt.server, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,237 @@
+package optical
+
+import (
+	"bytes"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mniyk/endpoint-security-and-monitoring-tools/internal/procinfo"
+	"github.com/mniyk/endpoint-security-and-monitoring-tools/internal/sysfs"
+)
+
+const (
+	SOURCE_IMAGE   = "image"   // イメージファイルを書き込む
+	SOURCE_SESSION = "session" // ディレクトリやファイルからセッションを作成して書き込む
+)
+
+// 書き込み先のデバイスを指定するオプション（次の引数がデバイス）
+var deviceOptions = map[string]bool{
+	"-dev":    true, // xorriso
+	"-outdev": true, // xorriso
+	"-Z":      true, // growisofs（"-Z /dev/sr0=image.iso" の形式も可）
+	"-M":      true, // growisofs
+}
+
+// 次の引数がディスク上のパス、その次の引数がイメージ内のパスのxorrisoのオプション
+var mapOptions = map[string]bool{
+	"-map":        true,
+	"-map_single": true,
+	"-update":     true,
+	"-update_r":   true,
+}
+
+// 書き込みツールのプロセスの構造体
+type Burn struct {
+	PID         int
+	Tool        string
+	Args        []string
+	Device      string    // 書き込み先のデバイス（特定できない場合は空）
+	Sources     []string  // 書き込むイメージファイルまたはセッションの元のパス
+	SourceType  string    // "image" "session"
+	Size        int64     // イメージまたはセッションの元のパスの合計サイズ（ディレクトリは配下のファイルの合計）
+	StartedAt   time.Time // プロセスの開始時刻（取得できない場合は検出した時刻）
+	Executable  string
+	User        string
+	CommandLine string
+}
+
+// procfsから書き込みツールのプロセスを列挙（キーはPID）
+func listBurns(procfsRoot string, tools []string) (map[int]*Burn, error) {
+	entries, err := os.ReadDir(procfsRoot)
+	if err != nil {
+		return nil, fmt.Errorf("failed reading %s: %w", procfsRoot, err)
+	}
+
+	burns := make(map[int]*Burn)
+	for _, entry := range entries {
+		pid, err := strconv.Atoi(entry.Name())
+		if err != nil {
+			continue
+		}
+
+		// 終了済みの場合は読み込めない
+		root := filepath.Join(procfsRoot, entry.Name())
+		cmdline, err := os.ReadFile(filepath.Join(root, "cmdline"))
+		if err != nil || len(cmdline) == 0 {
+			continue
+		}
+		args := strings.Split(string(bytes.TrimRight(cmdline, "\x00")), "\x00")
+
+		// commは15文字で切り詰められるため、コマンドの名前でも照合（例: xorrisoのxorrecordとしての起動）
+		tool := ""
+		comm := sysfs.ReadValue(filepath.Join(root, "comm"))
+		for _, name := range []string{filepath.Base(args[0]), comm} {
+			if slices.Contains(tools, name) {
+				tool = name
+				break
+			}
+		}
+		if tool == "" {
+			continue
+		}
+
+		burns[pid] = &Burn{PID: pid, Tool: tool, Args: args}
+	}
+
+	return burns, nil
+}
+
+// 新しく検出したプロセスの書き込み先、書き込む内容のサイズ、実行ユーザーを取得
+func (b *Burn) inspect(procfsRoot string) {
+	// 検出までのポーリング間隔の分だけ書き込み時間が短くならないよう、プロセスの開始時刻を使用
+	startedAt, err := procinfo.StartedAt(procfsRoot, b.PID)
+	if err != nil {
+		startedAt = time.Now()
+	}
+	b.StartedAt = startedAt
+
+	cwd, _ := os.Readlink(filepath.Join(procfsRoot, strconv.Itoa(b.PID), "cwd"))
+	b.Device, b.Sources = parseBurnArgs(b.Args[1:], cwd)
+	b.Size, b.SourceType = sourcesSize(b.Sources)
+
+	// 終了済みの場合などは取得できた項目のみ使用
+	process, _ := procinfo.Lookup(procfsRoot, b.PID)
+	b.Executable = process.Executable
+	b.User = process.User
+	b.CommandLine = strings.Join(b.Args, " ")
+}
+
+// イベントデータに書き込みツールの情報を追加
+func (b *Burn) addTo(data map[string]interface{}) {
+	data["tool"] = b.Tool
+	data["pid"] = b.PID
+	data["executable"] = b.Executable
+	data["command_line"] = b.CommandLine
+	data["process_user"] = b.User
+	data["target_device"] = b.Device
+	data["sources"] = b.Sources
+	data["source_type"] = b.SourceType
+	data["size"] = b.Size
+}
+
+// 引数から書き込み先のデバイスと、書き込むイメージファイルまたはセッションの元のパスを取得
+// 例: "wodim dev=/dev/sr0 image.iso" "growisofs -Z /dev/sr0=image.iso" "growisofs -Z /dev/sr0 -R -J dir"
+func parseBurnArgs(args []string, cwd string) (string, []string) {
+	device := ""
+	var sources []string
+
+	addSource := func(path string) {
+		if path == "" {
+			return
+		}
+		if !filepath.IsAbs(path) && cwd != "" {
+			path = filepath.Join(cwd, path)
+		}
+		// オプションの値などの存在しないパス、デバイスファイル、ルートディレクトリは除外
+		info, err := os.Stat(path)
+		if err != nil || info.Mode()&fs.ModeDevice != 0 || filepath.Dir(path) == path {
+			return
+		}
+		if !slices.Contains(sources, path) {
+			sources = append(sources, path)
+		}
+	}
+
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+
+		switch {
+		case deviceOptions[arg] && i+1 < len(args):
+			i++
+			target, image, _ := strings.Cut(args[i], "=")
+			device = target
+			addSource(image)
+
+		case mapOptions[arg] && i+2 < len(args):
+			addSource(args[i+1])
+			i += 2
+
+		case strings.HasPrefix(arg, "dev="):
+			device = strings.TrimPrefix(arg, "dev=")
+
+		case strings.HasPrefix(arg, "-"):
+			// その他のオプション
+
+		case strings.Contains(arg, "="):
+			// wodimの "speed=8" などのオプション
+
+		case device == "" && strings.HasPrefix(arg, "/dev/"):
+			device = arg
+
+		default:
+			addSource(arg)
+		}
+	}
+
+	// xorrisoの "stdio:/dev/sr0" の形式
+	device = strings.TrimPrefix(device, "stdio:")
+
+	return device, sources
+}
+
+// イメージファイルまたはセッションの元のパスの合計サイズを取得
+func sourcesSize(sources []string) (int64, string) {
+	if len(sources) == 0 {
+		return 0, ""
+	}
+
+	var size int64
+	sourceType := SOURCE_IMAGE
+	for _, source := range sources {
+		filepath.WalkDir(source, func(path string, entry fs.DirEntry, err error) error {
+			if err != nil {
+				return nil
+			}
+			if entry.IsDir() {
+				sourceType = SOURCE_SESSION
+				return nil
+			}
+			if info, err := entry.Info(); err == nil && info.Mode().IsRegular() {
+				size += info.Size()
+			}
+			return nil
+		})
+	}
+
+	// 複数のファイルはセッションとして書き込まれる
+	if len(sources) != 1 {
+		sourceType = SOURCE_SESSION
+	}
+
+	return size, sourceType
+}
+
+// 書き込み先のデバイスのパスから光学ドライブを特定（"/dev/cdrw" などのリンクも解決）
+func matchDrive(device string, drives map[string]*Drive) *Drive {
+	if device == "" {
+		// デバイスを指定しない場合はツールが最初のドライブを使用するため、1台のみの場合のみ特定
+		if len(drives) == 1 {
+			for _, drive := range drives {
+				return drive
+			}
+		}
+		return nil
+	}
+
+	if resolved, err := filepath.EvalSymlinks(device); err == nil {
+		device = resolved
+	}
+
+	return drives[filepath.Base(device)]
+}
@@ -0,0 +1,107 @@
+package optical
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/mniyk/endpoint-security-and-monitoring-tools/internal/testutil"
+)
+
+func TestParseBurnArgs(t *testing.T) {
+	cwd := t.TempDir()
+	testutil.WriteFiles(t, cwd, map[string]string{
+		"backup.iso":         "iso",
+		"photos/a.jpg":       "a",
+		"docs/report.pdf":    "pdf",
+		"docs/sub/notes.txt": "notes",
+	})
+	image := filepath.Join(cwd, "backup.iso")
+	photos := filepath.Join(cwd, "photos")
+	docs := filepath.Join(cwd, "docs")
+
+	tests := []struct {
+		name    string
+		args    []string
+		device  string
+		sources []string
+	}{
+		{"wodim dev=", []string{"-v", "dev=/dev/sr0", "speed=8", "-eject", image}, "/dev/sr0", []string{image}},
+		{"wodim relative image", []string{"dev=/dev/sr1", "backup.iso"}, "/dev/sr1", []string{image}},
+		{"growisofs -Z dev=image", []string{"-dvd-compat", "-Z", "/dev/sr0=backup.iso"}, "/dev/sr0", []string{image}},
+		{"growisofs -Z session", []string{"-Z", "/dev/sr0", "-R", "-J", "photos", "docs"}, "/dev/sr0", []string{photos, docs}},
+		{"xorriso -outdev stdio:", []string{"-outdev", "stdio:/dev/sr0", "-map", "docs", "/docs", "-map", photos, "/photos"}, "/dev/sr0", []string{docs, photos}},
+		{"xorriso -map_single", []string{"-dev", "/dev/sr0", "-map_single", "docs/report.pdf", "/report.pdf"}, "/dev/sr0", []string{filepath.Join(docs, "report.pdf")}},
+		// 存在しないパス、重複、デバイスファイル、ルートディレクトリは除外
+		{"ignored paths", []string{"dev=/dev/sr0", "missing.iso", image, "backup.iso", "/dev/null", "/"}, "/dev/sr0", []string{image}},
+		{"device without option", []string{"/dev/sr0", image}, "/dev/sr0", []string{image}},
+		{"no device", []string{image}, "", []string{image}},
+	}
+
+	for _, tt := range tests {
+		device, sources := parseBurnArgs(tt.args, cwd)
+		if device != tt.device || !reflect.DeepEqual(sources, tt.sources) {
+			t.Errorf("%s: parseBurnArgs(%v) = %q, %v, want %q, %v", tt.name, tt.args, device, sources, tt.device, tt.sources)
+		}
+	}
+}
+
+func TestSourcesSize(t *testing.T) {
+	root := t.TempDir()
+	testutil.WriteFiles(t, root, map[string]string{
+		"backup.iso":      "12345",
+		"docs/report.pdf": "123",
+		"docs/sub/a.txt":  "12",
+	})
+
+	tests := []struct {
+		name       string
+		sources    []string
+		size       int64
+		sourceType string
+	}{
+		{"single image", []string{filepath.Join(root, "backup.iso")}, 5, SOURCE_IMAGE},
+		{"directory", []string{filepath.Join(root, "docs")}, 5, SOURCE_SESSION},
+		{"several files", []string{filepath.Join(root, "backup.iso"), filepath.Join(root, "docs", "report.pdf")}, 8, SOURCE_SESSION},
+		{"none", nil, 0, ""},
+	}
+
+	for _, tt := range tests {
+		if size, sourceType := sourcesSize(tt.sources); size != tt.size || sourceType != tt.sourceType {
+			t.Errorf("%s: sourcesSize = %d, %q, want %d, %q", tt.name, size, sourceType, tt.size, tt.sourceType)
+		}
+	}
+}
+
+func TestMatchDrive(t *testing.T) {
+	devRoot := t.TempDir()
+	testutil.WriteFiles(t, devRoot, map[string]string{"sr0": "", "sr1": ""})
+	if err := os.Symlink("sr1", filepath.Join(devRoot, "cdrw")); err != nil {
+		t.Fatal(err)
+	}
+
+	sr0 := &Drive{Name: "sr0"}
+	sr1 := &Drive{Name: "sr1"}
+	drives := map[string]*Drive{"sr0": sr0, "sr1": sr1}
+
+	tests := []struct {
+		name   string
+		device string
+		drives map[string]*Drive
+		want   *Drive
+	}{
+		{"device path", filepath.Join(devRoot, "sr0"), drives, sr0},
+		{"symlink", filepath.Join(devRoot, "cdrw"), drives, sr1},
+		{"unknown device", filepath.Join(devRoot, "sr9"), drives, nil},
+		// デバイスの指定がない場合はドライブが1台のみの場合のみ特定
+		{"no device with one drive", "", map[string]*Drive{"sr0": sr0}, sr0},
+		{"no device with two drives", "", drives, nil},
+	}
+
+	for _, tt := range tests {
+		if got := matchDrive(tt.device, tt.drives); got != tt.want {
+			t.Errorf("%s: matchDrive(%q) = %+v, want %+v", tt.name, tt.device, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,141 @@
+package optical
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/mniyk/endpoint-security-and-monitoring-tools/internal/sysfs"
+)
+
+const (
+	SECTOR_SIZE = 512 // sysfsのsizeの単位
+
+	MEDIA_STATE_BLANK = "blank"
+)
+
+// udevがメディアの種類以外に付けるID_CDROM_MEDIA_で始まる項目
+var udevMediaProperties = map[string]bool{
+	"ID_CDROM_MEDIA_BLANK":               true,
+	"ID_CDROM_MEDIA_STATE":               true,
+	"ID_CDROM_MEDIA_SESSION_COUNT":       true,
+	"ID_CDROM_MEDIA_TRACK_COUNT":         true,
+	"ID_CDROM_MEDIA_TRACK_COUNT_DATA":    true,
+	"ID_CDROM_MEDIA_TRACK_COUNT_AUDIO":   true,
+	"ID_CDROM_MEDIA_SESSION_NEXT":        true,
+	"ID_CDROM_MEDIA_SESSION_LAST_OFFSET": true,
+}
+
+// 光学ドライブの構造体
+type Drive struct {
+	Name         string // 例: "sr0"
+	DevicePath   string // 例: "/dev/sr0"
+	Vendor       string
+	Model        string
+	Revision     string
+	MediaPresent bool
+	MediaType    string // 例: "cd_r" "dvd_plus_rw" "bd_r"（udevから取得できない場合は空）
+	MediaBlank   bool
+	MediaSize    int64 // 読み込めるデータのバイト数（空のメディアは0）
+}
+
+// イベントデータにドライブの情報を追加
+func (d *Drive) addTo(data map[string]interface{}) {
+	if d == nil {
+		return
+	}
+
+	data["drive"] = d.Name
+	data["device_path"] = d.DevicePath
+	data["vendor"] = d.Vendor
+	data["model"] = d.Model
+	data["revision"] = d.Revision
+	data["media_present"] = d.MediaPresent
+	if d.MediaPresent {
+		data["media_type"] = d.MediaType
+		data["media_blank"] = d.MediaBlank
+		data["media_size"] = d.MediaSize
+	}
+}
+
+// sysfsから光学ドライブ（/sys/block/sr*）とメディアの状態を列挙（キーはデバイス名）
+func listDrives(sysfsRoot string, devRoot string, udevDataRoot string) (map[string]*Drive, error) {
+	blockRoot := filepath.Join(sysfsRoot, "block")
+	entries, err := os.ReadDir(blockRoot)
+	if err != nil {
+		return nil, fmt.Errorf("failed reading %s: %w", blockRoot, err)
+	}
+
+	drives := make(map[string]*Drive)
+	for _, entry := range entries {
+		if !strings.HasPrefix(entry.Name(), "sr") {
+			continue
+		}
+
+		blockPath := filepath.Join(blockRoot, entry.Name())
+		drive := &Drive{
+			Name:       entry.Name(),
+			DevicePath: filepath.Join(devRoot, entry.Name()),
+			Vendor:     sysfs.ReadValue(filepath.Join(blockPath, "device", "vendor")),
+			Model:      sysfs.ReadValue(filepath.Join(blockPath, "device", "model")),
+			Revision:   sysfs.ReadValue(filepath.Join(blockPath, "device", "rev")),
+		}
+
+		if sectors, err := strconv.ParseInt(sysfs.ReadValue(filepath.Join(blockPath, "size")), 10, 64); err == nil {
+			drive.MediaSize = sectors * SECTOR_SIZE
+		}
+
+		// ドライブに問い合わせられない場合（権限がないなど）は、空のメディアを検出できないがサイズで判定
+		present, known := mediaPresent(drive.DevicePath)
+		if !known {
+			present = drive.MediaSize > 0
+		}
+		drive.MediaPresent = present
+
+		if present {
+			drive.MediaType, drive.MediaBlank = readUdevMedia(udevDataRoot, sysfs.ReadValue(filepath.Join(blockPath, "dev")))
+		}
+
+		drives[drive.Name] = drive
+	}
+
+	return drives, nil
+}
+
+// udevのデータベース（/run/udev/data/b<メジャー>:<マイナー>）からメディアの種類と空かどうかを取得
+func readUdevMedia(udevDataRoot string, devNumber string) (string, bool) {
+	if devNumber == "" {
+		return "", false
+	}
+
+	file, err := os.Open(filepath.Join(udevDataRoot, "b"+devNumber))
+	if err != nil {
+		return "", false
+	}
+	defer file.Close()
+
+	mediaType := ""
+	blank := false
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		// 例: "E:ID_CDROM_MEDIA_DVD_PLUS_R=1"
+		key, value, found := strings.Cut(strings.TrimPrefix(scanner.Text(), "E:"), "=")
+		if !found || !strings.HasPrefix(key, "ID_CDROM_MEDIA_") {
+			continue
+		}
+
+		switch {
+		case key == "ID_CDROM_MEDIA_BLANK":
+			blank = blank || value == "1"
+		case key == "ID_CDROM_MEDIA_STATE":
+			blank = blank || value == MEDIA_STATE_BLANK
+		case !udevMediaProperties[key] && value == "1" && mediaType == "":
+			mediaType = strings.ToLower(strings.TrimPrefix(key, "ID_CDROM_MEDIA_"))
+		}
+	}
+
+	return mediaType, blank
+}
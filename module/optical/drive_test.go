@@ -0,0 +1,85 @@
+package optical
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/mniyk/endpoint-security-and-monitoring-tools/internal/testutil"
+)
+
+func TestReadUdevMedia(t *testing.T) {
+	udevDataRoot := t.TempDir()
+	testutil.WriteFiles(t, udevDataRoot, map[string]string{
+		"b11:0": "S:cdrom\nE:ID_CDROM=1\nE:ID_CDROM_MEDIA=1\nE:ID_CDROM_MEDIA_DVD_PLUS_R=1\nE:ID_CDROM_MEDIA_STATE=blank\nE:ID_CDROM_MEDIA_TRACK_COUNT=1\n",
+		"b11:1": "E:ID_CDROM_MEDIA_BLANK=1\nE:ID_CDROM_MEDIA_CD_R=1\n",
+		"b11:2": "E:ID_CDROM_MEDIA_SESSION_COUNT=1\nE:ID_CDROM_MEDIA_BD=1\nE:ID_CDROM_MEDIA_STATE=complete\n",
+	})
+
+	tests := []struct {
+		devNumber string
+		mediaType string
+		blank     bool
+	}{
+		{"11:0", "dvd_plus_r", true},
+		{"11:1", "cd_r", true},
+		{"11:2", "bd", false},
+		{"11:3", "", false},
+		{"", "", false},
+	}
+
+	for _, tt := range tests {
+		mediaType, blank := readUdevMedia(udevDataRoot, tt.devNumber)
+		if mediaType != tt.mediaType || blank != tt.blank {
+			t.Errorf("readUdevMedia(%q) = %q, %v, want %q, %v", tt.devNumber, mediaType, blank, tt.mediaType, tt.blank)
+		}
+	}
+}
+
+func TestListDrives(t *testing.T) {
+	root := t.TempDir()
+	sysfsRoot := filepath.Join(root, "sys")
+	devRoot := filepath.Join(root, "dev")
+	udevDataRoot := filepath.Join(root, "udev")
+
+	testutil.WriteFiles(t, sysfsRoot, map[string]string{
+		"block/sr0/dev":           "11:0\n",
+		"block/sr0/size":          "2048\n",
+		"block/sr0/device/vendor": "HL-DT-ST\n",
+		"block/sr0/device/model":  "DVDRAM GP65NB60\n",
+		"block/sr0/device/rev":    "PF00\n",
+		"block/sr1/dev":           "11:1\n",
+		"block/sr1/size":          "0\n",
+		"block/sda/size":          "1000\n",
+	})
+	testutil.WriteFiles(t, udevDataRoot, map[string]string{
+		"b11:0": "E:ID_CDROM_MEDIA_CD=1\n",
+		"b11:1": "E:ID_CDROM_MEDIA_CD_R=1\nE:ID_CDROM_MEDIA_BLANK=1\n",
+	})
+
+	drives, err := listDrives(sysfsRoot, devRoot, udevDataRoot)
+	if err != nil {
+		t.Fatalf("listDrives: %v", err)
+	}
+	if len(drives) != 2 {
+		t.Fatalf("listDrives found %d drives, want sr0 and sr1: %v", len(drives), drives)
+	}
+
+	// ドライブに問い合わせられないため、メディアの有無はサイズで判定
+	sr0 := drives["sr0"]
+	want := Drive{
+		Name: "sr0", DevicePath: filepath.Join(devRoot, "sr0"), Vendor: "HL-DT-ST", Model: "DVDRAM GP65NB60", Revision: "PF00",
+		MediaPresent: true, MediaType: "cd", MediaSize: 2048 * SECTOR_SIZE,
+	}
+	if sr0 == nil || *sr0 != want {
+		t.Errorf("sr0 = %+v, want %+v", sr0, want)
+	}
+
+	sr1 := drives["sr1"]
+	if sr1 == nil || sr1.MediaPresent || sr1.MediaType != "" || sr1.MediaBlank {
+		t.Errorf("sr1 without media = %+v", sr1)
+	}
+
+	if _, err := listDrives(filepath.Join(root, "missing"), devRoot, udevDataRoot); err == nil {
+		t.Error("listDrives without sysfs succeeded")
+	}
+}
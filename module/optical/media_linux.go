@@ -0,0 +1,29 @@
+package optical
+
+import (
+	"golang.org/x/sys/unix"
+)
+
+// linux/cdrom.h の定義
+const (
+	CDROM_DRIVE_STATUS = 0x5326
+	CDSL_CURRENT       = 0x7fffffff
+	CDS_DISC_OK        = 4
+)
+
+// ドライブにメディアの有無を問い合わせる（空のメディアも検出、問い合わせられない場合はknownがfalse）
+func mediaPresent(devicePath string) (present bool, known bool) {
+	// O_NONBLOCKでメディアがなくても開ける
+	fd, err := unix.Open(devicePath, unix.O_RDONLY|unix.O_NONBLOCK|unix.O_CLOEXEC, 0)
+	if err != nil {
+		return false, false
+	}
+	defer unix.Close(fd)
+
+	status, _, errno := unix.Syscall(unix.SYS_IOCTL, uintptr(fd), CDROM_DRIVE_STATUS, CDSL_CURRENT)
+	if errno != 0 {
+		return false, false
+	}
+
+	return status == CDS_DISC_OK, true
+}
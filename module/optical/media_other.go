@@ -0,0 +1,8 @@
+//go:build !linux
+
+package optical
+
+// 対応していないプラットフォームではドライブに問い合わせない
+func mediaPresent(devicePath string) (present bool, known bool) {
+	return false, false
+}
@@ -0,0 +1,327 @@
+package optical
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/mniyk/endpoint-security-and-monitoring-tools/internal/config"
+	"github.com/mniyk/endpoint-security-and-monitoring-tools/internal/userinfo"
+	"github.com/mniyk/endpoint-security-and-monitoring-tools/module"
+)
+
+const (
+	MONITOR_INTERVAL            = 2 * time.Second
+	MODULE_NAME                 = "Optical Media Burning Monitoring"
+	DEFAULT_SYSFS_ROOT          = "/sys"
+	DEFAULT_PROCFS_ROOT         = "/proc"
+	DEFAULT_DEV_ROOT            = "/dev"
+	DEFAULT_UDEV_DATA_ROOT      = "/run/udev/data"
+	CONNECTED_DRIVE_SEVERITY    = 2
+	DISCONNECTED_DRIVE_SEVERITY = 2
+	INSERTED_MEDIA_SEVERITY     = 3
+	REMOVED_MEDIA_SEVERITY      = 2
+	BURN_STARTED_SEVERITY       = 5
+	BURN_FINISHED_SEVERITY      = 5
+	UNKNOWN_DRIVE_BURN_SEVERITY = 4 // 光学ドライブ以外（イメージファイルなど）への書き込みの可能性がある
+)
+
+// 既定で監視する書き込みツール（xorrecordとcdrskinはcdrecord互換のコマンド）
+var defaultBurningTools = []string{"wodim", "growisofs", "xorriso", "cdrecord", "xorrecord", "cdrskin"}
+
+// Optical Media Burning Monitoringの設定の構造体
+type MonitorConfig struct {
+	SysfsRoot    string   `json:"sysfs_root"`     // sysfsを読み込むルート
+	ProcfsRoot   string   `json:"procfs_root"`    // procfsを読み込むルート
+	DevRoot      string   `json:"dev_root"`       // デバイスファイルのディレクトリ
+	UdevDataRoot string   `json:"udev_data_root"` // udevのデータベースのディレクトリ（メディアの種類の取得用）
+	BurningTools []string `json:"burning_tools"`  // 書き込みツールのコマンド名
+}
+
+// 新しいMonitorConfigを作成
+func NewMonitorConfig(moduleConfig config.Config) *MonitorConfig {
+	return &MonitorConfig{
+		SysfsRoot:    moduleConfig.String("sysfs_root", DEFAULT_SYSFS_ROOT),
+		ProcfsRoot:   moduleConfig.String("procfs_root", DEFAULT_PROCFS_ROOT),
+		DevRoot:      moduleConfig.String("dev_root", DEFAULT_DEV_ROOT),
+		UdevDataRoot: moduleConfig.String("udev_data_root", DEFAULT_UDEV_DATA_ROOT),
+		BurningTools: moduleConfig.Strings("burning_tools", defaultBurningTools),
+	}
+}
+
+// 監視のための構造体
+type Monitor struct {
+	events          []module.Event
+	config          MonitorConfig
+	cancel          context.CancelFunc
+	lifecycleMu     sync.Mutex
+	wg              sync.WaitGroup
+	eventsMu        sync.RWMutex
+	drives          map[string]*Drive
+	burns           map[int]*Burn
+	userInfo        *userinfo.UserInfo
+	eventDispatcher module.EventSink
+	health          *module.HealthTracker
+}
+
+// 新しいMonitorを作成
+func NewMonitor(config *MonitorConfig, userInfo *userinfo.UserInfo, eventDispatcher module.EventSink) *Monitor {
+	return &Monitor{
+		config:          *config,
+		events:          make([]module.Event, 0),
+		drives:          make(map[string]*Drive),
+		burns:           make(map[int]*Burn),
+		userInfo:        userInfo,
+		eventDispatcher: eventDispatcher,
+		health:          module.NewHealthTracker(),
+	}
+}
+
+// モジュールを初期化
+func (m *Monitor) Initialize() error {
+	m.health.SetState(module.StateInitializing)
+	m.drives = make(map[string]*Drive)
+	m.burns = make(map[int]*Burn)
+	log.Printf("[%s] Initialize...", MODULE_NAME)
+
+	if len(m.config.BurningTools) == 0 {
+		return fmt.Errorf("burning_tools must not be empty")
+	}
+
+	return nil
+}
+
+// モニタリングを開始
+func (m *Monitor) Start(ctx context.Context) error {
+	m.lifecycleMu.Lock()
+	defer m.lifecycleMu.Unlock()
+
+	if m.cancel != nil {
+		return fmt.Errorf("%s already started", MODULE_NAME)
+	}
+
+	log.Printf("[%s] Start...", MODULE_NAME)
+
+	ctx, m.cancel = context.WithCancel(ctx)
+	m.health.SetState(module.StateRunning)
+
+	// ドライブと書き込みツールの監視を開始
+	module.Go(ctx, &m.wg, func() {
+		m.startDriveMonitoring(ctx)
+	})
+
+	return nil
+}
+
+// 監視の継続的なループを実行
+func (m *Monitor) startDriveMonitoring(ctx context.Context) {
+	ticker := time.NewTicker(MONITOR_INTERVAL)
+	defer ticker.Stop()
+
+	// 監視者にループを登録
+	heartbeat := module.StartHeartbeat(ctx, "drive_monitoring")
+	defer heartbeat.Stop()
+
+	for {
+		// 監視者へ生存を通知
+		heartbeat.Beat()
+
+		m.scanDrives()
+		m.scanBurns()
+		m.health.MarkScan()
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// 光学ドライブの接続と切断、メディアの挿入と取り出しを検出
+func (m *Monitor) scanDrives() {
+	drives, err := listDrives(m.config.SysfsRoot, m.config.DevRoot, m.config.UdevDataRoot)
+	if err != nil {
+		log.Printf("[%s] Failed enumerating drives: %v\n", MODULE_NAME, err)
+		m.health.RecordError(err)
+		return
+	}
+
+	for name, drive := range drives {
+		previous, exists := m.drives[name]
+		if !exists {
+			log.Printf("[%s] Connected optical drive %s %s (%s)\n", MODULE_NAME, drive.Vendor, drive.Model, drive.DevicePath)
+			m.addEvent("optical_drive_connected", CONNECTED_DRIVE_SEVERITY, m.driveEventData(drive))
+		}
+
+		switch {
+		case drive.MediaPresent && (!exists || !previous.MediaPresent):
+			log.Printf("[%s] Inserted media in %s Type: %s Blank: %t Size: %d\n", MODULE_NAME, drive.DevicePath, drive.MediaType, drive.MediaBlank, drive.MediaSize)
+			m.addEvent("optical_media_inserted", INSERTED_MEDIA_SEVERITY, m.driveEventData(drive))
+		case !drive.MediaPresent && exists && previous.MediaPresent:
+			log.Printf("[%s] Removed media from %s\n", MODULE_NAME, drive.DevicePath)
+			data := m.driveEventData(drive)
+			// 取り出したメディアの情報
+			data["media_type"] = previous.MediaType
+			data["media_size"] = previous.MediaSize
+			m.addEvent("optical_media_removed", REMOVED_MEDIA_SEVERITY, data)
+		}
+	}
+
+	for name, drive := range m.drives {
+		if _, exists := drives[name]; !exists {
+			log.Printf("[%s] Disconnected optical drive %s %s (%s)\n", MODULE_NAME, drive.Vendor, drive.Model, drive.DevicePath)
+			m.addEvent("optical_drive_disconnected", DISCONNECTED_DRIVE_SEVERITY, m.driveEventData(drive))
+		}
+	}
+
+	m.drives = drives
+
+	mediaCount := 0
+	for _, drive := range drives {
+		if drive.MediaPresent {
+			mediaCount++
+		}
+	}
+	m.health.SetDetail("optical_drives", len(drives))
+	m.health.SetDetail("media_present", mediaCount)
+}
+
+// 書き込みツールの起動と終了を検出
+func (m *Monitor) scanBurns() {
+	burns, err := listBurns(m.config.ProcfsRoot, m.config.BurningTools)
+	if err != nil {
+		log.Printf("[%s] Failed enumerating processes: %v\n", MODULE_NAME, err)
+		m.health.RecordError(err)
+		return
+	}
+
+	for pid, burn := range burns {
+		if previous, exists := m.burns[pid]; exists {
+			burns[pid] = previous
+			continue
+		}
+
+		burn.inspect(m.config.ProcfsRoot)
+		drive := matchDrive(burn.Device, m.drives)
+
+		log.Printf("[%s] Started burning Tool: %s PID: %d Device: %s Sources: %v Size: %d\n", MODULE_NAME, burn.Tool, burn.PID, burn.Device, burn.Sources, burn.Size)
+
+		severity := BURN_STARTED_SEVERITY
+		if drive == nil {
+			severity = UNKNOWN_DRIVE_BURN_SEVERITY
+		}
+		m.addEvent("optical_burn_started", severity, m.burnEventData(burn, drive))
+	}
+
+	for pid, burn := range m.burns {
+		if _, exists := burns[pid]; exists {
+			continue
+		}
+
+		// 終了後のメディアの状態（書き込まれたサイズなど）を記録
+		drive := matchDrive(burn.Device, m.drives)
+		duration := time.Since(burn.StartedAt)
+
+		log.Printf("[%s] Finished burning Tool: %s PID: %d Device: %s Duration: %s\n", MODULE_NAME, burn.Tool, burn.PID, burn.Device, duration.Round(time.Second))
+
+		severity := BURN_FINISHED_SEVERITY
+		if drive == nil {
+			severity = UNKNOWN_DRIVE_BURN_SEVERITY
+		}
+		data := m.burnEventData(burn, drive)
+		data["duration_seconds"] = int(duration.Seconds())
+		m.addEvent("optical_burn_finished", severity, data)
+	}
+
+	m.burns = burns
+	m.health.SetDetail("active_burns", len(burns))
+}
+
+// ドライブとメディアのイベントデータを作成
+func (m *Monitor) driveEventData(drive *Drive) map[string]interface{} {
+	data := map[string]interface{}{
+		"user":      m.userInfo.UserName,
+		"host":      m.userInfo.HostName,
+		"timestamp": time.Now().Format(time.RFC3339),
+	}
+	drive.addTo(data)
+
+	return data
+}
+
+// 書き込みのイベントデータを作成（ドライブを特定できない場合はツールの情報のみ）
+func (m *Monitor) burnEventData(burn *Burn, drive *Drive) map[string]interface{} {
+	data := m.driveEventData(drive)
+	data["started_at"] = burn.StartedAt.Format(time.RFC3339)
+	burn.addTo(data)
+
+	return data
+}
+
+// モニタリングを停止
+func (m *Monitor) Stop(ctx context.Context) error {
+	m.lifecycleMu.Lock()
+	cancel := m.cancel
+	m.cancel = nil
+	m.lifecycleMu.Unlock()
+
+	// 停止済みの場合は何もしない
+	if cancel == nil {
+		return nil
+	}
+
+	log.Printf("[%s] Stop...", MODULE_NAME)
+
+	cancel()
+
+	// 監視ゴルーチンの終了を待機
+	if err := module.Wait(ctx, &m.wg); err != nil {
+		return fmt.Errorf("%s did not stop: %w", MODULE_NAME, err)
+	}
+
+	m.health.SetState(module.StateStopped)
+
+	return nil
+}
+
+// モジュールの稼働状況を取得
+func (m *Monitor) Health() module.Health {
+	return m.health.Snapshot()
+}
+
+// モジュールが検出したイベントを取得
+func (m *Monitor) GetEvents() []module.Event {
+	m.eventsMu.RLock()
+	defer m.eventsMu.RUnlock()
+
+	// イベントのコピーを返す（オリジナルが変更されないように）
+	eventsCopy := make([]module.Event, len(m.events))
+	copy(eventsCopy, m.events)
+
+	return eventsCopy
+}
+
+// 新しいイベントを追加
+func (m *Monitor) addEvent(eventType string, severity int, data map[string]interface{}) {
+	event := module.Event{
+		ID:        fmt.Sprintf("optical-%d", time.Now().UnixNano()),
+		Timestamp: time.Now(),
+		Type:      eventType,
+		Severity:  severity,
+		Data:      data,
+	}
+
+	m.eventsMu.Lock()
+	m.events = append(m.events, event)
+	m.eventsMu.Unlock()
+
+	m.health.RecordEvent()
+
+	log.Printf("[%s] Detection new event: %s Importance: %d\n", MODULE_NAME, eventType, severity)
+
+	// イベントをsenderに送信（非同期）
+	go m.eventDispatcher.Add(event)
+}
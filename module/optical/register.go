@@ -0,0 +1,30 @@
+package optical
+
+import (
+	"github.com/mniyk/endpoint-security-and-monitoring-tools/internal/config"
+	"github.com/mniyk/endpoint-security-and-monitoring-tools/module"
+)
+
+const REGISTRY_NAME = "optical_burning_monitoring"
+
+// モジュールをレジストリに登録
+func init() {
+	module.Register(module.Descriptor{
+		Name:        REGISTRY_NAME,
+		Description: "Detection of optical drives, media insertion and CD/DVD/Blu-ray burning",
+		Options: []module.OptionSpec{
+			{Name: "sysfs_root", Type: "string", Default: DEFAULT_SYSFS_ROOT, Description: "Root of sysfs"},
+			{Name: "procfs_root", Type: "string", Default: DEFAULT_PROCFS_ROOT, Description: "Root of procfs"},
+			{Name: "dev_root", Type: "string", Default: DEFAULT_DEV_ROOT, Description: "Directory of device files"},
+			{Name: "udev_data_root", Type: "string", Default: DEFAULT_UDEV_DATA_ROOT, Description: "Directory of the udev database, used for the media type"},
+			{Name: "burning_tools", Type: "array", Default: defaultBurningTools, Description: "Command names of burning tools"},
+		},
+		Platforms: []string{"linux"},
+		Factory:   newModule,
+	})
+}
+
+// 設定からモジュールを生成
+func newModule(moduleConfig config.Config, deps module.Dependencies) (module.Module, error) {
+	return NewMonitor(NewMonitorConfig(moduleConfig), deps.UserInfo, deps.EventSink), nil
+}
@@ -1,6 +1,7 @@
 package printer
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"sync"
@@ -10,7 +11,6 @@ import (
 	"golang.org/x/sys/windows"
 
 	"github.com/mniyk/endpoint-security-and-monitoring-tools/internal/config"
-	"github.com/mniyk/endpoint-security-and-monitoring-tools/internal/transmission"
 	"github.com/mniyk/endpoint-security-and-monitoring-tools/internal/userinfo"
 	"github.com/mniyk/endpoint-security-and-monitoring-tools/module"
 )
@@ -108,59 +108,94 @@ func NewMonitorConfig(moduleConfig config.Config) *MonitorConfig {
 type Monitor struct {
 	events          []module.Event
 	config          MonitorConfig
-	stopChan        chan struct{}
+	cancel          context.CancelFunc
+	lifecycleMu     sync.Mutex
+	wg              sync.WaitGroup
 	eventsMu        sync.RWMutex
 	lastJobID       uint32
 	userInfo        *userinfo.UserInfo
-	eventDispatcher transmission.EventDispatcher
+	eventDispatcher module.EventSink
+	health          *module.HealthTracker
+}
+
+// 設定からモジュールを生成
+func newModule(moduleConfig config.Config, deps module.Dependencies) (module.Module, error) {
+	return NewMonitor(NewMonitorConfig(moduleConfig), deps.UserInfo, deps.EventSink), nil
 }
 
 // 新しいMonitorを作成
-func NewMonitor(config *MonitorConfig, userInfo *userinfo.UserInfo, eventDispatcher transmission.EventDispatcher) *Monitor {
+func NewMonitor(config *MonitorConfig, userInfo *userinfo.UserInfo, eventDispatcher module.EventSink) *Monitor {
 	return &Monitor{
 		config:          *config,
 		events:          make([]module.Event, 0),
 		userInfo:        userInfo,
 		eventDispatcher: eventDispatcher,
+		health:          module.NewHealthTracker(),
 	}
 }
 
 // モジュールを初期化
 func (m *Monitor) Initialize() error {
-	m.stopChan = make(chan struct{})
+	m.health.SetState(module.StateInitializing)
 	log.Printf("[%s] Initialize...", MODULE_NAME)
 	return nil
 }
 
 // モニタリングを開始
-func (m *Monitor) Start() error {
+func (m *Monitor) Start(ctx context.Context) error {
+	m.lifecycleMu.Lock()
+	defer m.lifecycleMu.Unlock()
+
+	if m.cancel != nil {
+		return fmt.Errorf("%s already started", MODULE_NAME)
+	}
+
 	log.Printf("[%s] Start...", MODULE_NAME)
 
+	ctx, m.cancel = context.WithCancel(ctx)
+	m.health.SetState(module.StateRunning)
+
 	// 印刷監視を開始
-	go m.startPrintMonitoring()
+	module.Go(ctx, &m.wg, func() {
+		m.startPrintMonitoring(ctx)
+	})
 
 	return nil
 }
 
 // 監視の継続的なループを実行
-func (m *Monitor) startPrintMonitoring() {
+func (m *Monitor) startPrintMonitoring(ctx context.Context) {
+	ticker := time.NewTicker(MONITOR_INTERVAL)
+	defer ticker.Stop()
+
+	// 監視者にループを登録
+	heartbeat := module.StartHeartbeat(ctx, "print_monitoring")
+	defer heartbeat.Stop()
+
 	for {
+		// 監視者へ生存を通知
+		heartbeat.Beat()
+
+		// 印刷ジョブをスキャン
+		m.checkPrintJobs()
+
+		// 設定された間隔で再スキャン
 		select {
-		case <-m.stopChan:
+		case <-ctx.Done():
 			return
-		default:
-			// 印刷ジョブをスキャン
-			m.checkPrintJobs()
-
-			// 設定された間隔で再スキャン
-			time.Sleep(MONITOR_INTERVAL)
+		case <-ticker.C:
 		}
 	}
 }
 
 // 印刷ジョブをチェック
 func (m *Monitor) checkPrintJobs() {
-	printers := m.getLocalPrinters()
+	printers, err := m.getLocalPrinters()
+	if err != nil {
+		log.Printf("[%s] %v", MODULE_NAME, err)
+		m.health.RecordError(err)
+		return
+	}
 
 	for _, printerName := range printers {
 		jobs := m.getPrintJobs(printerName)
@@ -172,10 +207,13 @@ func (m *Monitor) checkPrintJobs() {
 			}
 		}
 	}
+
+	m.health.MarkScan()
+	m.health.SetDetail("printers", len(printers))
 }
 
 // ローカルプリンターリストを取得
-func (m *Monitor) getLocalPrinters() []string {
+func (m *Monitor) getLocalPrinters() ([]string, error) {
 	var printers []string
 
 	// EnumPrintersW API を使用してプリンターを取得
@@ -222,12 +260,12 @@ func (m *Monitor) getLocalPrinters() []string {
 		}
 	}
 
-	// プリンターが見つからない場合は異常終了
+	// プリンターが見つからない場合はエラー
 	if len(printers) == 0 {
-		log.Fatalf("[%s] Failed to enumerate printers: no printers found", MODULE_NAME)
+		return nil, fmt.Errorf("failed to enumerate printers: no printers found")
 	}
 
-	return printers
+	return printers, nil
 }
 
 // 指定プリンターの印刷ジョブを取得
@@ -331,13 +369,36 @@ func (m *Monitor) logPrintOperation(op PrintOperation) {
 }
 
 // モニタリングを停止
-func (m *Monitor) Stop() error {
+func (m *Monitor) Stop(ctx context.Context) error {
+	m.lifecycleMu.Lock()
+	cancel := m.cancel
+	m.cancel = nil
+	m.lifecycleMu.Unlock()
+
+	// 停止済みの場合は何もしない
+	if cancel == nil {
+		return nil
+	}
+
 	log.Printf("[%s] Stop...", MODULE_NAME)
 
-	close(m.stopChan)
+	cancel()
+
+	// 監視ゴルーチンの終了を待機
+	if err := module.Wait(ctx, &m.wg); err != nil {
+		return fmt.Errorf("%s did not stop: %w", MODULE_NAME, err)
+	}
+
+	m.health.SetState(module.StateStopped)
+
 	return nil
 }
 
+// モジュールの稼働状況を取得
+func (m *Monitor) Health() module.Health {
+	return m.health.Snapshot()
+}
+
 // モジュールが検出したイベントを取得
 func (m *Monitor) GetEvents() []module.Event {
 	m.eventsMu.RLock()
@@ -363,6 +424,8 @@ func (m *Monitor) addEvent(eventType string, severity int, data map[string]inter
 	m.events = append(m.events, event)
 	m.eventsMu.Unlock()
 
+	m.health.RecordEvent()
+
 	log.Printf("[%s] Detection new event: %s Importance: %d\n", MODULE_NAME, eventType, severity)
 
 	// イベントをsenderに送信（非同期）
@@ -0,0 +1,17 @@
+package printer
+
+import (
+	"github.com/mniyk/endpoint-security-and-monitoring-tools/module"
+)
+
+const REGISTRY_NAME = "printer_transfer_monitoring"
+
+// モジュールをレジストリに登録
+func init() {
+	module.Register(module.Descriptor{
+		Name:        REGISTRY_NAME,
+		Description: "Tracking of transfers to Printer",
+		Platforms:   []string{"windows"},
+		Factory:     newModule,
+	})
+}
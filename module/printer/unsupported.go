@@ -0,0 +1,13 @@
+//go:build !windows
+
+package printer
+
+import (
+	"github.com/mniyk/endpoint-security-and-monitoring-tools/internal/config"
+	"github.com/mniyk/endpoint-security-and-monitoring-tools/module"
+)
+
+// 対応していないプラットフォームではモジュールを生成しない
+func newModule(moduleConfig config.Config, deps module.Dependencies) (module.Module, error) {
+	return nil, module.ErrUnsupportedPlatform
+}
@@ -0,0 +1,208 @@
+package module
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/mniyk/endpoint-security-and-monitoring-tools/internal/config"
+	"github.com/mniyk/endpoint-security-and-monitoring-tools/internal/userinfo"
+)
+
+// 対応していないプラットフォームでモジュールを生成しようとした場合のエラー
+var ErrUnsupportedPlatform = errors.New("module is not supported on this platform")
+
+// モジュールが検出したイベントの送信先
+type EventSink interface {
+	Add(event Event) error
+}
+
+// モジュール生成時に渡す依存関係の構造体
+type Dependencies struct {
+	UserInfo  *userinfo.UserInfo
+	EventSink EventSink
+}
+
+// モジュールを生成するファクトリ関数
+type Factory func(moduleConfig config.Config, deps Dependencies) (Module, error)
+
+// オプションの定義の構造体
+type OptionSpec struct {
+	Name        string
+	Type        string // "bool" "number" "string" "array" "object"
+	Default     interface{}
+	Description string
+}
+
+// 登録されたモジュールの情報の構造体
+type Descriptor struct {
+	Name        string
+	Description string
+	Options     []OptionSpec
+	Platforms   []string // runtime.GOOSの値（空の場合はすべてのプラットフォーム）
+	Factory     Factory
+}
+
+var (
+	registryMu sync.RWMutex
+	registry   = make(map[string]Descriptor)
+)
+
+// モジュールのファクトリを登録
+func Register(descriptor Descriptor) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	if descriptor.Name == "" {
+		panic("module: Register with empty name")
+	}
+	if descriptor.Factory == nil {
+		panic(fmt.Sprintf("module: Register %s with nil factory", descriptor.Name))
+	}
+	if _, exists := registry[descriptor.Name]; exists {
+		panic(fmt.Sprintf("module: Register called twice for %s", descriptor.Name))
+	}
+
+	registry[descriptor.Name] = descriptor
+}
+
+// 登録されたモジュールの情報を取得
+func Lookup(name string) (Descriptor, error) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	descriptor, ok := registry[name]
+	if !ok {
+		if suggestions := suggestNames(name); len(suggestions) > 0 {
+			return Descriptor{}, fmt.Errorf("unknown module %s (did you mean %s?)", name, strings.Join(suggestions, ", "))
+		}
+		return Descriptor{}, fmt.Errorf("unknown module %s", name)
+	}
+
+	return descriptor, nil
+}
+
+// 登録されたすべてのモジュールの情報を名前順で取得
+func Descriptors() []Descriptor {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	descriptors := make([]Descriptor, 0, len(registry))
+	for _, descriptor := range registry {
+		descriptors = append(descriptors, descriptor)
+	}
+	sort.Slice(descriptors, func(i, j int) bool {
+		return descriptors[i].Name < descriptors[j].Name
+	})
+
+	return descriptors
+}
+
+// 指定されたプラットフォームに対応しているかどうかを確認
+func (d Descriptor) SupportsPlatform(goos string) bool {
+	if len(d.Platforms) == 0 {
+		return true
+	}
+
+	for _, platform := range d.Platforms {
+		if platform == goos {
+			return true
+		}
+	}
+
+	return false
+}
+
+// オプションをスキーマに照らして検証
+func (d Descriptor) ValidateOptions(options map[string]interface{}) error {
+	specs := make(map[string]OptionSpec, len(d.Options))
+	for _, spec := range d.Options {
+		specs[spec.Name] = spec
+	}
+
+	var problems []string
+	for key, value := range options {
+		spec, ok := specs[key]
+		if !ok {
+			problems = append(problems, fmt.Sprintf("unknown option %s", key))
+			continue
+		}
+		if !matchesType(spec.Type, value) {
+			problems = append(problems, fmt.Sprintf("option %s must be %s", key, spec.Type))
+		}
+	}
+
+	if len(problems) > 0 {
+		sort.Strings(problems)
+		return fmt.Errorf("invalid options for %s: %s", d.Name, strings.Join(problems, "; "))
+	}
+
+	return nil
+}
+
+// JSONから読み込んだ値が型に一致するかどうかを確認
+func matchesType(typeName string, value interface{}) bool {
+	switch typeName {
+	case "bool":
+		_, ok := value.(bool)
+		return ok
+	case "number":
+		_, ok := value.(float64)
+		return ok
+	case "string":
+		_, ok := value.(string)
+		return ok
+	case "array":
+		_, ok := value.([]interface{})
+		return ok
+	case "object":
+		_, ok := value.(map[string]interface{})
+		return ok
+	default:
+		return true
+	}
+}
+
+// 登録済みの名前から候補を提示（呼び出し元でロックを取得済みであること）
+func suggestNames(name string) []string {
+	var suggestions []string
+
+	for candidate := range registry {
+		threshold := len(candidate) / 3
+		if threshold < 2 {
+			threshold = 2
+		}
+		if levenshtein(name, candidate) <= threshold || strings.Contains(candidate, name) {
+			suggestions = append(suggestions, candidate)
+		}
+	}
+	sort.Strings(suggestions)
+
+	return suggestions
+}
+
+// 2つの文字列の編集距離を計算
+func levenshtein(a, b string) int {
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+
+	for j := range previous {
+		previous[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+
+	return previous[len(b)]
+}
@@ -0,0 +1,120 @@
+package module
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/mniyk/endpoint-security-and-monitoring-tools/internal/config"
+)
+
+// テスト用のモジュールを登録（テストの実行順に依存しないよう一度だけ登録）
+func init() {
+	factory := func(moduleConfig config.Config, deps Dependencies) (Module, error) {
+		return nil, nil
+	}
+
+	Register(Descriptor{
+		Name: "test_storage",
+		Options: []OptionSpec{
+			{Name: "enforce", Type: "bool"},
+			{Name: "max_size", Type: "number"},
+			{Name: "policy_path", Type: "string"},
+			{Name: "allowed_extensions", Type: "array"},
+			{Name: "filters", Type: "object"},
+		},
+		Factory: factory,
+	})
+	Register(Descriptor{Name: "test_storage_audit", Factory: factory})
+	// printerと同じくオプションを定義しないモジュール
+	Register(Descriptor{Name: "test_printer", Factory: factory})
+}
+
+func TestLookup(t *testing.T) {
+	if descriptor, err := Lookup("test_printer"); err != nil || descriptor.Name != "test_printer" {
+		t.Fatalf("Lookup(test_printer) = %+v, %v", descriptor, err)
+	}
+
+	tests := []struct {
+		name    string
+		suggest string // 空の場合は候補を提示しない
+	}{
+		{"test_printr", "test_printer"},
+		{"test_storag", "test_storage"},
+		{"storage_audit", "test_storage_audit"},
+		{"bluetooth", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Lookup(tt.name)
+			if err == nil {
+				t.Fatalf("Lookup(%s) succeeded, want error", tt.name)
+			}
+
+			hasSuggestion := strings.Contains(err.Error(), "did you mean")
+			if tt.suggest == "" {
+				if hasSuggestion {
+					t.Fatalf("Lookup(%s) suggested names: %v", tt.name, err)
+				}
+				return
+			}
+			if !hasSuggestion || !strings.Contains(err.Error(), tt.suggest) {
+				t.Fatalf("Lookup(%s) error %q does not suggest %s", tt.name, err, tt.suggest)
+			}
+		})
+	}
+}
+
+func TestValidateOptions(t *testing.T) {
+	storage, err := Lookup("test_storage")
+	if err != nil {
+		t.Fatal(err)
+	}
+	printer, err := Lookup("test_printer")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		descriptor Descriptor
+		options    map[string]interface{}
+		problems   []string // 空の場合は成功
+	}{
+		{"no options", storage, nil, nil},
+		{"matching types", storage, map[string]interface{}{
+			"enforce":            true,
+			"max_size":           float64(1024),
+			"policy_path":        "/etc/policy.json",
+			"allowed_extensions": []interface{}{".pdf"},
+			"filters":            map[string]interface{}{},
+		}, nil},
+		{"unknown option", storage, map[string]interface{}{"enforced": true}, []string{"unknown option enforced"}},
+		{"wrong types", storage, map[string]interface{}{
+			"enforce":  "true",
+			"max_size": 1024,
+		}, []string{"option enforce must be bool", "option max_size must be number"}},
+		{"module without options", printer, map[string]interface{}{"enforce": true}, []string{"unknown option enforce"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.descriptor.ValidateOptions(tt.options)
+			if len(tt.problems) == 0 {
+				if err != nil {
+					t.Fatalf("ValidateOptions: %v", err)
+				}
+				return
+			}
+
+			if err == nil {
+				t.Fatal("ValidateOptions succeeded, want error")
+			}
+			for _, problem := range tt.problems {
+				if !strings.Contains(err.Error(), problem) {
+					t.Errorf("error %q does not report %q", err, problem)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,72 @@
+package module
+
+import (
+	"context"
+	"sort"
+	"time"
+)
+
+const AGENT_HEARTBEAT_SEVERITY = 1
+
+// モジュールごとの状況の構造体
+type ModuleStatus struct {
+	Name     string  `json:"name"`
+	State    State   `json:"state"`
+	Restarts int     `json:"restarts"`
+	Health   *Health `json:"health,omitempty"` // HealthReporterを実装していない場合はnil
+}
+
+// すべてのモジュールの状況を取得
+func (m *Manager) Status() []ModuleStatus {
+	m.mu.RLock()
+	statuses := make([]ModuleStatus, 0, len(m.Modules))
+	for name, module := range m.Modules {
+		status := ModuleStatus{
+			Name:  name,
+			State: m.states[name],
+		}
+
+		if reporter, ok := module.(HealthReporter); ok {
+			health := reporter.Health()
+			status.Health = &health
+		}
+
+		statuses = append(statuses, status)
+	}
+	m.mu.RUnlock()
+
+	m.supervisionMu.Lock()
+	for i := range statuses {
+		if state, ok := m.supervision[statuses[i].Name]; ok {
+			statuses[i].Restarts = len(state.restarts)
+		}
+	}
+	m.supervisionMu.Unlock()
+
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].Name < statuses[j].Name
+	})
+
+	return statuses
+}
+
+// 定期的にエージェントの稼働状況を送信
+func (m *Manager) sendHeartbeats(ctx context.Context) {
+	ticker := time.NewTicker(time.Duration(m.configs.Agent.HeartbeatInterval))
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			m.emitAgentEvent(
+				"agent_heartbeat",
+				AGENT_HEARTBEAT_SEVERITY,
+				map[string]interface{}{
+					"modules": m.Status(),
+				},
+			)
+		}
+	}
+}
@@ -0,0 +1,354 @@
+package module
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"runtime/debug"
+	"sync"
+	"time"
+)
+
+const (
+	SUPERVISOR_NAME           = "Supervisor"
+	MODULE_CRASHED_SEVERITY   = 4
+	MODULE_RESTARTED_SEVERITY = 2
+	RESTART_STOP_TIMEOUT      = 10 * time.Second
+)
+
+// コンテキストに監視情報を格納するためのキー
+type supervisionKey struct{}
+
+// モジュールのゴルーチンから監視者へ通知するための構造体
+type supervision struct {
+	manager    *Manager
+	name       string
+	generation int
+	onPanic    func(recovered interface{}, stack []byte)
+}
+
+// モジュールごとの監視状態の構造体
+type supervisionState struct {
+	restarts   []time.Time // RestartWindow内の再起動時刻
+	generation int         // 開始時に割り当て、以前のインスタンスのループを区別
+	loops      map[*loopState]bool
+	restarting bool
+}
+
+// 監視ループごとのハートビートの状態の構造体
+type loopState struct {
+	name          string
+	lastHeartbeat time.Time
+}
+
+// 監視ループの生存を監視者へ通知する構造体（監視者がいない場合はnil）
+type Heartbeat struct {
+	manager *Manager
+	module  string
+	loop    *loopState
+}
+
+// ctxに紐づく監視者の下でゴルーチンを起動（パニックは監視者に通知）
+func Go(ctx context.Context, wg *sync.WaitGroup, fn func()) {
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		defer func() {
+			if recovered := recover(); recovered != nil {
+				s, ok := ctx.Value(supervisionKey{}).(*supervision)
+				if !ok {
+					// 監視者がいない場合は従来どおりプロセスを終了
+					panic(recovered)
+				}
+				s.onPanic(recovered, debug.Stack())
+			}
+		}()
+
+		fn()
+	}()
+}
+
+// ctxに紐づく監視者に監視ループを登録（ループごとにハートビートの途絶を検知し、終了時にStopを呼び出す）
+func StartHeartbeat(ctx context.Context, loop string) *Heartbeat {
+	s, ok := ctx.Value(supervisionKey{}).(*supervision)
+	if !ok {
+		return nil
+	}
+
+	state := s.manager.registerLoop(s.name, s.generation, loop)
+	if state == nil {
+		return nil
+	}
+
+	return &Heartbeat{manager: s.manager, module: s.name, loop: state}
+}
+
+// 監視ループの生存を通知
+func (h *Heartbeat) Beat() {
+	if h == nil {
+		return
+	}
+
+	h.manager.supervisionMu.Lock()
+	defer h.manager.supervisionMu.Unlock()
+
+	h.loop.lastHeartbeat = time.Now()
+}
+
+// 監視ループの終了を通知（以降は停止を検知しない）
+func (h *Heartbeat) Stop() {
+	if h == nil {
+		return
+	}
+
+	h.manager.supervisionMu.Lock()
+	defer h.manager.supervisionMu.Unlock()
+
+	if state, ok := h.manager.supervision[h.module]; ok {
+		delete(state.loops, h.loop)
+	}
+}
+
+// 監視者の情報を持つモジュール用のコンテキストを作成
+func (m *Manager) supervisedContext(name string, generation int) context.Context {
+	return context.WithValue(m.supervisorContext(), supervisionKey{}, &supervision{
+		manager:    m,
+		name:       name,
+		generation: generation,
+		onPanic: func(recovered interface{}, stack []byte) {
+			log.Printf("[%s] Module %s panicked: %v\n%s", SUPERVISOR_NAME, name, recovered, stack)
+			m.handleFailure(name, fmt.Sprintf("panic: %v", recovered))
+		},
+	})
+}
+
+// モジュールを監視下で開始
+func (m *Manager) startSupervised(name string, module Module) error {
+	m.supervisionMu.Lock()
+	state, ok := m.supervision[name]
+	if !ok {
+		state = &supervisionState{}
+		m.supervision[name] = state
+	}
+	m.generations++
+	state.generation = m.generations
+	state.loops = make(map[*loopState]bool)
+	generation := state.generation
+	m.supervisionMu.Unlock()
+
+	return module.Start(m.supervisedContext(name, generation))
+}
+
+// 監視者が再起動中かどうかを確認
+func (m *Manager) isRestarting(name string) bool {
+	m.supervisionMu.Lock()
+	defer m.supervisionMu.Unlock()
+
+	state, ok := m.supervision[name]
+	return ok && state.restarting
+}
+
+// 監視ループを登録（停止したモジュールや以前のインスタンスのループは登録しない）
+func (m *Manager) registerLoop(name string, generation int, loop string) *loopState {
+	m.supervisionMu.Lock()
+	defer m.supervisionMu.Unlock()
+
+	state, ok := m.supervision[name]
+	if !ok || state.generation != generation {
+		return nil
+	}
+
+	registered := &loopState{name: loop, lastHeartbeat: time.Now()}
+	state.loops[registered] = true
+	return registered
+}
+
+// ハートビートが途絶えたモジュールを定期的に検出
+func (m *Manager) watchStalls(ctx context.Context) {
+	stallTimeout := time.Duration(m.configs.Agent.Supervisor.StallTimeout)
+
+	ticker := time.NewTicker(stallTimeout / 4)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		// モジュール名と途絶えた監視ループ名
+		stalled := make(map[string]string)
+
+		m.supervisionMu.Lock()
+		for name, state := range m.supervision {
+			if state.restarting {
+				continue
+			}
+			for loop := range state.loops {
+				if time.Since(loop.lastHeartbeat) > stallTimeout {
+					stalled[name] = loop.name
+					break
+				}
+			}
+		}
+		m.supervisionMu.Unlock()
+
+		for name, loop := range stalled {
+			log.Printf("[%s] Module %s stalled: no heartbeat from %s for %s", SUPERVISOR_NAME, name, loop, stallTimeout)
+			m.handleFailure(name, fmt.Sprintf("stalled: no heartbeat from %s for %s", loop, stallTimeout))
+		}
+	}
+}
+
+// クラッシュまたは停止したモジュールを再起動
+func (m *Manager) handleFailure(name string, reason string) {
+	// 意図的に停止中のモジュールは再起動しない
+	if current, err := m.ModuleState(name); err != nil || current != StateRunning {
+		return
+	}
+
+	supervisorCtx := m.supervisorContext()
+
+	m.supervisionMu.Lock()
+	state, ok := m.supervision[name]
+	if !ok || state.restarting || supervisorCtx.Err() != nil {
+		m.supervisionMu.Unlock()
+		return
+	}
+	state.restarting = true
+
+	// 再起動回数の期間外の記録を削除
+	supervisorConfig := m.configs.Agent.Supervisor
+	window := time.Duration(supervisorConfig.RestartWindow)
+	recent := state.restarts[:0]
+	for _, restartedAt := range state.restarts {
+		if time.Since(restartedAt) < window {
+			recent = append(recent, restartedAt)
+		}
+	}
+	state.restarts = recent
+	attempt := len(state.restarts) + 1
+	m.supervisionMu.Unlock()
+
+	giveUp := attempt > supervisorConfig.MaxRestarts
+	m.emitAgentEvent(
+		"agent_module_crashed",
+		MODULE_CRASHED_SEVERITY,
+		map[string]interface{}{
+			"module":        name,
+			"reason":        reason,
+			"restart_count": attempt - 1,
+			"will_restart":  !giveUp,
+		},
+	)
+
+	// パニックしたゴルーチンの終了を待たないよう別のゴルーチンで再起動
+	m.supervisorWg.Add(1)
+	go func() {
+		defer m.supervisorWg.Done()
+		m.restartAfterFailure(name, attempt, giveUp)
+	}()
+}
+
+// バックオフ後にモジュールを再初期化して再開
+func (m *Manager) restartAfterFailure(name string, attempt int, giveUp bool) {
+	m.mu.RLock()
+	module := m.Modules[name]
+	m.mu.RUnlock()
+
+	// 失敗したモジュールのゴルーチンを停止
+	stopCtx, cancel := context.WithTimeout(context.Background(), RESTART_STOP_TIMEOUT)
+	stopErr := module.Stop(stopCtx)
+	cancel()
+
+	// 停止できないゴルーチンが残っている場合は、同じインスタンスを二重に動かさないよう再起動しない
+	if stopErr != nil {
+		log.Printf("[%s] Failed stop module (%s) before restart, giving up: %v", SUPERVISOR_NAME, name, stopErr)
+		giveUp = true
+	} else if giveUp {
+		log.Printf("[%s] Module %s exceeded restart budget, giving up", SUPERVISOR_NAME, name)
+	}
+
+	if giveUp {
+		m.setState(name, StateFailed)
+
+		m.supervisionMu.Lock()
+		delete(m.supervision, name)
+		m.supervisionMu.Unlock()
+		return
+	}
+
+	backoff := m.restartBackoff(attempt)
+
+	select {
+	case <-m.supervisorContext().Done():
+		return
+	case <-time.After(backoff):
+	}
+
+	err := module.Initialize()
+	if err == nil {
+		err = m.startSupervised(name, module)
+	}
+
+	m.supervisionMu.Lock()
+	if state, ok := m.supervision[name]; ok {
+		state.restarts = append(state.restarts, time.Now())
+		state.restarting = false
+	}
+	m.supervisionMu.Unlock()
+
+	if err != nil {
+		log.Printf("[%s] Failed restart module (%s): %v", SUPERVISOR_NAME, name, err)
+		m.handleFailure(name, fmt.Sprintf("restart failed: %v", err))
+		return
+	}
+
+	log.Printf("[%s] Restarted module %s (attempt %d)", SUPERVISOR_NAME, name, attempt)
+
+	m.emitAgentEvent(
+		"agent_module_restarted",
+		MODULE_RESTARTED_SEVERITY,
+		map[string]interface{}{
+			"module":  name,
+			"attempt": attempt,
+			"backoff": backoff.String(),
+		},
+	)
+}
+
+// 再起動回数に応じて延長した待機時間を取得（上限はMaxBackoff）
+func (m *Manager) restartBackoff(attempt int) time.Duration {
+	supervisorConfig := m.configs.Agent.Supervisor
+	backoff := time.Duration(supervisorConfig.InitialBackoff) << (attempt - 1)
+	if backoff <= 0 || backoff > time.Duration(supervisorConfig.MaxBackoff) {
+		backoff = time.Duration(supervisorConfig.MaxBackoff)
+	}
+
+	return backoff
+}
+
+// エージェント自身のイベントを送信
+func (m *Manager) emitAgentEvent(eventType string, severity int, data map[string]interface{}) {
+	if m.eventSink == nil {
+		return
+	}
+
+	if m.userInfo != nil {
+		data["user"] = m.userInfo.UserName
+		data["host"] = m.userInfo.HostName
+	}
+
+	event := Event{
+		ID:        fmt.Sprintf("agent-%d", time.Now().UnixNano()),
+		Timestamp: time.Now(),
+		Type:      eventType,
+		Severity:  severity,
+		Data:      data,
+	}
+
+	if err := m.eventSink.Add(event); err != nil {
+		log.Printf("[%s] Failed send event %s: %v", SUPERVISOR_NAME, eventType, err)
+	}
+}
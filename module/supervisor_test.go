@@ -0,0 +1,107 @@
+package module
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// モジュールの開始回数が期待した値になるまで待機
+func waitForStarts(t *testing.T, module *fakeModule, want int) {
+	t.Helper()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for module.starts() < want {
+		if time.Now().After(deadline) {
+			t.Fatalf("module started %d times, want %d", module.starts(), want)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+// 再起動回数を取得
+func restartCount(manager *Manager, name string) int {
+	for _, status := range manager.Status() {
+		if status.Name == name {
+			return status.Restarts
+		}
+	}
+
+	return -1
+}
+
+func TestSupervisorRestartsStalledLoop(t *testing.T) {
+	// 同じモジュールの他のループが動いていても、途絶えたループを検知
+	scanner := &fakeModule{loops: []string{"events", "scan"}, stallOnce: "scan"}
+	steady := &fakeModule{loops: []string{"scan"}}
+	manager := newTestManager(t, map[string]*fakeModule{"scanner": scanner, "steady": steady})
+
+	startTestManager(t, manager)
+
+	waitForStarts(t, scanner, 2)
+	waitForState(t, manager, "scanner", StateRunning)
+
+	// 再起動後のループは途絶えないため、StallTimeoutを過ぎても再起動しない
+	time.Sleep(400 * time.Millisecond)
+
+	if started := scanner.starts(); started != 2 {
+		t.Errorf("scanner started %d times, want 2", started)
+	}
+	if restarts := restartCount(manager, "scanner"); restarts != 1 {
+		t.Errorf("scanner restarts = %d, want 1", restarts)
+	}
+	if started := steady.starts(); started != 1 {
+		t.Errorf("steady module restarted: started %d times", started)
+	}
+}
+
+func TestSupervisorRestartBudget(t *testing.T) {
+	crashing := &fakeModule{panics: true}
+	manager := newTestManager(t, map[string]*fakeModule{"crashing": crashing})
+
+	startTestManager(t, manager)
+
+	// MaxRestarts(2)回まで再起動し、それ以降は諦める
+	waitForState(t, manager, "crashing", StateFailed)
+
+	if started := crashing.starts(); started != 3 {
+		t.Fatalf("crashing started %d times, want 3", started)
+	}
+}
+
+func TestSupervisorGivesUpWhenStopFails(t *testing.T) {
+	// 停止できないインスタンスを再度開始しない
+	crashing := &fakeModule{panics: true, stopErr: errors.New("goroutines still running")}
+	manager := newTestManager(t, map[string]*fakeModule{"crashing": crashing})
+
+	startTestManager(t, manager)
+
+	waitForState(t, manager, "crashing", StateFailed)
+	time.Sleep(50 * time.Millisecond)
+
+	if started := crashing.starts(); started != 1 {
+		t.Fatalf("crashing started %d times after a failed stop, want 1", started)
+	}
+}
+
+func TestRestartBackoff(t *testing.T) {
+	manager := newTestManager(t, nil)
+
+	// InitialBackoffから倍増し、MaxBackoffで頭打ち
+	tests := []struct {
+		attempt int
+		want    time.Duration
+	}{
+		{1, 10 * time.Millisecond},
+		{2, 20 * time.Millisecond},
+		{3, 40 * time.Millisecond},
+		{4, 40 * time.Millisecond},
+		{64, 40 * time.Millisecond},
+	}
+
+	for _, tt := range tests {
+		if got := manager.restartBackoff(tt.attempt); got != tt.want {
+			t.Errorf("restartBackoff(%d) = %s, want %s", tt.attempt, got, tt.want)
+		}
+	}
+}
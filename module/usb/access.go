@@ -0,0 +1,154 @@
+package usb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/mniyk/endpoint-security-and-monitoring-tools/internal/fanotify"
+	"github.com/mniyk/endpoint-security-and-monitoring-tools/internal/filewatch"
+	"github.com/mniyk/endpoint-security-and-monitoring-tools/internal/procinfo"
+	"github.com/mniyk/endpoint-security-and-monitoring-tools/module"
+)
+
+const (
+	FILE_BACKEND_FSNOTIFY = "fsnotify" // ファイル操作をfsnotifyのみで検出
+	FILE_BACKEND_FANOTIFY = "fanotify" // 書き込みをfanotifyで検出して書き込んだプロセスを特定（Linux）
+	FILE_BACKEND_AUTO     = "auto"     // fanotifyが使える場合はfanotify
+	MAX_CACHED_PROCESSES  = 1024
+	FILE_WRITE_BUFFER     = 64
+)
+
+// ファイル操作の検出方式の設定を検証
+func validateFileBackend(backend string) error {
+	switch backend {
+	case FILE_BACKEND_FSNOTIFY, FILE_BACKEND_FANOTIFY, FILE_BACKEND_AUTO:
+		return nil
+	default:
+		return fmt.Errorf("unknown file_backend %q", backend)
+	}
+}
+
+// リムーバブルドライブへのアクセスをfanotifyで記録（書き込みを記録しない場合はnilのチャネル）
+func (m *Monitor) startAccessTracking(ctx context.Context, drive Volume) <-chan filewatch.Write {
+	trackReads := m.importEnabled() && m.config.ImportReadTracking
+	trackWrites := m.config.FileBackend != FILE_BACKEND_FSNOTIFY
+	if !trackReads && !trackWrites {
+		return nil
+	}
+
+	var mask uint64
+	if trackReads {
+		mask |= fanotify.CLOSE_NOWRITE
+	}
+	if trackWrites {
+		mask |= fanotify.CLOSE_WRITE
+	}
+
+	notifier, err := m.openAccessNotifier(drive, mask)
+	if err != nil {
+		// fsnotifyのみで検出を継続
+		if trackWrites {
+			m.health.SetDetail("file_backend", FILE_BACKEND_FSNOTIFY)
+		}
+		if trackReads {
+			m.health.SetDetail("read_tracking", "unavailable")
+		}
+		if m.config.FileBackend == FILE_BACKEND_FANOTIFY || trackReads {
+			log.Printf("[%s] fanotify unavailable for drive(%s), falling back to fsnotify: %v\n", MODULE_NAME, drive.ID, err)
+		}
+		return nil
+	}
+
+	if trackWrites {
+		m.health.SetDetail("file_backend", FILE_BACKEND_FANOTIFY)
+	}
+	if trackReads {
+		m.health.SetDetail("read_tracking", "fanotify")
+	}
+
+	var writes chan filewatch.Write
+	if trackWrites {
+		writes = make(chan filewatch.Write, FILE_WRITE_BUFFER)
+	}
+
+	module.Go(ctx, &m.wg, func() {
+		defer notifier.Close()
+		if writes != nil {
+			defer close(writes)
+		}
+
+		m.readAccessEvents(ctx, notifier, drive, writes)
+	})
+
+	return writes
+}
+
+// fanotifyを開いてドライブのマウントを購読
+func (m *Monitor) openAccessNotifier(drive Volume, mask uint64) (*fanotify.Notifier, error) {
+	notifier, err := fanotify.Open()
+	if err != nil {
+		if !errors.Is(err, fanotify.ErrUnsupported) {
+			m.health.RecordError(err)
+		}
+		return nil, err
+	}
+
+	if err := notifier.MarkMount(drive.MountPath, mask); err != nil {
+		notifier.Close()
+		m.health.RecordError(err)
+		return nil, err
+	}
+
+	return notifier, nil
+}
+
+// fanotifyのイベントを読み込みの記録と書き込みのチャネルに振り分け
+func (m *Monitor) readAccessEvents(ctx context.Context, notifier *fanotify.Notifier, drive Volume, writes chan<- filewatch.Write) {
+	root := strings.TrimSuffix(filepath.Clean(drive.MountPath), string(os.PathSeparator)) + string(os.PathSeparator)
+
+	// 同じプロセスの情報を何度も読み込まないようにする
+	processes := procinfo.NewCache(m.config.ProcfsRoot, MAX_CACHED_PROCESSES)
+
+	// 受信待ちの間もPOLL_TIMEOUTごとに生存を通知
+	heartbeat := module.StartHeartbeat(ctx, "access:"+drive.MountPath)
+	defer heartbeat.Stop()
+
+	for {
+		heartbeat.Beat()
+
+		events, err := notifier.Read(ctx)
+		if err != nil {
+			if ctx.Err() == nil {
+				log.Printf("[%s] Failed reading fanotify events: %v\n", MODULE_NAME, err)
+				m.health.RecordError(err)
+			}
+			return
+		}
+
+		for _, event := range events {
+			// マウントポイントがドライブのルートと異なる場合に備えてドライブ外のファイルを除外
+			if !strings.HasPrefix(event.Path, root) {
+				continue
+			}
+
+			process := processes.Lookup(event.PID)
+
+			if event.IsRead() {
+				m.reads.Record(event.Path, process)
+			}
+
+			if event.IsWrite() && writes != nil {
+				select {
+				case writes <- filewatch.Write{Path: event.Path, Process: process}:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package usb
+
+import (
+	"bytes"
+	"io"
+	"math"
+	"os"
+	"strings"
+)
+
+const (
+	ENCRYPTION_NONE      = "none"
+	ENCRYPTION_LUKS      = "luks"
+	ENCRYPTION_BITLOCKER = "bitlocker"
+	ENCRYPTION_VERACRYPT = "veracrypt"
+	ENCRYPTION_DM_CRYPT  = "dm-crypt" // LUKSヘッダのないdm-crypt（plainモードなど）
+
+	VOLUME_HEADER_SIZE          = 4096 // 4Knのデバイスでも読み込めるセクターの倍数
+	BOOT_SECTOR_SIZE            = 512
+	VERACRYPT_ENTROPY_THRESHOLD = 7.2 // ランダムな512バイトのエントロピーは約7.5ビット
+	UNENCRYPTED_WRITE_INCREASE  = 2   // 暗号化されていないボリュームへの書き込みで上げる重要度
+	MAX_SEVERITY                = 5
+
+	// 暗号化方式を判定できたボリュームのファイル操作の重要度（暗号化されていないボリュームへの書き込みはここから上げる）
+	ASSESSED_FILE_OPERATION_SEVERITY = FILE_OPERATION_SEVERITY - UNENCRYPTED_WRITE_INCREASE
+)
+
+// ボリュームのヘッダの署名
+var (
+	luksSignature      = []byte("LUKS\xba\xbe")
+	bitlockerSignature = []byte("-FVE-FS-") // ブートセクターのOEM ID（オフセット3）
+)
+
+// ボリュームのヘッダを読み込んで暗号化方式を判定（読み込めない場合は空）
+func detectVolumeEncryption(devicePath string) string {
+	file, err := os.Open(devicePath)
+	if err != nil {
+		return ""
+	}
+	defer file.Close()
+
+	header := make([]byte, VOLUME_HEADER_SIZE)
+	n, err := io.ReadFull(file, header)
+	if err != nil && n < BOOT_SECTOR_SIZE {
+		return ""
+	}
+
+	return encryptionOfHeader(header[:n])
+}
+
+// ヘッダの署名から暗号化方式を判定
+func encryptionOfHeader(header []byte) string {
+	switch {
+	case bytes.HasPrefix(header, luksSignature):
+		return ENCRYPTION_LUKS
+	case len(header) >= 3+len(bitlockerSignature) && bytes.Equal(header[3:3+len(bitlockerSignature)], bitlockerSignature):
+		return ENCRYPTION_BITLOCKER
+	case len(header) >= BOOT_SECTOR_SIZE && byteEntropy(header[:BOOT_SECTOR_SIZE]) >= VERACRYPT_ENTROPY_THRESHOLD:
+		// VeraCryptのヘッダは署名がなく全体が暗号化されているため、ファイルシステムのブートセクターとの違いで判定
+		return ENCRYPTION_VERACRYPT
+	default:
+		return ENCRYPTION_NONE
+	}
+}
+
+// dm-cryptのUUID（例: "CRYPT-LUKS2-..."）とデバイス名から暗号化方式を判定（dm-cryptでない場合は空）
+func encryptionOfDMCrypt(uuid string, name string) string {
+	switch {
+	case !strings.HasPrefix(uuid, "CRYPT-"):
+		return ""
+	case strings.HasPrefix(uuid, "CRYPT-LUKS"):
+		return ENCRYPTION_LUKS
+	case strings.HasPrefix(uuid, "CRYPT-BITLK"):
+		return ENCRYPTION_BITLOCKER
+	case strings.HasPrefix(uuid, "CRYPT-TCRYPT"), strings.HasPrefix(name, "veracrypt"):
+		return ENCRYPTION_VERACRYPT
+	default:
+		return ENCRYPTION_DM_CRYPT
+	}
+}
+
+// バイト値のシャノンエントロピー（ビット/バイト）を計算
+func byteEntropy(data []byte) float64 {
+	var counts [256]int
+	for _, b := range data {
+		counts[b]++
+	}
+
+	entropy := 0.0
+	for _, count := range counts {
+		if count == 0 {
+			continue
+		}
+		p := float64(count) / float64(len(data))
+		entropy -= p * math.Log2(p)
+	}
+
+	return entropy
+}
+
+// ファイル操作の重要度の基準（暗号化方式を判定できない場合は最大）
+func fileOperationSeverity(encryption string) int {
+	if encryption == "" {
+		return FILE_OPERATION_SEVERITY
+	}
+
+	return ASSESSED_FILE_OPERATION_SEVERITY
+}
+
+// 暗号化されていないボリュームへの書き込みの重要度を上げる（上限はMAX_SEVERITY）
+func raiseUnencryptedSeverity(severity int) int {
+	return min(severity+UNENCRYPTED_WRITE_INCREASE, MAX_SEVERITY)
+}
@@ -0,0 +1,41 @@
+package usb
+
+import (
+	"testing"
+
+	"github.com/mniyk/endpoint-security-and-monitoring-tools/internal/filewatch"
+	"github.com/mniyk/endpoint-security-and-monitoring-tools/internal/inspection"
+	"github.com/mniyk/endpoint-security-and-monitoring-tools/internal/userinfo"
+)
+
+func TestFileOperationSeverity(t *testing.T) {
+	tests := []struct {
+		name       string
+		encryption string
+		operation  string
+		want       int
+	}{
+		{"unencrypted write", ENCRYPTION_NONE, filewatch.OPERATION_CREATE, MAX_SEVERITY},
+		{"unencrypted delete", ENCRYPTION_NONE, filewatch.OPERATION_DELETE, ASSESSED_FILE_OPERATION_SEVERITY},
+		{"encrypted write", ENCRYPTION_LUKS, filewatch.OPERATION_WRITE, ASSESSED_FILE_OPERATION_SEVERITY},
+		{"unknown encryption", "", filewatch.OPERATION_WRITE, FILE_OPERATION_SEVERITY},
+	}
+
+	for _, tt := range tests {
+		monitor, _ := newTestMonitor(t, nil, newFakeDriveEnumerator())
+		monitor.userInfo = &userinfo.UserInfo{}
+		monitor.logFileOperation(Volume{ID: "sdb1", Encryption: tt.encryption}, filewatch.Change{
+			Operation: tt.operation,
+			Path:      "/media/usb/file.txt",
+		}, inspection.Result{})
+
+		events := monitor.GetEvents()
+		if len(events) != 1 || events[0].Severity != tt.want {
+			t.Errorf("%s: got %+v, want severity %d", tt.name, events, tt.want)
+		}
+	}
+
+	if ASSESSED_FILE_OPERATION_SEVERITY >= MAX_SEVERITY {
+		t.Fatal("no headroom to raise unencrypted writes")
+	}
+}
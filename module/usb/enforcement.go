@@ -0,0 +1,234 @@
+package usb
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+)
+
+const (
+	ENFORCEMENT_OFF              = "off"   // 強制しない
+	ENFORCEMENT_AUDIT            = "audit" // 実行する予定の操作をイベントとして記録するのみ（ドライラン）
+	ENFORCEMENT_BLOCK            = "block" // 承認されていないデバイスを実際にブロック
+	ENFORCEMENT_DEAUTHORIZE      = "deauthorize"
+	ENFORCEMENT_READ_ONLY        = "read_only"
+	ENFORCEMENT_ACTION_SEVERITY  = 5
+	ENFORCEMENT_RESULT_DRY_RUN   = "dry_run"
+	ENFORCEMENT_RESULT_OVERRIDE  = "overridden"
+	ENFORCEMENT_RESULT_SUCCEEDED = "succeeded"
+	ENFORCEMENT_RESULT_FAILED    = "failed"
+)
+
+// 対応していないプラットフォームでブロックしようとした場合のエラー
+var ErrEnforcementUnsupported = errors.New("usb enforcement is not supported on this platform")
+
+// 承認されていないデバイスをブロックするインターフェース
+type Enforcer interface {
+	// USBデバイスをsysfsのauthorizedで無効化
+	Deauthorize(device *DeviceInfo) error
+	// ボリュームを読み取り専用で再マウント
+	RemountReadOnly(drive Volume) error
+}
+
+// 強制モードと操作の設定を検証
+func validateEnforcement(config MonitorConfig) error {
+	switch config.Enforcement {
+	case ENFORCEMENT_OFF:
+		return nil
+	case ENFORCEMENT_AUDIT, ENFORCEMENT_BLOCK:
+	default:
+		return fmt.Errorf("unknown enforcement mode %q", config.Enforcement)
+	}
+
+	switch config.EnforcementAction {
+	case ENFORCEMENT_DEAUTHORIZE, ENFORCEMENT_READ_ONLY:
+	default:
+		return fmt.Errorf("unknown enforcement action %q", config.EnforcementAction)
+	}
+
+	if config.PolicyFile == "" {
+		return errors.New("enforcement requires policy_file")
+	}
+	if config.Enforcement == ENFORCEMENT_BLOCK && !ENFORCEMENT_SUPPORTED {
+		return ErrEnforcementUnsupported
+	}
+
+	return nil
+}
+
+// 強制の対象の構造体（deauthorizeはUSBデバイスごと、read_onlyはボリュームごと）
+type enforcementTarget struct {
+	key    string      // 切断されるまで同じ対象に再適用しないための識別子
+	device *DeviceInfo // 識別情報がない場合はnil
+	drive  *Volume     // 再マウントするボリューム（マウントされていないデバイスの場合はnil）
+	drives []string    // 対象に含まれるボリュームのID
+}
+
+// 強制が有効かどうかを確認
+func (m *Monitor) enforcementEnabled() bool {
+	return m.policy != nil && m.config.Enforcement != ENFORCEMENT_OFF
+}
+
+// 接続されているUSBデバイスとボリュームにポリシーを強制し、ブロックしたボリュームのIDを取得
+// 適用済みの対象は切断されるまで再適用しない（解除ファイルで見送った対象は解除ファイルが削除されたら適用）
+func (m *Monitor) enforceConnected(drives []Volume) map[string]bool {
+	targets := m.enforcementTargets(drives)
+
+	// 切断された対象の記録を削除
+	present := make(map[string]bool, len(targets))
+	for _, target := range targets {
+		present[target.key] = true
+	}
+	for key := range m.enforced {
+		if !present[key] {
+			delete(m.enforced, key)
+		}
+	}
+
+	blocked := make(map[string]bool)
+	for _, target := range targets {
+		result, done := m.enforced[target.key]
+		if !done || (result == ENFORCEMENT_RESULT_OVERRIDE && !m.overrideActive()) {
+			// ポリシーに違反していない対象は記録せず、ポリシーの更新後に判定し直す
+			if result = m.enforce(target); result != "" {
+				m.enforced[target.key] = result
+			}
+		}
+
+		if result == ENFORCEMENT_RESULT_SUCCEEDED {
+			for _, id := range target.drives {
+				blocked[id] = true
+			}
+		}
+	}
+
+	return blocked
+}
+
+// 強制の対象を列挙（マウントされていないUSBストレージも含む）
+func (m *Monitor) enforcementTargets(drives []Volume) []enforcementTarget {
+	var targets []enforcementTarget
+
+	// 読み取り専用での再マウントはマウントされているボリュームのみが対象
+	if m.config.EnforcementAction == ENFORCEMENT_READ_ONLY {
+		for i := range drives {
+			targets = append(targets, enforcementTarget{
+				key:    "volume:" + drives[i].ID,
+				device: drives[i].Device,
+				drive:  &drives[i],
+				drives: []string{drives[i].ID},
+			})
+		}
+		return targets
+	}
+
+	// パーティションごとではなくUSBデバイスごとに1回だけ無効化
+	indexes := make(map[string]int)
+	add := func(key string, device *DeviceInfo, driveID string) {
+		i, exists := indexes[key]
+		if !exists {
+			i = len(targets)
+			indexes[key] = i
+			targets = append(targets, enforcementTarget{key: key, device: device})
+		}
+		if driveID != "" {
+			targets[i].drives = append(targets[i].drives, driveID)
+		}
+	}
+
+	for _, drive := range drives {
+		if drive.Device == nil || drive.Device.BusPath == "" {
+			add("volume:"+drive.ID, drive.Device, drive.ID)
+		} else {
+			add("device:"+drive.Device.BusPath, drive.Device, drive.ID)
+		}
+	}
+
+	if lister, ok := m.enumerator.(DeviceLister); ok {
+		devices, err := lister.StorageDevices()
+		if err != nil {
+			log.Printf("[%s] Failed enumerating USB storage devices: %v\n", MODULE_NAME, err)
+			m.health.RecordError(err)
+		}
+		for i := range devices {
+			add("device:"+devices[i].BusPath, &devices[i], "")
+		}
+	}
+
+	return targets
+}
+
+// ポリシーに違反した対象に強制を適用してイベントを記録（違反していない場合は空の結果）
+func (m *Monitor) enforce(target enforcementTarget) string {
+	var data map[string]interface{}
+	if target.drive != nil {
+		data = m.driveEventData(*target.drive)
+	} else {
+		data = m.deviceEventData(target.device, target.drives)
+	}
+
+	severity := m.applyPolicy(target.device, ENFORCEMENT_ACTION_SEVERITY, data)
+	if violation, _ := data["policy_violation"].(bool); !violation {
+		return ""
+	}
+
+	data["mode"] = m.config.Enforcement
+	data["action"] = m.config.EnforcementAction
+	data["dry_run"] = m.config.Enforcement != ENFORCEMENT_BLOCK
+
+	switch {
+	case m.config.Enforcement == ENFORCEMENT_AUDIT:
+		data["result"] = ENFORCEMENT_RESULT_DRY_RUN
+
+	case m.overrideActive():
+		// 緊急時の解除ファイルがある場合はブロックしない
+		data["result"] = ENFORCEMENT_RESULT_OVERRIDE
+		data["override_file"] = m.config.OverrideFile
+
+	default:
+		var err error
+		if target.drive == nil {
+			err = m.enforcer.Deauthorize(target.device)
+		} else {
+			err = m.enforcer.RemountReadOnly(*target.drive)
+		}
+
+		if err != nil {
+			log.Printf("[%s] Failed enforcing %s on %s: %v\n", MODULE_NAME, m.config.EnforcementAction, target.key, err)
+			m.health.RecordError(err)
+			data["result"] = ENFORCEMENT_RESULT_FAILED
+			data["error"] = err.Error()
+		} else {
+			data["result"] = ENFORCEMENT_RESULT_SUCCEEDED
+		}
+	}
+
+	log.Printf("[%s] Enforcement %s on %s: %s\n", MODULE_NAME, m.config.EnforcementAction, target.key, data["result"])
+
+	m.addEvent("usb_enforcement_action", severity, data)
+
+	return data["result"].(string)
+}
+
+// USBデバイスの強制のイベントデータを作成
+func (m *Monitor) deviceEventData(device *DeviceInfo, drives []string) map[string]interface{} {
+	data := map[string]interface{}{
+		"drives": drives,
+		"user":   m.userInfo.UserName,
+		"host":   m.userInfo.HostName,
+	}
+	device.addTo(data)
+
+	return data
+}
+
+// 緊急時の解除ファイルが存在するかどうかを確認
+func (m *Monitor) overrideActive() bool {
+	if m.config.OverrideFile == "" {
+		return false
+	}
+
+	_, err := os.Stat(m.config.OverrideFile)
+	return err == nil
+}
@@ -0,0 +1,52 @@
+package usb
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"golang.org/x/sys/unix"
+)
+
+const ENFORCEMENT_SUPPORTED = true
+
+// sysfsとmount(2)でデバイスをブロックするEnforcer
+type linuxEnforcer struct {
+	sysfsRoot string
+}
+
+// 新しいEnforcerを作成
+func NewEnforcer(config *MonitorConfig) Enforcer {
+	return &linuxEnforcer{sysfsRoot: config.SysfsRoot}
+}
+
+// /sys/bus/usb/devices/<バスパス>/authorized に0を書き込んでデバイスを無効化
+func (e *linuxEnforcer) Deauthorize(device *DeviceInfo) error {
+	if device == nil || device.BusPath == "" {
+		return errors.New("device has no USB bus path")
+	}
+
+	// sysfsの属性ファイルは作成しない
+	path := filepath.Join(e.sysfsRoot, "bus", "usb", "devices", device.BusPath, "authorized")
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_TRUNC, 0)
+	if err != nil {
+		return fmt.Errorf("failed deauthorizing %s: %w", device.BusPath, err)
+	}
+	defer file.Close()
+
+	if _, err := file.WriteString("0"); err != nil {
+		return fmt.Errorf("failed deauthorizing %s: %w", device.BusPath, err)
+	}
+
+	return nil
+}
+
+// ボリュームを読み取り専用で再マウント
+func (e *linuxEnforcer) RemountReadOnly(drive Volume) error {
+	if err := unix.Mount("", drive.MountPath, "", unix.MS_REMOUNT|unix.MS_RDONLY, ""); err != nil {
+		return fmt.Errorf("failed remounting %s read-only: %w", drive.MountPath, err)
+	}
+
+	return nil
+}
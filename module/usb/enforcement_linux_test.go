@@ -0,0 +1,321 @@
+package usb
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/mniyk/endpoint-security-and-monitoring-tools/internal/config"
+	"github.com/mniyk/endpoint-security-and-monitoring-tools/internal/testutil"
+	"github.com/mniyk/endpoint-security-and-monitoring-tools/module"
+)
+
+// マウントされていないUSBメモリ（ブロックデバイスはあるがパーティションはマウントされていない）
+const unmountedStickDevicePath = "devices/pci0000:00/0000:00:14.0/usb2/2-4"
+
+// 偽のシステムでポリシーと強制を設定したMonitorを作成（承認するのはSanDiskのUSBメモリのみ）
+func newEnforcementMonitor(t *testing.T, mode string) (*Monitor, *MonitorConfig) {
+	t.Helper()
+
+	fakeSystem, _, _ := newFakeSystem(t)
+	testutil.WriteFiles(t, fakeSystem.SysfsRoot, map[string]string{
+		unmountedStickDevicePath + "/idVendor":                                        "090c\n",
+		unmountedStickDevicePath + "/idProduct":                                       "1000\n",
+		unmountedStickDevicePath + "/serial":                                          "0411\n",
+		unmountedStickDevicePath + "/authorized":                                      "1\n",
+		unmountedStickDevicePath + "/2-4:1.0/bInterfaceClass":                         "08\n",
+		unmountedStickDevicePath + "/2-4:1.0/host9/target9:0:0/9:0:0:0/block/sdg/dev": "8:96\n",
+		// ルートハブとUSBマスストレージ以外のデバイスは対象外
+		"devices/pci0000:00/0000:00:14.0/usb2/idVendor":                    "1d6b\n",
+		"devices/pci0000:00/0000:00:14.0/usb2/2-0:1.0/bInterfaceClass":     "09\n",
+		"devices/pci0000:00/0000:00:14.0/usb2/2-5/idVendor":                "046d\n",
+		"devices/pci0000:00/0000:00:14.0/usb2/2-5/2-5:1.0/bInterfaceClass": "03\n",
+	})
+	writeTestLinks(t, fakeSystem.SysfsRoot, map[string]string{
+		"bus/usb/devices/2-4":  "../../../" + unmountedStickDevicePath,
+		"bus/usb/devices/2-5":  "../../../devices/pci0000:00/0000:00:14.0/usb2/2-5",
+		"bus/usb/devices/usb2": "../../../devices/pci0000:00/0000:00:14.0/usb2",
+		"block/sdg":            "../" + unmountedStickDevicePath + "/2-4:1.0/host9/target9:0:0/9:0:0:0/block/sdg",
+	})
+
+	monitorConfig, err := NewMonitorConfig(config.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	monitorConfig.SysfsRoot = fakeSystem.SysfsRoot
+	monitorConfig.ProcfsRoot = fakeSystem.ProcfsRoot
+	monitorConfig.DevfsRoot = fakeSystem.DevfsRoot
+	monitorConfig.Enforcement = mode
+	monitorConfig.EnforcementAction = ENFORCEMENT_DEAUTHORIZE
+	monitorConfig.OverrideFile = filepath.Join(t.TempDir(), "override")
+	monitorConfig.PolicyFile = filepath.Join(t.TempDir(), "policy.json")
+	writePolicyFile(t, monitorConfig.PolicyFile, `{"approved": [{"vendor_id": "0781", "label": "corporate"}]}`, time.Now().Add(-time.Hour))
+
+	monitor, _ := newTestMonitor(t, monitorConfig, NewDriveEnumerator(monitorConfig))
+	return monitor, monitorConfig
+}
+
+// ポリシーファイルを書き込んで更新日時を設定
+func writePolicyFile(t *testing.T, path string, content string, modTime time.Time) {
+	t.Helper()
+
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(path, modTime, modTime); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// USBデバイスのauthorizedの値を取得
+func readAuthorized(t *testing.T, monitorConfig *MonitorConfig, busPath string) string {
+	t.Helper()
+
+	data, err := os.ReadFile(filepath.Join(monitorConfig.SysfsRoot, "bus", "usb", "devices", busPath, "authorized"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return string(data)
+}
+
+// 強制のイベントをバスパスごとに取得
+func enforcementEvents(monitor *Monitor) map[string]module.Event {
+	events := make(map[string]module.Event)
+	for _, event := range monitor.GetEvents() {
+		if event.Type == "usb_enforcement_action" {
+			events[event.Data["bus_path"].(string)] = event
+		}
+	}
+
+	return events
+}
+
+// 強制のイベントの件数を取得
+func countEnforcementEvents(monitor *Monitor) int {
+	count := 0
+	for _, event := range monitor.GetEvents() {
+		if event.Type == "usb_enforcement_action" {
+			count++
+		}
+	}
+
+	return count
+}
+
+func TestStorageDevices(t *testing.T) {
+	_, monitorConfig := newEnforcementMonitor(t, ENFORCEMENT_AUDIT)
+
+	devices, err := NewDriveEnumerator(monitorConfig).(DeviceLister).StorageDevices()
+	if err != nil {
+		t.Fatalf("StorageDevices: %v", err)
+	}
+
+	var busPaths []string
+	for _, device := range devices {
+		busPaths = append(busPaths, device.BusPath)
+		if device.InterfaceClass != MASS_STORAGE_CLASS {
+			t.Errorf("device %s has interface class %q", device.BusPath, device.InterfaceClass)
+		}
+	}
+	if want := []string{"2-1", "2-3", "2-4", "3-2"}; !slices.Equal(busPaths, want) {
+		t.Fatalf("StorageDevices = %v, want %v", busPaths, want)
+	}
+}
+
+func TestEnforcementAuditOnlyRecordsEvents(t *testing.T) {
+	monitor, monitorConfig := newEnforcementMonitor(t, ENFORCEMENT_AUDIT)
+	ctx := t.Context()
+
+	monitor.scanDrives(ctx)
+
+	events := enforcementEvents(monitor)
+	if len(events) != 3 {
+		t.Fatalf("got enforcement events for %d devices, want 3: %v", len(events), eventTypes(monitor))
+	}
+	for _, busPath := range []string{"2-3", "2-4", "3-2"} {
+		event, ok := events[busPath]
+		if !ok {
+			t.Fatalf("no enforcement event for %s", busPath)
+		}
+		if event.Data["result"] != ENFORCEMENT_RESULT_DRY_RUN || event.Data["dry_run"] != true || event.Data["mode"] != ENFORCEMENT_AUDIT {
+			t.Errorf("%s: unexpected audit event %v", busPath, event.Data)
+		}
+		if event.Severity != MAX_SEVERITY {
+			t.Errorf("%s: severity %d, want %d", busPath, event.Severity, MAX_SEVERITY)
+		}
+	}
+
+	// マウントされていないデバイスはボリュームなしで記録
+	if drives := events["2-4"].Data["drives"].([]string); len(drives) != 0 {
+		t.Errorf("unmounted device has drives %v", drives)
+	}
+	if drives := events["3-2"].Data["drives"].([]string); !slices.Equal(drives, []string{"sdc"}) {
+		t.Errorf("device 3-2 has drives %v, want [sdc]", drives)
+	}
+
+	// 監査モードでは何も書き込まず、すべてのドライブを監視
+	for _, busPath := range []string{"2-1", "2-3", "2-4", "3-2"} {
+		if got := readAuthorized(t, monitorConfig, busPath); got != "1\n" {
+			t.Errorf("%s authorized = %q in audit mode", busPath, got)
+		}
+	}
+	if len(monitor.watchContexts) != 3 {
+		t.Errorf("%d drives watched, want 3", len(monitor.watchContexts))
+	}
+
+	// 同じ接続では再度記録しない
+	monitor.scanDrives(ctx)
+	if count := countEnforcementEvents(monitor); count != 3 {
+		t.Errorf("rescan produced %d enforcement events in total, want 3", count)
+	}
+}
+
+func TestEnforcementBlockDeauthorizesDevices(t *testing.T) {
+	monitor, monitorConfig := newEnforcementMonitor(t, ENFORCEMENT_BLOCK)
+	ctx := t.Context()
+
+	monitor.scanDrives(ctx)
+
+	events := enforcementEvents(monitor)
+	if len(events) != 3 {
+		t.Fatalf("got enforcement events for %d devices, want 3: %v", len(events), eventTypes(monitor))
+	}
+	for busPath, event := range events {
+		if event.Data["result"] != ENFORCEMENT_RESULT_SUCCEEDED || event.Data["dry_run"] != false {
+			t.Errorf("%s: unexpected block event %v", busPath, event.Data)
+		}
+	}
+
+	tests := []struct {
+		busPath    string
+		authorized string
+	}{
+		{"2-1", "1\n"}, // 承認されたデバイス
+		{"2-3", "0"},
+		{"2-4", "0"}, // マウントされていないデバイスもブロック
+		{"3-2", "0"},
+	}
+	for _, tt := range tests {
+		if got := readAuthorized(t, monitorConfig, tt.busPath); got != tt.authorized {
+			t.Errorf("%s authorized = %q, want %q", tt.busPath, got, tt.authorized)
+		}
+	}
+
+	// ブロックしたドライブは監視しない
+	if _, ok := monitor.watchContexts["sdb1"]; !ok || len(monitor.watchContexts) != 1 {
+		t.Errorf("watched drives %v, want only sdb1", keys(monitor.watchContexts))
+	}
+
+	// 切断されるまでは再度書き込まない
+	monitor.scanDrives(ctx)
+	if count := countEnforcementEvents(monitor); count != 3 {
+		t.Errorf("rescan produced %d enforcement events in total, want 3", count)
+	}
+}
+
+func TestEnforcementFailureEvent(t *testing.T) {
+	monitor, monitorConfig := newEnforcementMonitor(t, ENFORCEMENT_BLOCK)
+	ctx := t.Context()
+
+	// 属性ファイルがない場合は作成せずに失敗として記録
+	authorized := filepath.Join(monitorConfig.SysfsRoot, unmountedStickDevicePath, "authorized")
+	if err := os.Remove(authorized); err != nil {
+		t.Fatal(err)
+	}
+
+	monitor.scanDrives(ctx)
+	monitor.scanDrives(ctx)
+
+	event, ok := enforcementEvents(monitor)["2-4"]
+	if !ok {
+		t.Fatal("no enforcement event for 2-4")
+	}
+	if event.Data["result"] != ENFORCEMENT_RESULT_FAILED {
+		t.Errorf("result = %v, want %s", event.Data["result"], ENFORCEMENT_RESULT_FAILED)
+	}
+	if message, _ := event.Data["error"].(string); message == "" {
+		t.Error("failure event has no error")
+	}
+	if _, err := os.Stat(authorized); !os.IsNotExist(err) {
+		t.Errorf("authorized was created: %v", err)
+	}
+	if count := countEnforcementEvents(monitor); count != 3 {
+		t.Errorf("got %d enforcement events in total, want 3 (failures are not retried)", count)
+	}
+	if monitor.Health().ErrorCount == 0 {
+		t.Error("failure not recorded in health")
+	}
+}
+
+func TestEnforcementOverride(t *testing.T) {
+	monitor, monitorConfig := newEnforcementMonitor(t, ENFORCEMENT_BLOCK)
+	ctx := t.Context()
+
+	if err := os.WriteFile(monitorConfig.OverrideFile, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	monitor.scanDrives(ctx)
+	if got := enforcementEvents(monitor)["3-2"].Data["result"]; got != ENFORCEMENT_RESULT_OVERRIDE {
+		t.Fatalf("result = %v with override file, want %s", got, ENFORCEMENT_RESULT_OVERRIDE)
+	}
+	if got := readAuthorized(t, monitorConfig, "3-2"); got != "1\n" {
+		t.Fatalf("authorized = %q with override file", got)
+	}
+
+	// 解除ファイルが削除されたらブロック
+	if err := os.Remove(monitorConfig.OverrideFile); err != nil {
+		t.Fatal(err)
+	}
+	monitor.scanDrives(ctx)
+	if got := enforcementEvents(monitor)["3-2"].Data["result"]; got != ENFORCEMENT_RESULT_SUCCEEDED {
+		t.Fatalf("result = %v after override removed, want %s", got, ENFORCEMENT_RESULT_SUCCEEDED)
+	}
+	if got := readAuthorized(t, monitorConfig, "3-2"); got != "0" {
+		t.Fatalf("authorized = %q after override removed", got)
+	}
+}
+
+func TestEnforcementPolicyReloadBlocksConnectedDevices(t *testing.T) {
+	monitor, monitorConfig := newEnforcementMonitor(t, ENFORCEMENT_BLOCK)
+	ctx := t.Context()
+
+	// 拒否リストのみのポリシーではすべてのデバイスを承認
+	writePolicyFile(t, monitorConfig.PolicyFile, `{"blocked": []}`, time.Now().Add(-30*time.Minute))
+	monitor.scanDrives(ctx)
+	if count := countEnforcementEvents(monitor); count != 0 {
+		t.Fatalf("got %d enforcement events with everything approved", count)
+	}
+
+	// 接続中のデバイスを拒否リストに追加
+	writePolicyFile(t, monitorConfig.PolicyFile, `{"blocked": [{"vendor_id": "1058", "label": "personal disk"}]}`, time.Now())
+	if !monitor.policy.Changed() {
+		t.Fatal("policy change not detected")
+	}
+	monitor.scanDrives(ctx)
+
+	events := enforcementEvents(monitor)
+	if len(events) != 1 || events["3-2"].Data["result"] != ENFORCEMENT_RESULT_SUCCEEDED {
+		t.Fatalf("enforcement after reload = %v, want only 3-2 blocked", events)
+	}
+	if events["3-2"].Data["policy_label"] != "personal disk" {
+		t.Errorf("policy_label = %v", events["3-2"].Data["policy_label"])
+	}
+	if got := readAuthorized(t, monitorConfig, "3-2"); got != "0" {
+		t.Errorf("authorized = %q after reload", got)
+	}
+}
+
+// マップのキーを取得
+func keys[V any](m map[string]V) []string {
+	var result []string
+	for key := range m {
+		result = append(result, key)
+	}
+	slices.Sort(result)
+
+	return result
+}
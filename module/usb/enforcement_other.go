@@ -0,0 +1,23 @@
+//go:build !linux
+
+package usb
+
+const ENFORCEMENT_SUPPORTED = false
+
+// 対応していないプラットフォーム用のEnforcer
+type unsupportedEnforcer struct{}
+
+// 新しいEnforcerを作成
+func NewEnforcer(config *MonitorConfig) Enforcer {
+	return &unsupportedEnforcer{}
+}
+
+// 対応していないためエラーを返す
+func (e *unsupportedEnforcer) Deauthorize(device *DeviceInfo) error {
+	return ErrEnforcementUnsupported
+}
+
+// 対応していないためエラーを返す
+func (e *unsupportedEnforcer) RemountReadOnly(drive Volume) error {
+	return ErrEnforcementUnsupported
+}
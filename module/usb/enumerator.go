@@ -0,0 +1,52 @@
+package usb
+
+// ボリュームの情報の構造体
+type Volume struct {
+	ID         string // Windowsはドライブレター、Linuxはデバイス名（例: sdb1）
+	MountPath  string // ボリュームのルートパス（例: E:\ /media/user/USB）
+	Removable  bool
+	FileSystem string
+	Label      string
+	UUID       string      // ファイルシステムのUUID（WindowsとFATはボリュームのシリアル番号）
+	Size       uint64      // バイト単位の容量
+	FreeBytes  uint64      // 列挙時の空き容量
+	DevicePath string      // 暗号化の判定でヘッダを読み込むデバイス（例: /dev/sdb1 \\.\E:）
+	Encryption string      // 暗号化方式（"none" "luks" "bitlocker" "veracrypt" "dm-crypt"、判定できない場合は空）
+	Device     *DeviceInfo // USBデバイスの識別情報（取得できない場合はnil）
+}
+
+// USBデバイスの識別情報の構造体
+type DeviceInfo struct {
+	VendorID       string // 例: "0781"
+	ProductID      string // 例: "5581"
+	Manufacturer   string
+	Product        string
+	Serial         string
+	BusPath        string // 例: "2-1.4"
+	InterfaceClass string // 例: "08"（マスストレージ）
+}
+
+// イベントデータにUSBデバイスの識別情報を追加
+func (d *DeviceInfo) addTo(data map[string]interface{}) {
+	if d == nil {
+		return
+	}
+
+	data["vendor_id"] = d.VendorID
+	data["product_id"] = d.ProductID
+	data["manufacturer"] = d.Manufacturer
+	data["product"] = d.Product
+	data["serial"] = d.Serial
+	data["bus_path"] = d.BusPath
+	data["interface_class"] = d.InterfaceClass
+}
+
+// ボリュームを列挙するためのメソッドを定義
+type DriveEnumerator interface {
+	Volumes() ([]Volume, error) // マウントされているボリュームを取得
+}
+
+// マウントされていないものも含めてUSBストレージデバイスを列挙するためのメソッドを定義
+type DeviceLister interface {
+	StorageDevices() ([]DeviceInfo, error)
+}
@@ -0,0 +1,326 @@
+package usb
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"golang.org/x/sys/unix"
+
+	"github.com/mniyk/endpoint-security-and-monitoring-tools/internal/mountinfo"
+	"github.com/mniyk/endpoint-security-and-monitoring-tools/internal/sysfs"
+)
+
+const (
+	DEVICE_IDENTITY_SUPPORTED = true
+
+	DEFAULT_SYSFS_ROOT  = "/sys"
+	DEFAULT_PROCFS_ROOT = "/proc"
+	DEFAULT_DEVFS_ROOT  = "/dev"
+	SECTOR_SIZE         = 512
+	MASS_STORAGE_CLASS  = "08" // USBマスストレージのインターフェースクラス
+)
+
+// リムーバブルメディアとして扱わないブロックデバイスの接頭辞
+var ignoredBlockDevicePrefixes = []string{"loop", "ram", "zram", "dm-", "md", "sr", "nbd"}
+
+// sysfsとmountinfoからボリュームを列挙するDriveEnumerator
+type linuxDriveEnumerator struct {
+	sysfsRoot  string
+	procfsRoot string
+	devfsRoot  string
+}
+
+// 新しいDriveEnumeratorを作成
+func NewDriveEnumerator(config *MonitorConfig) DriveEnumerator {
+	return &linuxDriveEnumerator{
+		sysfsRoot:  config.SysfsRoot,
+		procfsRoot: config.ProcfsRoot,
+		devfsRoot:  config.DevfsRoot,
+	}
+}
+
+// マウントされているボリュームを取得
+func (e *linuxDriveEnumerator) Volumes() ([]Volume, error) {
+	var volumes []Volume
+
+	// マウント情報を読み込み
+	mounts, err := mountinfo.ReadFile(filepath.Join(e.procfsRoot, "self", "mountinfo"))
+	if err != nil {
+		return nil, fmt.Errorf("failed reading mountinfo: %w", err)
+	}
+
+	// デバイス番号とデバイスパスからマウント情報を引けるようにする
+	mountsByNumber := make(map[string]mountinfo.Mount)
+	mountsBySource := make(map[string]mountinfo.Mount)
+	for _, mount := range mounts {
+		if _, exists := mountsByNumber[mount.DeviceNumber()]; !exists {
+			mountsByNumber[mount.DeviceNumber()] = mount
+		}
+		if _, exists := mountsBySource[mount.Source]; !exists {
+			mountsBySource[mount.Source] = mount
+		}
+	}
+
+	// ブロックデバイスを列挙
+	blockRoot := filepath.Join(e.sysfsRoot, "block")
+	entries, err := os.ReadDir(blockRoot)
+	if err != nil {
+		return nil, fmt.Errorf("failed reading %s: %w", blockRoot, err)
+	}
+
+	labels := e.readDiskLinks("by-label")
+	uuids := e.readDiskLinks("by-uuid")
+
+	for _, entry := range entries {
+		deviceName := entry.Name()
+		if isIgnoredBlockDevice(deviceName) {
+			continue
+		}
+
+		devicePath := filepath.Join(blockRoot, deviceName)
+		removable := isRemovableBlockDevice(devicePath)
+		device := e.readUSBDeviceInfo(devicePath)
+
+		// ディスク本体とパーティションのマウントポイントを確認
+		for _, name := range append([]string{deviceName}, listPartitions(devicePath)...) {
+			sysPath := devicePath
+			if name != deviceName {
+				sysPath = filepath.Join(devicePath, name)
+			}
+
+			volume := Volume{
+				ID:         name,
+				Removable:  removable,
+				Size:       readSectors(filepath.Join(sysPath, "size")) * SECTOR_SIZE,
+				DevicePath: filepath.Join(e.devfsRoot, name),
+				Device:     device,
+			}
+
+			mountedName := name
+			mount, ok := mountsByNumber[sysfs.ReadValue(filepath.Join(sysPath, "dev"))]
+			if !ok {
+				mount, ok = mountsBySource[filepath.Join(e.devfsRoot, name)]
+			}
+			if !ok {
+				// dm-cryptで暗号化されたボリュームは復号したデバイス（dm-N）がマウントされる
+				holder, encryption := readCryptHolder(blockRoot, sysPath)
+				if holder == "" {
+					continue
+				}
+				if mount, ok = mountsByNumber[sysfs.ReadValue(filepath.Join(blockRoot, holder, "dev"))]; !ok {
+					continue
+				}
+				mountedName = holder
+				volume.Encryption = encryption
+			}
+
+			volume.MountPath = mount.MountPoint
+			volume.FileSystem = mount.FSType
+			volume.Label = labels[mountedName]
+			volume.UUID = uuids[mountedName]
+			volume.FreeBytes = readFreeBytes(mount.MountPoint)
+
+			volumes = append(volumes, volume)
+		}
+	}
+
+	return volumes, nil
+}
+
+// /dev/disk/by-label や by-uuid のシンボリックリンクからデバイス名とラベルまたはUUIDの対応を取得
+func (e *linuxDriveEnumerator) readDiskLinks(kind string) map[string]string {
+	links := make(map[string]string)
+
+	linkRoot := filepath.Join(e.devfsRoot, "disk", kind)
+	entries, err := os.ReadDir(linkRoot)
+	if err != nil {
+		return links
+	}
+
+	for _, entry := range entries {
+		target, err := os.Readlink(filepath.Join(linkRoot, entry.Name()))
+		if err != nil {
+			continue
+		}
+		links[filepath.Base(target)] = unescapeUdevLabel(entry.Name())
+	}
+
+	return links
+}
+
+// ボリュームを使用しているdm-cryptのデバイス名と暗号化方式を取得（ない場合は空）
+func readCryptHolder(blockRoot string, sysPath string) (string, string) {
+	entries, err := os.ReadDir(filepath.Join(sysPath, "holders"))
+	if err != nil {
+		return "", ""
+	}
+
+	for _, entry := range entries {
+		dmPath := filepath.Join(blockRoot, entry.Name(), "dm")
+		encryption := encryptionOfDMCrypt(sysfs.ReadValue(filepath.Join(dmPath, "uuid")), sysfs.ReadValue(filepath.Join(dmPath, "name")))
+		if encryption != "" {
+			return entry.Name(), encryption
+		}
+	}
+
+	return "", ""
+}
+
+// マウントポイントの空き容量を取得（取得できない場合は0）
+func readFreeBytes(mountPoint string) uint64 {
+	var stat unix.Statfs_t
+	if err := unix.Statfs(mountPoint, &stat); err != nil {
+		return 0
+	}
+
+	return stat.Bavail * uint64(stat.Bsize)
+}
+
+// udevがエスケープしたラベルを復元（例: "MY\x20STICK"）
+func unescapeUdevLabel(label string) string {
+	var builder strings.Builder
+	for i := 0; i < len(label); i++ {
+		if label[i] == '\\' && i+4 <= len(label) && label[i+1] == 'x' {
+			if code, err := strconv.ParseUint(label[i+2:i+4], 16, 8); err == nil {
+				builder.WriteByte(byte(code))
+				i += 3
+				continue
+			}
+		}
+		builder.WriteByte(label[i])
+	}
+
+	return builder.String()
+}
+
+// 監視対象外のブロックデバイスかどうかを確認
+func isIgnoredBlockDevice(name string) bool {
+	for _, prefix := range ignoredBlockDevicePrefixes {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// リムーバブルまたはUSB接続のブロックデバイスかどうかを確認
+func isRemovableBlockDevice(devicePath string) bool {
+	if sysfs.ReadValue(filepath.Join(devicePath, "removable")) == "1" {
+		return true
+	}
+
+	// USB接続の外付けディスクはremovableが0の場合があるため接続経路も確認
+	link, err := os.Readlink(devicePath)
+	if err != nil {
+		return false
+	}
+
+	return strings.Contains(filepath.ToSlash(link), "/usb")
+}
+
+// ブロックデバイスの親をたどってUSBデバイスの識別情報を取得（USB接続でない場合はnil）
+// 例: /sys/devices/pci0000:00/.../usb2/2-1/2-1:1.0/host6/target6:0:0/6:0:0:0/block/sdb
+func (e *linuxDriveEnumerator) readUSBDeviceInfo(devicePath string) *DeviceInfo {
+	resolved, err := filepath.EvalSymlinks(devicePath)
+	if err != nil {
+		return nil
+	}
+
+	sysfsRoot, err := filepath.EvalSymlinks(e.sysfsRoot)
+	if err != nil {
+		return nil
+	}
+
+	// idVendorを持つディレクトリ（USBデバイス）までsysfsの中で親をたどる
+	interfacePath := ""
+	for current := filepath.Dir(resolved); strings.HasPrefix(current, sysfsRoot+string(os.PathSeparator)); current = filepath.Dir(current) {
+		if _, err := os.Stat(filepath.Join(current, "idVendor")); err == nil {
+			return readDeviceInfo(current, interfacePath)
+		}
+
+		// USBデバイス直下のインターフェース（例: 2-1:1.0）を記録
+		if _, err := os.Stat(filepath.Join(current, "bInterfaceClass")); err == nil {
+			interfacePath = current
+		}
+	}
+
+	return nil
+}
+
+// USBデバイスのディレクトリから識別情報を取得（インターフェースがわからない場合はクラスを空にする）
+func readDeviceInfo(devicePath string, interfacePath string) *DeviceInfo {
+	device := &DeviceInfo{
+		VendorID:     sysfs.ReadValue(filepath.Join(devicePath, "idVendor")),
+		ProductID:    sysfs.ReadValue(filepath.Join(devicePath, "idProduct")),
+		Manufacturer: sysfs.ReadValue(filepath.Join(devicePath, "manufacturer")),
+		Product:      sysfs.ReadValue(filepath.Join(devicePath, "product")),
+		Serial:       sysfs.ReadValue(filepath.Join(devicePath, "serial")),
+		BusPath:      filepath.Base(devicePath),
+	}
+	if interfacePath != "" {
+		device.InterfaceClass = sysfs.ReadValue(filepath.Join(interfacePath, "bInterfaceClass"))
+	}
+
+	return device
+}
+
+// マウントされていないものも含めてUSBマスストレージのデバイスを取得
+func (e *linuxDriveEnumerator) StorageDevices() ([]DeviceInfo, error) {
+	devicesRoot := filepath.Join(e.sysfsRoot, "bus", "usb", "devices")
+	entries, err := os.ReadDir(devicesRoot)
+	if err != nil {
+		return nil, fmt.Errorf("failed reading %s: %w", devicesRoot, err)
+	}
+
+	var devices []DeviceInfo
+	for _, entry := range entries {
+		// インターフェース（例: 2-1:1.0）はデバイスのディレクトリから確認
+		name := entry.Name()
+		if strings.Contains(name, ":") {
+			continue
+		}
+
+		devicePath := filepath.Join(devicesRoot, name)
+		interfaces, _ := filepath.Glob(filepath.Join(devicePath, name+":*"))
+		for _, interfacePath := range interfaces {
+			if sysfs.ReadValue(filepath.Join(interfacePath, "bInterfaceClass")) == MASS_STORAGE_CLASS {
+				devices = append(devices, *readDeviceInfo(devicePath, interfacePath))
+				break
+			}
+		}
+	}
+
+	return devices, nil
+}
+
+// ブロックデバイスのパーティション名を取得
+func listPartitions(devicePath string) []string {
+	var partitions []string
+
+	entries, err := os.ReadDir(devicePath)
+	if err != nil {
+		return partitions
+	}
+
+	for _, entry := range entries {
+		if _, err := os.Stat(filepath.Join(devicePath, entry.Name(), "partition")); err == nil {
+			partitions = append(partitions, entry.Name())
+		}
+	}
+
+	return partitions
+}
+
+// sysfsのセクター数を取得
+func readSectors(path string) uint64 {
+	sectors, err := strconv.ParseUint(sysfs.ReadValue(path), 10, 64)
+	if err != nil {
+		return 0
+	}
+
+	return sectors
+}
@@ -0,0 +1,266 @@
+package usb
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/mniyk/endpoint-security-and-monitoring-tools/internal/testutil"
+)
+
+// USBメモリのsysfs上のパス（/sys/block/sdb のリンク先）
+const (
+	usbStickDevicePath = "devices/pci0000:00/0000:00:14.0/usb2/2-1"
+	usbStickBlockPath  = usbStickDevicePath + "/2-1:1.0/host6/target6:0:0/6:0:0:0/block/sdb"
+	usbDiskDevicePath  = "devices/pci0000:00/0000:00:14.0/usb3/3-2"
+	usbDiskBlockPath   = usbDiskDevicePath + "/3-2:1.0/host7/target7:0:0/7:0:0:0/block/sdc"
+	sataDiskBlockPath  = "devices/pci0000:00/0000:00:17.0/ata1/host0/target0:0:0/0:0:0:0/block/sda"
+	usbCryptDevicePath = "devices/pci0000:00/0000:00:14.0/usb2/2-3"
+	usbCryptBlockPath  = usbCryptDevicePath + "/2-3:1.0/host8/target8:0:0/8:0:0:0/block/sdf"
+)
+
+// テスト用のシンボリックリンクを作成（キーはリンク、値はリンク先）
+func writeTestLinks(t *testing.T, root string, links map[string]string) {
+	t.Helper()
+
+	for name, target := range links {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Symlink(target, path); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// sysfs、procfs、devfsのルートと、マウントポイントとして使うディレクトリを持つ偽のシステムを作成
+func newFakeSystem(t *testing.T) (config *MonitorConfig, stickMount string, diskMount string) {
+	t.Helper()
+
+	root := t.TempDir()
+	sysfs := filepath.Join(root, "sys")
+	procfs := filepath.Join(root, "proc")
+	devfs := filepath.Join(root, "dev")
+
+	// 空白を含むマウントポイント（mountinfoでは \040 にエスケープされる）
+	stickMount = filepath.Join(root, "media", "alice", "MY STICK")
+	diskMount = filepath.Join(root, "media", "alice", "backup")
+	for _, dir := range []string{stickMount, diskMount, filepath.Join(diskMount, "crypt")} {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	testutil.WriteFiles(t, sysfs, map[string]string{
+		// USBメモリ（removable=1、sdb1はマウント済み、sdb2は未マウント）
+		usbStickDevicePath + "/idVendor":                "0781\n",
+		usbStickDevicePath + "/idProduct":               "5581\n",
+		usbStickDevicePath + "/manufacturer":            "SanDisk\n",
+		usbStickDevicePath + "/product":                 "Ultra\n",
+		usbStickDevicePath + "/serial":                  "4C530001\n",
+		usbStickDevicePath + "/authorized":              "1\n",
+		usbStickDevicePath + "/2-1:1.0/bInterfaceClass": "08\n",
+		usbStickBlockPath + "/removable":                "1\n",
+		usbStickBlockPath + "/dev":                      "8:16\n",
+		usbStickBlockPath + "/size":                     "62530624\n",
+		usbStickBlockPath + "/sdb1/partition":           "1\n",
+		usbStickBlockPath + "/sdb1/dev":                 "8:17\n",
+		usbStickBlockPath + "/sdb1/size":                "62521344\n",
+		usbStickBlockPath + "/sdb2/partition":           "2\n",
+		usbStickBlockPath + "/sdb2/dev":                 "8:18\n",
+		usbStickBlockPath + "/sdb2/size":                "8192\n",
+		usbStickBlockPath + "/queue/rotational":         "0\n",
+		usbDiskDevicePath + "/idVendor":                 "1058\n",
+		usbDiskDevicePath + "/idProduct":                "25a2\n",
+		usbDiskDevicePath + "/serial":                   "WX11A\n",
+		usbDiskDevicePath + "/authorized":               "1\n",
+		usbDiskDevicePath + "/3-2:1.0/bInterfaceClass":  "08\n",
+		usbDiskBlockPath + "/removable":                 "0\n",
+		usbDiskBlockPath + "/dev":                       "8:32\n",
+		usbDiskBlockPath + "/size":                      "1953525168\n",
+		sataDiskBlockPath + "/removable":                "0\n",
+		sataDiskBlockPath + "/dev":                      "8:0\n",
+		sataDiskBlockPath + "/sda1/partition":           "1\n",
+		sataDiskBlockPath + "/sda1/dev":                 "8:1\n",
+		usbCryptDevicePath + "/idVendor":                "0951\n",
+		usbCryptDevicePath + "/idProduct":               "1666\n",
+		usbCryptDevicePath + "/authorized":              "1\n",
+		usbCryptDevicePath + "/2-3:1.0/bInterfaceClass": "08\n",
+		usbCryptBlockPath + "/removable":                "1\n",
+		usbCryptBlockPath + "/dev":                      "8:80\n",
+		usbCryptBlockPath + "/sdf1/partition":           "1\n",
+		usbCryptBlockPath + "/sdf1/dev":                 "8:81\n",
+		usbCryptBlockPath + "/sdf1/holders/dm-0/.keep":  "",
+		"devices/virtual/block/dm-0/dev":                "253:0\n",
+		"devices/virtual/block/dm-0/dm/uuid":            "CRYPT-LUKS2-0f1e2d3c-luks-stick\n",
+		"devices/virtual/block/dm-0/dm/name":            "luks-stick\n",
+		"devices/virtual/block/loop0/removable":         "0\n",
+		"devices/virtual/block/loop0/dev":               "7:0\n",
+	})
+	writeTestLinks(t, sysfs, map[string]string{
+		"block/sda":   "../" + sataDiskBlockPath,
+		"block/sdb":   "../" + usbStickBlockPath,
+		"block/sdc":   "../" + usbDiskBlockPath,
+		"block/sdf":   "../" + usbCryptBlockPath,
+		"block/dm-0":  "../devices/virtual/block/dm-0",
+		"block/loop0": "../devices/virtual/block/loop0",
+
+		"bus/usb/devices/2-1":     "../../../" + usbStickDevicePath,
+		"bus/usb/devices/2-1:1.0": "../../../" + usbStickDevicePath + "/2-1:1.0",
+		"bus/usb/devices/3-2":     "../../../" + usbDiskDevicePath,
+		"bus/usb/devices/2-3":     "../../../" + usbCryptDevicePath,
+	})
+	writeTestLinks(t, devfs, map[string]string{
+		`disk/by-label/MY\x20STICK`: "../../sdb1",
+		"disk/by-label/BACKUP":      "../../sdc",
+		"disk/by-uuid/1A2B-3C4D":    "../../sdb1",
+		"disk/by-uuid/9f8e7d6c":     "../../dm-0",
+	})
+
+	// マウント元はdevfsのルートからのパス（btrfsのsdcはデバイス番号が一致しないためマウント元で照合）
+	testutil.WriteFiles(t, procfs, map[string]string{
+		"self/mountinfo": strings.Join([]string{
+			"22 1 8:1 / / rw,relatime shared:1 - ext4 " + devfs + "/sda1 rw",
+			"451 22 8:17 / " + strings.ReplaceAll(stickMount, " ", `\040`) + " rw,nosuid,nodev shared:245 - vfat " + devfs + "/sdb1 rw,codepage=437",
+			"452 22 0:45 / " + diskMount + " rw,relatime shared:246 - btrfs " + devfs + "/sdc rw",
+			"453 22 253:0 / " + diskMount + "/crypt rw,relatime shared:247 - ext4 " + devfs + "/mapper/luks-stick rw",
+			"454 22 7:0 / /snap/core/1 ro,nodev shared:248 - squashfs " + devfs + "/loop0 ro",
+		}, "\n") + "\n",
+	})
+
+	config = &MonitorConfig{SysfsRoot: sysfs, ProcfsRoot: procfs, DevfsRoot: devfs}
+	return config, stickMount, diskMount
+}
+
+func TestLinuxDriveEnumeratorVolumes(t *testing.T) {
+	config, stickMount, diskMount := newFakeSystem(t)
+
+	volumes, err := NewDriveEnumerator(config).Volumes()
+	if err != nil {
+		t.Fatalf("Volumes: %v", err)
+	}
+
+	stick := &DeviceInfo{VendorID: "0781", ProductID: "5581", Manufacturer: "SanDisk", Product: "Ultra", Serial: "4C530001", BusPath: "2-1", InterfaceClass: "08"}
+	disk := &DeviceInfo{VendorID: "1058", ProductID: "25a2", Serial: "WX11A", BusPath: "3-2", InterfaceClass: "08"}
+	crypt := &DeviceInfo{VendorID: "0951", ProductID: "1666", BusPath: "2-3", InterfaceClass: "08"}
+
+	// sdb2は未マウント、loop0は監視対象外のため含まれない
+	want := []Volume{
+		{ID: "sda1", MountPath: "/", FileSystem: "ext4", DevicePath: filepath.Join(config.DevfsRoot, "sda1")},
+		{ID: "sdb1", MountPath: stickMount, Removable: true, FileSystem: "vfat", Label: "MY STICK", UUID: "1A2B-3C4D", Size: 62521344 * SECTOR_SIZE, DevicePath: filepath.Join(config.DevfsRoot, "sdb1"), Device: stick},
+		{ID: "sdc", MountPath: diskMount, Removable: true, FileSystem: "btrfs", Label: "BACKUP", Size: 1953525168 * SECTOR_SIZE, DevicePath: filepath.Join(config.DevfsRoot, "sdc"), Device: disk},
+		{ID: "sdf1", MountPath: diskMount + "/crypt", Removable: true, FileSystem: "ext4", UUID: "9f8e7d6c", DevicePath: filepath.Join(config.DevfsRoot, "sdf1"), Encryption: ENCRYPTION_LUKS, Device: crypt},
+	}
+
+	if len(volumes) != len(want) {
+		t.Fatalf("Volumes returned %d volumes, want %d: %+v", len(volumes), len(want), volumes)
+	}
+	for i := range want {
+		got := volumes[i]
+
+		// 空き容量はテストを実行するファイルシステムに依存するため、実在するマウントポイントで取得できることのみ確認
+		if got.MountPath != "/" && got.FreeBytes == 0 {
+			t.Errorf("volume %s has no free bytes", got.ID)
+		}
+		got.FreeBytes = 0
+
+		if !reflect.DeepEqual(got, want[i]) {
+			t.Errorf("volume %d mismatch\n got: %+v (device %+v)\nwant: %+v (device %+v)", i, got, got.Device, want[i], want[i].Device)
+		}
+	}
+}
+
+func TestLinuxDriveEnumeratorMissingMountinfo(t *testing.T) {
+	config, _, _ := newFakeSystem(t)
+	if err := os.Remove(filepath.Join(config.ProcfsRoot, "self", "mountinfo")); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := NewDriveEnumerator(config).Volumes(); err == nil {
+		t.Fatal("Volumes succeeded without mountinfo, want error")
+	}
+}
+
+func TestIsRemovableBlockDevice(t *testing.T) {
+	config, _, _ := newFakeSystem(t)
+
+	tests := []struct {
+		name string
+		want bool
+	}{
+		{"sdb", true},  // removable=1
+		{"sdc", true},  // removable=0だがUSB接続
+		{"sda", false}, // 内蔵のSATAディスク
+		{"loop0", false},
+	}
+
+	for _, tt := range tests {
+		if got := isRemovableBlockDevice(filepath.Join(config.SysfsRoot, "block", tt.name)); got != tt.want {
+			t.Errorf("isRemovableBlockDevice(%s) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestListPartitions(t *testing.T) {
+	config, _, _ := newFakeSystem(t)
+
+	tests := []struct {
+		name string
+		want []string
+	}{
+		{"sdb", []string{"sdb1", "sdb2"}},
+		{"sdc", nil},
+		{"missing", nil},
+	}
+
+	for _, tt := range tests {
+		if got := listPartitions(filepath.Join(config.SysfsRoot, "block", tt.name)); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("listPartitions(%s) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestIsIgnoredBlockDevice(t *testing.T) {
+	tests := []struct {
+		name string
+		want bool
+	}{
+		{"sdb", false},
+		{"mmcblk0", false},
+		{"nvme0n1", false},
+		{"loop3", true},
+		{"zram0", true},
+		{"dm-1", true},
+		{"md127", true},
+		{"sr0", true},
+		{"nbd0", true},
+	}
+
+	for _, tt := range tests {
+		if got := isIgnoredBlockDevice(tt.name); got != tt.want {
+			t.Errorf("isIgnoredBlockDevice(%s) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestUnescapeUdevLabel(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{`MY\x20STICK`, "MY STICK"},
+		{`a\x2fb`, "a/b"},
+		{`PLAIN`, "PLAIN"},
+		{`bad\xZZ`, `bad\xZZ`},
+		{`short\x2`, `short\x2`},
+	}
+
+	for _, tt := range tests {
+		if got := unescapeUdevLabel(tt.in); got != tt.want {
+			t.Errorf("unescapeUdevLabel(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,25 @@
+//go:build !windows && !linux
+
+package usb
+
+import "errors"
+
+const (
+	DEVICE_IDENTITY_SUPPORTED = false
+	DEFAULT_SYSFS_ROOT        = ""
+	DEFAULT_PROCFS_ROOT       = ""
+	DEFAULT_DEVFS_ROOT        = ""
+)
+
+// 対応していないプラットフォーム用のDriveEnumerator
+type unsupportedDriveEnumerator struct{}
+
+// 新しいDriveEnumeratorを作成
+func NewDriveEnumerator(config *MonitorConfig) DriveEnumerator {
+	return &unsupportedDriveEnumerator{}
+}
+
+// 対応していないためエラーを返す
+func (e *unsupportedDriveEnumerator) Volumes() ([]Volume, error) {
+	return nil, errors.New("volume enumeration is not supported on this platform")
+}
@@ -0,0 +1,144 @@
+package usb
+
+import (
+	"fmt"
+	"syscall"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+const (
+	DEVICE_IDENTITY_SUPPORTED = false // ドライブレターからUSBデバイスの情報を取得しない
+	DRIVE_REMOVABLE           = 2
+	DEFAULT_SYSFS_ROOT        = ""
+	DEFAULT_PROCFS_ROOT       = ""
+	DEFAULT_DEVFS_ROOT        = ""
+	VOLUME_NAME_LENGTH        = windows.MAX_PATH + 1
+)
+
+// Windows API用の関数
+var (
+	kernel32 = windows.NewLazySystemDLL("kernel32.dll")
+
+	procGetLogicalDrives     = kernel32.NewProc("GetLogicalDrives")
+	procGetDriveType         = kernel32.NewProc("GetDriveTypeW")
+	procGetVolumeInformation = kernel32.NewProc("GetVolumeInformationW")
+	procGetDiskFreeSpaceEx   = kernel32.NewProc("GetDiskFreeSpaceExW")
+)
+
+// Win32 APIでドライブレターを列挙するDriveEnumerator
+type windowsDriveEnumerator struct{}
+
+// 新しいDriveEnumeratorを作成
+func NewDriveEnumerator(config *MonitorConfig) DriveEnumerator {
+	return &windowsDriveEnumerator{}
+}
+
+// マウントされているボリュームを取得
+func (e *windowsDriveEnumerator) Volumes() ([]Volume, error) {
+	var volumes []Volume
+
+	driveLetters, err := e.getAvailableDrives()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, driveLetter := range driveLetters {
+		drivePath := fmt.Sprintf("%s:\\", driveLetter)
+
+		drivePathUTF16, err := syscall.UTF16PtrFromString(drivePath)
+		if err != nil {
+			return nil, fmt.Errorf("failed converting drive path to UTF16: %w", err)
+		}
+
+		volume := Volume{
+			ID:         driveLetter,
+			MountPath:  drivePath,
+			Removable:  e.isRemovableDrive(drivePathUTF16),
+			DevicePath: fmt.Sprintf(`\\.\%s:`, driveLetter),
+		}
+		// 固定ドライブやネットワークドライブは問い合わせない（切断済みの共有で待たされるため）
+		if volume.Removable {
+			volume.Label, volume.FileSystem, volume.UUID = e.getVolumeInformation(drivePathUTF16)
+			volume.Size, volume.FreeBytes = e.getVolumeSize(drivePathUTF16)
+		}
+
+		volumes = append(volumes, volume)
+	}
+
+	return volumes, nil
+}
+
+// 利用可能なドライブレターを取得
+func (e *windowsDriveEnumerator) getAvailableDrives() ([]string, error) {
+	var drives []string
+
+	// GetLogicalDrives Win32 API呼び出し
+	bitmask, _, err := procGetLogicalDrives.Call()
+
+	if bitmask == 0 {
+		return nil, fmt.Errorf("failed getting logical drives: %w", err)
+	}
+
+	// 各ビットをチェック (A-Z)
+	for i := 0; i < 26; i++ {
+		if (bitmask & (1 << uint(i))) != 0 {
+			drives = append(drives, string(rune('A'+i)))
+		}
+	}
+
+	return drives, nil
+}
+
+// リムーバブルドライブかどうかをチェック
+func (e *windowsDriveEnumerator) isRemovableDrive(drivePath *uint16) bool {
+	// GetDriveType Win32 API呼び出し
+	driveType, _, _ := procGetDriveType.Call(uintptr(unsafe.Pointer(drivePath)))
+
+	return driveType == DRIVE_REMOVABLE
+}
+
+// ボリュームラベル、ファイルシステム名とシリアル番号を取得（メディアがない場合は空）
+func (e *windowsDriveEnumerator) getVolumeInformation(drivePath *uint16) (string, string, string) {
+	volumeName := make([]uint16, VOLUME_NAME_LENGTH)
+	fileSystemName := make([]uint16, VOLUME_NAME_LENGTH)
+	var serialNumber uint32
+
+	// GetVolumeInformationW Win32 API呼び出し
+	ret, _, _ := procGetVolumeInformation.Call(
+		uintptr(unsafe.Pointer(drivePath)),
+		uintptr(unsafe.Pointer(&volumeName[0])),
+		uintptr(len(volumeName)),
+		uintptr(unsafe.Pointer(&serialNumber)),
+		0, 0,
+		uintptr(unsafe.Pointer(&fileSystemName[0])),
+		uintptr(len(fileSystemName)),
+	)
+	if ret == 0 {
+		return "", "", ""
+	}
+
+	// blkidのFATのUUIDと同じ形式（例: 1A2B-3C4D）
+	serial := fmt.Sprintf("%04X-%04X", serialNumber>>16, serialNumber&0xFFFF)
+
+	return windows.UTF16ToString(volumeName), windows.UTF16ToString(fileSystemName), serial
+}
+
+// ボリュームの容量と空き容量を取得（取得できない場合は0）
+func (e *windowsDriveEnumerator) getVolumeSize(drivePath *uint16) (uint64, uint64) {
+	var freeBytesAvailable, totalBytes, totalFreeBytes uint64
+
+	// GetDiskFreeSpaceExW Win32 API呼び出し
+	ret, _, _ := procGetDiskFreeSpaceEx.Call(
+		uintptr(unsafe.Pointer(drivePath)),
+		uintptr(unsafe.Pointer(&freeBytesAvailable)),
+		uintptr(unsafe.Pointer(&totalBytes)),
+		uintptr(unsafe.Pointer(&totalFreeBytes)),
+	)
+	if ret == 0 {
+		return 0, 0
+	}
+
+	return totalBytes, freeBytesAvailable
+}
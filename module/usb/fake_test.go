@@ -0,0 +1,116 @@
+package usb
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/mniyk/endpoint-security-and-monitoring-tools/internal/config"
+	"github.com/mniyk/endpoint-security-and-monitoring-tools/internal/testutil"
+	"github.com/mniyk/endpoint-security-and-monitoring-tools/internal/userinfo"
+)
+
+// テスト用にメモリ上のボリュームを返すDriveEnumerator
+type fakeDriveEnumerator struct {
+	mu      sync.Mutex
+	volumes map[string]Volume
+	order   []string
+	err     error
+}
+
+// 新しいfakeDriveEnumeratorを作成
+func newFakeDriveEnumerator(volumes ...Volume) *fakeDriveEnumerator {
+	enumerator := &fakeDriveEnumerator{
+		volumes: make(map[string]Volume),
+	}
+	for _, volume := range volumes {
+		enumerator.Attach(volume)
+	}
+
+	return enumerator
+}
+
+// ボリュームの接続を再現
+func (f *fakeDriveEnumerator) Attach(volume Volume) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if _, exists := f.volumes[volume.ID]; !exists {
+		f.order = append(f.order, volume.ID)
+	}
+	f.volumes[volume.ID] = volume
+}
+
+// ボリュームの切断を再現
+func (f *fakeDriveEnumerator) Detach(id string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	delete(f.volumes, id)
+	for i, existing := range f.order {
+		if existing == id {
+			f.order = append(f.order[:i], f.order[i+1:]...)
+			break
+		}
+	}
+}
+
+// 列挙時に返すエラーを設定（nilで解除）
+func (f *fakeDriveEnumerator) SetError(err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.err = err
+}
+
+// 接続順にボリュームを取得
+func (f *fakeDriveEnumerator) Volumes() ([]Volume, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.err != nil {
+		return nil, f.err
+	}
+
+	volumes := make([]Volume, 0, len(f.order))
+	for _, id := range f.order {
+		volumes = append(volumes, f.volumes[id])
+	}
+
+	return volumes, nil
+}
+
+// テスト用のMonitorを初期化（終了時に監視のゴルーチンを停止）
+func newTestMonitor(t *testing.T, monitorConfig *MonitorConfig, enumerator DriveEnumerator) (*Monitor, context.Context) {
+	t.Helper()
+
+	if monitorConfig == nil {
+		var err error
+		if monitorConfig, err = NewMonitorConfig(config.Config{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	monitor := NewMonitor(monitorConfig, enumerator, &userinfo.UserInfo{UserName: "alice", HostName: "workstation"}, testutil.DiscardSink{})
+	if err := monitor.Initialize(); err != nil {
+		t.Fatalf("Initialize: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(func() {
+		cancel()
+		monitor.wg.Wait()
+	})
+
+	return monitor, ctx
+}
+
+// 記録されたイベントの種類を順に取得
+func eventTypes(monitor *Monitor) []string {
+	var types []string
+	for _, event := range monitor.GetEvents() {
+		types = append(types, event.Type)
+	}
+
+	return types
+}
@@ -0,0 +1,137 @@
+package usb
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// 既定で除外するOSのメタデータファイルと一時ファイル
+var defaultExcludePatterns = []string{
+	"~$*",       // Officeの所有者ファイル
+	".~lock.*#", // LibreOfficeのロックファイル
+	".DS_Store", // macOSのフォルダ設定
+	"._*",       // macOSのリソースフォーク
+	".Trashes",  // macOSのゴミ箱
+	".Spotlight-V100",
+	".fseventsd",
+	".TemporaryItems",
+	"Thumbs.db",   // Windowsのサムネイルキャッシュ
+	"desktop.ini", // Windowsのフォルダ設定
+	"System Volume Information",
+	"$RECYCLE.BIN",
+}
+
+// ファイル操作イベントの対象とするパスを絞り込む構造体
+type PathFilter struct {
+	includePatterns   []string
+	excludePatterns   []string
+	includeExtensions map[string]bool
+	excludeExtensions map[string]bool
+}
+
+// 設定から新しいPathFilterを作成
+func NewPathFilter(config MonitorConfig) (*PathFilter, error) {
+	excludes := config.ExcludePatterns
+	if config.DefaultExcludes {
+		excludes = append(append([]string{}, defaultExcludePatterns...), excludes...)
+	}
+
+	includePatterns, err := normalizePatterns(config.IncludePatterns)
+	if err != nil {
+		return nil, err
+	}
+	excludePatterns, err := normalizePatterns(excludes)
+	if err != nil {
+		return nil, err
+	}
+
+	return &PathFilter{
+		includePatterns:   includePatterns,
+		excludePatterns:   excludePatterns,
+		includeExtensions: normalizeExtensions(config.IncludeExtensions),
+		excludeExtensions: normalizeExtensions(config.ExcludeExtensions),
+	}, nil
+}
+
+// パターンを小文字にして検証
+func normalizePatterns(patterns []string) ([]string, error) {
+	normalized := make([]string, 0, len(patterns))
+	for _, pattern := range patterns {
+		pattern = strings.ToLower(filepath.ToSlash(pattern))
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid pattern %q: %w", pattern, err)
+		}
+		normalized = append(normalized, pattern)
+	}
+
+	return normalized, nil
+}
+
+// 拡張子を先頭のドット付きの小文字にそろえる
+func normalizeExtensions(extensions []string) map[string]bool {
+	normalized := make(map[string]bool, len(extensions))
+	for _, extension := range extensions {
+		extension = strings.ToLower(strings.TrimSpace(extension))
+		if extension == "" {
+			continue
+		}
+		if !strings.HasPrefix(extension, ".") {
+			extension = "." + extension
+		}
+		normalized[extension] = true
+	}
+
+	return normalized
+}
+
+// ファイル操作イベントの対象とするかどうかを確認（パターンはドライブのルートからの相対パスか各要素に一致、大文字と小文字を区別しない）
+func (f *PathFilter) Allows(root string, filePath string) bool {
+	if f == nil {
+		return true
+	}
+
+	relPath, err := filepath.Rel(root, filePath)
+	if err != nil || relPath == ".." || strings.HasPrefix(relPath, ".."+string(os.PathSeparator)) {
+		relPath = filePath
+	}
+	relPath = strings.ToLower(filepath.ToSlash(relPath))
+	elements := strings.Split(relPath, "/")
+	baseName := elements[len(elements)-1]
+	extension := path.Ext(baseName)
+
+	// 除外はパスのいずれかの要素（ディレクトリを含む）に一致すれば対象外
+	if f.excludeExtensions[extension] {
+		return false
+	}
+	for _, pattern := range f.excludePatterns {
+		if matched, _ := path.Match(pattern, relPath); matched {
+			return false
+		}
+		for _, element := range elements {
+			if matched, _ := path.Match(pattern, element); matched {
+				return false
+			}
+		}
+	}
+
+	// 対象の指定がある場合はファイル名か相対パスが一致するもののみ
+	if len(f.includeExtensions) > 0 && !f.includeExtensions[extension] {
+		return false
+	}
+	if len(f.includePatterns) > 0 {
+		for _, pattern := range f.includePatterns {
+			if matched, _ := path.Match(pattern, baseName); matched {
+				return true
+			}
+			if matched, _ := path.Match(pattern, relPath); matched {
+				return true
+			}
+		}
+		return false
+	}
+
+	return true
+}
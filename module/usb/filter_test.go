@@ -0,0 +1,83 @@
+package usb
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestPathFilterAllows(t *testing.T) {
+	root := filepath.Join(string(filepath.Separator), "media", "usb")
+
+	tests := []struct {
+		name   string
+		config MonitorConfig
+		path   string
+		want   bool
+	}{
+		// 既定の除外
+		{"office owner file", MonitorConfig{DefaultExcludes: true}, "~$report.docx", false},
+		{"libreoffice lock", MonitorConfig{DefaultExcludes: true}, ".~lock.report.odt#", false},
+		{"resource fork", MonitorConfig{DefaultExcludes: true}, "docs/._report.pdf", false},
+		{"thumbnail cache ignores case", MonitorConfig{DefaultExcludes: true}, "photos/THUMBS.DB", false},
+		{"inside excluded directory", MonitorConfig{DefaultExcludes: true}, "System Volume Information/IndexerVolumeGuid", false},
+		{"regular file", MonitorConfig{DefaultExcludes: true}, "docs/report.pdf", true},
+		{"default excludes disabled", MonitorConfig{}, ".DS_Store", true},
+
+		// グロブ
+		{"exclude by element", MonitorConfig{ExcludePatterns: []string{"tmp"}}, "build/tmp/out.bin", false},
+		{"exclude by relative path", MonitorConfig{ExcludePatterns: []string{"backup/*.zip"}}, "backup/old.zip", false},
+		{"relative exclude only at the root", MonitorConfig{ExcludePatterns: []string{"backup/*.zip"}}, "docs/backup/old.zip", true},
+		{"include by file name", MonitorConfig{IncludePatterns: []string{"*.pdf"}}, "deep/dir/Report.PDF", true},
+		{"include by relative path", MonitorConfig{IncludePatterns: []string{"export/*"}}, "export/data.csv", true},
+		{"not included", MonitorConfig{IncludePatterns: []string{"*.pdf", "export/*"}}, "docs/data.csv", false},
+		{"exclude wins over include", MonitorConfig{IncludePatterns: []string{"*.pdf"}, ExcludePatterns: []string{"drafts"}}, "drafts/report.pdf", false},
+
+		// 拡張子
+		{"include extension without dot", MonitorConfig{IncludeExtensions: []string{"DOCX", " .xlsx "}}, "a/b.docx", true},
+		{"include extension with dot", MonitorConfig{IncludeExtensions: []string{"DOCX", " .xlsx "}}, "ledger.XLSX", true},
+		{"extension not included", MonitorConfig{IncludeExtensions: []string{"docx"}}, "notes.txt", false},
+		{"no extension not included", MonitorConfig{IncludeExtensions: []string{"docx"}}, "README", false},
+		{"exclude extension", MonitorConfig{ExcludeExtensions: []string{"tmp"}}, "cache.TMP", false},
+		{"exclude extension wins over include", MonitorConfig{IncludeExtensions: []string{"tmp"}, ExcludeExtensions: []string{".tmp"}}, "cache.tmp", false},
+		{"exclude extension wins over include pattern", MonitorConfig{IncludePatterns: []string{"cache.*"}, ExcludeExtensions: []string{"tmp"}}, "cache.tmp", false},
+		{"extension and pattern both required", MonitorConfig{IncludePatterns: []string{"export/*"}, IncludeExtensions: []string{"csv"}}, "export/data.json", false},
+	}
+
+	for _, tt := range tests {
+		filter, err := NewPathFilter(tt.config)
+		if err != nil {
+			t.Fatalf("%s: NewPathFilter: %v", tt.name, err)
+		}
+
+		if got := filter.Allows(root, filepath.Join(root, filepath.FromSlash(tt.path))); got != tt.want {
+			t.Errorf("%s: Allows(%s) = %v, want %v", tt.name, tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestPathFilterOutsideRoot(t *testing.T) {
+	filter, err := NewPathFilter(MonitorConfig{ExcludePatterns: []string{"secret"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// ルートの外のパスはパス全体で判定
+	root := filepath.Join(string(filepath.Separator), "media", "usb")
+	if filter.Allows(root, filepath.Join(string(filepath.Separator), "home", "secret", "a.txt")) {
+		t.Error("path outside the root was not matched element by element")
+	}
+
+	var nilFilter *PathFilter
+	if !nilFilter.Allows(root, filepath.Join(root, "a.txt")) {
+		t.Error("nil filter rejected a path")
+	}
+}
+
+func TestNewPathFilterInvalidPattern(t *testing.T) {
+	if _, err := NewPathFilter(MonitorConfig{ExcludePatterns: []string{"[a-"}}); err == nil {
+		t.Error("NewPathFilter accepted an invalid exclude pattern")
+	}
+	if _, err := NewPathFilter(MonitorConfig{IncludePatterns: []string{"[a-"}}); err == nil {
+		t.Error("NewPathFilter accepted an invalid include pattern")
+	}
+}
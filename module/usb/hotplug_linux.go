@@ -0,0 +1,77 @@
+package usb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	"golang.org/x/sys/unix"
+
+	"github.com/mniyk/endpoint-security-and-monitoring-tools/module"
+)
+
+const (
+	HOTPLUG_SUPPORTED      = true
+	UEVENT_BUFFER_SIZE     = 64 * 1024
+	UEVENT_KERNEL_GROUP    = 1
+	UEVENT_RECEIVE_TIMEOUT = 1 // 秒（コンテキストのキャンセルを確認する間隔）
+)
+
+// カーネルのデバイスイベントを受信するチャネルを作成
+func (m *Monitor) openHotplugEvents(ctx context.Context) (<-chan Uevent, error) {
+	fd, err := unix.Socket(unix.AF_NETLINK, unix.SOCK_DGRAM|unix.SOCK_CLOEXEC, unix.NETLINK_KOBJECT_UEVENT)
+	if err != nil {
+		return nil, fmt.Errorf("failed opening uevent socket: %w", err)
+	}
+
+	address := &unix.SockaddrNetlink{
+		Family: unix.AF_NETLINK,
+		Pid:    0,
+		Groups: UEVENT_KERNEL_GROUP,
+	}
+	if err := unix.Bind(fd, address); err != nil {
+		unix.Close(fd)
+		return nil, fmt.Errorf("failed binding uevent socket: %w", err)
+	}
+
+	// 受信を定期的に中断してキャンセルを確認
+	timeout := unix.Timeval{Sec: UEVENT_RECEIVE_TIMEOUT}
+	if err := unix.SetsockoptTimeval(fd, unix.SOL_SOCKET, unix.SO_RCVTIMEO, &timeout); err != nil {
+		unix.Close(fd)
+		return nil, fmt.Errorf("failed setting uevent socket timeout: %w", err)
+	}
+
+	events := make(chan Uevent, 16)
+
+	module.Go(ctx, &m.wg, func() {
+		defer close(events)
+		defer unix.Close(fd)
+
+		buffer := make([]byte, UEVENT_BUFFER_SIZE)
+		for ctx.Err() == nil {
+			n, _, err := unix.Recvfrom(fd, buffer, 0)
+			if err != nil {
+				if errors.Is(err, unix.EAGAIN) || errors.Is(err, unix.EINTR) {
+					continue
+				}
+				log.Printf("[%s] Failed receiving uevent: %v\n", MODULE_NAME, err)
+				m.health.RecordError(err)
+				return
+			}
+
+			event, err := ParseUevent(buffer[:n])
+			if err != nil {
+				continue
+			}
+
+			select {
+			case events <- event:
+			case <-ctx.Done():
+				return
+			}
+		}
+	})
+
+	return events, nil
+}
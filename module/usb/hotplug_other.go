@@ -0,0 +1,16 @@
+//go:build !linux
+
+package usb
+
+import (
+	"context"
+	"errors"
+)
+
+// ホットプラグ通知に対応していないためデフォルトで無効（明示的に有効にした場合のみ利用できない旨を記録）
+const HOTPLUG_SUPPORTED = false
+
+// 対応していないプラットフォームではポーリングで検出
+func (m *Monitor) openHotplugEvents(ctx context.Context) (<-chan Uevent, error) {
+	return nil, errors.New("hotplug events are not supported on this platform")
+}
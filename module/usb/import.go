@@ -0,0 +1,160 @@
+package usb
+
+import (
+	"context"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/mniyk/endpoint-security-and-monitoring-tools/internal/filewatch"
+	"github.com/mniyk/endpoint-security-and-monitoring-tools/internal/inspection"
+	"github.com/mniyk/endpoint-security-and-monitoring-tools/internal/procinfo"
+)
+
+const (
+	FILE_IMPORTED_SEVERITY = 5
+	IMPORT_READ_WINDOW     = 10 * time.Minute // 読み込みと持ち込みを関連付ける期間
+	MAX_TRACKED_READS      = 10000
+	IMPORT_WATCH_ID        = "import" // 稼働状況の集計でのホストのディレクトリの監視の識別子
+)
+
+// リムーバブルドライブのファイルを読み込んだプロセスの記録の構造体
+type readRecord struct {
+	process procinfo.Process
+	at      time.Time
+}
+
+// リムーバブルドライブからの最近の読み込みを保持する構造体
+type readTracker struct {
+	mu    sync.Mutex
+	reads map[string]readRecord
+}
+
+// 新しいreadTrackerを作成
+func newReadTracker() *readTracker {
+	return &readTracker{reads: make(map[string]readRecord)}
+}
+
+// 読み込みを記録（上限を超えた場合は古い記録を削除）
+func (t *readTracker) Record(path string, process procinfo.Process) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := time.Now()
+	if len(t.reads) >= MAX_TRACKED_READS {
+		for readPath, record := range t.reads {
+			if now.Sub(record.at) > IMPORT_READ_WINDOW {
+				delete(t.reads, readPath)
+			}
+		}
+	}
+	if len(t.reads) < MAX_TRACKED_READS {
+		t.reads[path] = readRecord{process: process, at: now}
+	}
+}
+
+// 最近の読み込みを検索
+func (t *readTracker) Lookup(path string) (procinfo.Process, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	record, ok := t.reads[path]
+	if !ok || time.Since(record.at) > IMPORT_READ_WINDOW {
+		return procinfo.Process{}, false
+	}
+
+	return record.process, true
+}
+
+// 持ち込みの検出が有効かどうかを確認
+func (m *Monitor) importEnabled() bool {
+	return len(m.config.ImportDirectories) > 0
+}
+
+// ホストのディレクトリに作成されたファイルを監視
+func (m *Monitor) watchImports(ctx context.Context) {
+	watcher := m.startWatch(IMPORT_WATCH_ID)
+	defer m.stopWatch(IMPORT_WATCH_ID)
+
+	hooks := filewatch.Hooks{Coverage: m.updateWatchCoverage}
+	err := watcher.Run(ctx, &m.wg, m.config.ImportDirectories, hooks, func(change filewatch.Change) {
+		// 書き込みが落ち着いたファイルと名前変更で置かれたファイルのみ照合
+		if change.Path == "" || change.Operation == filewatch.OPERATION_DELETE || change.Interrupted {
+			return
+		}
+
+		// ワーカーが空いていない場合は照合しない（監視を止めないため）
+		filePath := change.Path
+		skipped := func() {
+			log.Printf("[%s] Skipped import check of %s: module stopped\n", MODULE_NAME, filePath)
+		}
+		if !m.inspector.Run(func() { m.checkImport(filePath) }, skipped) {
+			log.Printf("[%s] Skipped import check of %s: workers busy\n", MODULE_NAME, filePath)
+		}
+	})
+	if err != nil {
+		log.Printf("[%s] Failed creating import watcher: %v\n", MODULE_NAME, err)
+		m.health.RecordError(err)
+	}
+}
+
+// ホストのファイルと同じ内容のファイルが接続中のリムーバブルドライブにあれば記録
+func (m *Monitor) checkImport(hostPath string) {
+	info, err := os.Stat(hostPath)
+	if err != nil || info.IsDir() || info.Size() == 0 {
+		return
+	}
+
+	// 同じサイズのファイルがある場合のみハッシュを計算
+	type candidate struct {
+		drive     Volume
+		inventory *Inventory
+		path      string
+	}
+	var candidates []candidate
+
+	m.drivesMu.RLock()
+	for driveID, inventory := range m.inventories {
+		for _, path := range inventory.FilesWithSize(info.Size()) {
+			candidates = append(candidates, candidate{drive: m.connectedDrives[driveID], inventory: inventory, path: path})
+		}
+	}
+	m.drivesMu.RUnlock()
+
+	if len(candidates) == 0 {
+		return
+	}
+
+	hasher := inspection.NewHasher(MODULE_NAME, []string{"sha256"}, 0)
+	status, hashes := hasher.HashFile(hostPath)
+	if status != inspection.HASH_STATUS_HASHED {
+		return
+	}
+
+	for _, c := range candidates {
+		hash, err := c.inventory.Hash(c.path, hasher)
+		if err != nil || hash != hashes["sha256"] {
+			continue
+		}
+
+		log.Printf("[%s] Imported %s from drive(%s) %s\n", MODULE_NAME, hostPath, c.drive.ID, c.path)
+
+		data := m.driveEventData(c.drive)
+		data["path"] = hostPath
+		data["filename"] = filepath.Base(hostPath)
+		data["size"] = info.Size()
+		data["sha256"] = hash
+		data["source_path"] = c.path
+		data["timestamp"] = time.Now().Format(time.RFC3339)
+
+		// リムーバブルドライブから読み込んだプロセスを追加
+		if process, ok := m.reads.Lookup(c.path); ok {
+			process.AddTo(data, "reader_")
+		}
+
+		m.addEvent("file_imported", m.applyPolicy(c.drive.Device, FILE_IMPORTED_SEVERITY, data), data)
+		return
+	}
+}
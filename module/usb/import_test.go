@@ -0,0 +1,61 @@
+package usb
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mniyk/endpoint-security-and-monitoring-tools/internal/procinfo"
+)
+
+func TestCheckImport(t *testing.T) {
+	driveRoot := t.TempDir()
+	hostRoot := t.TempDir()
+
+	monitor, _ := newTestMonitor(t, nil, newFakeDriveEnumerator())
+	drive := Volume{ID: "sdb1", MountPath: driveRoot, Removable: true}
+
+	// 同じサイズで内容が異なるファイルと、内容が一致するファイル
+	inventory := NewInventory()
+	decoy := filepath.Join(driveRoot, "decoy.txt")
+	source := filepath.Join(driveRoot, "plans", "budget.xlsx")
+	addInventoryFile(t, inventory, decoy, "AAAAAAAA")
+	addInventoryFile(t, inventory, source, "BBBBBBBB")
+	addInventoryFile(t, inventory, filepath.Join(driveRoot, "other.bin"), "BBBB")
+
+	monitor.drivesMu.Lock()
+	monitor.connectedDrives[drive.ID] = drive
+	monitor.inventories[drive.ID] = inventory
+	monitor.drivesMu.Unlock()
+
+	monitor.reads.Record(source, procinfo.Process{PID: 4242, Executable: "/usr/bin/cp"})
+
+	imported := filepath.Join(hostRoot, "budget.xlsx")
+	if err := os.WriteFile(imported, []byte("BBBBBBBB"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	unrelated := filepath.Join(hostRoot, "notes.txt")
+	if err := os.WriteFile(unrelated, []byte("CCCCCCCC"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	monitor.checkImport(unrelated)
+	monitor.checkImport(imported)
+
+	events := monitor.GetEvents()
+	if len(events) != 1 || events[0].Type != "file_imported" {
+		t.Fatalf("got events %v, want one file_imported", eventTypes(monitor))
+	}
+
+	data := events[0].Data
+	if data["path"] != imported || data["source_path"] != source || data["size"] != int64(8) {
+		t.Errorf("unexpected import %v", data)
+	}
+	if data["reader_pid"] != 4242 || data["reader_executable"] != "/usr/bin/cp" {
+		t.Errorf("reader not attributed: %v", data)
+	}
+	if hash := fmt.Sprint(data["sha256"]); len(hash) != 64 {
+		t.Errorf("sha256 = %q", hash)
+	}
+}
@@ -0,0 +1,261 @@
+package usb
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mniyk/endpoint-security-and-monitoring-tools/internal/inspection"
+)
+
+const (
+	DEFAULT_INVENTORY_MANIFEST_LIMIT = 1000
+	NO_EXTENSION                     = "(none)"
+)
+
+// ドライブ内のファイルの情報の構造体
+type InventoryEntry struct {
+	Path    string
+	Size    int64
+	ModTime time.Time
+	SHA256  string // 持ち込みの照合で計算したハッシュ（未計算の場合は空）
+}
+
+// ドライブ内のファイルの一覧の構造体（接続時の一覧と、ファイル操作で更新する現在の一覧）
+type Inventory struct {
+	mu       sync.Mutex
+	baseline map[string]InventoryEntry
+	current  map[string]InventoryEntry
+}
+
+// 新しいInventoryを作成
+func NewInventory() *Inventory {
+	return &Inventory{
+		baseline: make(map[string]InventoryEntry),
+		current:  make(map[string]InventoryEntry),
+	}
+}
+
+// ファイルを一覧に追加または更新（存在しない場合は削除）
+func (i *Inventory) Update(path string) {
+	if i == nil {
+		return
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		i.Remove(path)
+		return
+	}
+
+	i.Add(path, info)
+}
+
+// 取得済みのファイル情報を一覧に追加
+func (i *Inventory) Add(path string, info os.FileInfo) {
+	if i == nil || info.IsDir() {
+		return
+	}
+
+	i.mu.Lock()
+	i.current[path] = InventoryEntry{Path: path, Size: info.Size(), ModTime: info.ModTime()}
+	i.mu.Unlock()
+}
+
+// ファイルまたはディレクトリ配下のファイルを一覧から削除
+func (i *Inventory) Remove(path string) {
+	if i == nil {
+		return
+	}
+
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	prefix := path + string(os.PathSeparator)
+	for entryPath := range i.current {
+		if entryPath == path || strings.HasPrefix(entryPath, prefix) {
+			delete(i.current, entryPath)
+		}
+	}
+}
+
+// ファイルまたはディレクトリ配下のファイルのパスを変更
+func (i *Inventory) Move(oldPath string, newPath string) {
+	if i == nil {
+		return
+	}
+
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	prefix := oldPath + string(os.PathSeparator)
+	for entryPath, entry := range i.current {
+		if entryPath != oldPath && !strings.HasPrefix(entryPath, prefix) {
+			continue
+		}
+
+		delete(i.current, entryPath)
+		if newPath != "" {
+			entry.Path = newPath + strings.TrimPrefix(entryPath, oldPath)
+			i.current[entry.Path] = entry
+		}
+	}
+}
+
+// 指定されたサイズのファイルのパスを取得
+func (i *Inventory) FilesWithSize(size int64) []string {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	var paths []string
+	for path, entry := range i.current {
+		if entry.Size == size {
+			paths = append(paths, path)
+		}
+	}
+	sort.Strings(paths)
+
+	return paths
+}
+
+// ファイルのSHA-256を取得（変更されていなければ計算済みの値を使用）
+func (i *Inventory) Hash(path string, hasher *inspection.Hasher) (string, error) {
+	i.mu.Lock()
+	entry, ok := i.current[path]
+	i.mu.Unlock()
+
+	if ok && entry.SHA256 != "" {
+		return entry.SHA256, nil
+	}
+
+	// 計算した内容の時点の状態を取得
+	before, err := os.Stat(path)
+	if err != nil {
+		return "", err
+	}
+
+	status, hashes := hasher.HashFile(path)
+	if status != inspection.HASH_STATUS_HASHED {
+		return "", fmt.Errorf("failed hashing %s: %s", path, status)
+	}
+
+	// 計算した後の一覧の項目が計算前のファイルと一致する場合のみ記録
+	i.mu.Lock()
+	if current, exists := i.current[path]; exists && current.Size == before.Size() && current.ModTime.Equal(before.ModTime()) {
+		current.SHA256 = hashes["sha256"]
+		i.current[path] = current
+	}
+	i.mu.Unlock()
+
+	return hashes["sha256"], nil
+}
+
+// 現在の一覧を接続時の一覧として記録
+func (i *Inventory) MarkBaseline() {
+	if i == nil {
+		return
+	}
+
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	i.baseline = make(map[string]InventoryEntry, len(i.current))
+	for path, entry := range i.current {
+		i.baseline[path] = entry
+	}
+}
+
+// 接続時の一覧の集計をイベントデータに追加
+func (i *Inventory) addSummaryTo(data map[string]interface{}, limit int) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	var totalBytes int64
+	extensions := make(map[string]int)
+	entries := make([]InventoryEntry, 0, len(i.baseline))
+	for _, entry := range i.baseline {
+		totalBytes += entry.Size
+		extensions[extensionOf(entry.Path)]++
+		entries = append(entries, entry)
+	}
+
+	manifest, truncated := manifestOf(entries, limit)
+
+	data["file_count"] = len(i.baseline)
+	data["total_bytes"] = totalBytes
+	data["extensions"] = extensions
+	data["manifest"] = manifest
+	data["manifest_truncated"] = truncated
+}
+
+// 接続時の一覧と現在の一覧の差分をイベントデータに追加
+func (i *Inventory) addDiffTo(data map[string]interface{}, limit int) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	var added, changed, removed []InventoryEntry
+	for path, entry := range i.current {
+		before, exists := i.baseline[path]
+		switch {
+		case !exists:
+			added = append(added, entry)
+		case before.Size != entry.Size || !before.ModTime.Equal(entry.ModTime):
+			changed = append(changed, entry)
+		}
+	}
+	for path, entry := range i.baseline {
+		if _, exists := i.current[path]; !exists {
+			removed = append(removed, entry)
+		}
+	}
+
+	addedManifest, addedTruncated := manifestOf(added, limit)
+	changedManifest, changedTruncated := manifestOf(changed, limit)
+	removedManifest, removedTruncated := manifestOf(removed, limit)
+
+	data["added_count"] = len(added)
+	data["changed_count"] = len(changed)
+	data["removed_count"] = len(removed)
+	data["added"] = addedManifest
+	data["changed"] = changedManifest
+	data["removed"] = removedManifest
+	data["manifest_truncated"] = addedTruncated || changedTruncated || removedTruncated
+}
+
+// パス順に並べた上限件数までのファイルの一覧を作成
+func manifestOf(entries []InventoryEntry, limit int) ([]map[string]interface{}, bool) {
+	sort.Slice(entries, func(a, b int) bool {
+		return entries[a].Path < entries[b].Path
+	})
+
+	truncated := false
+	if limit > 0 && len(entries) > limit {
+		entries = entries[:limit]
+		truncated = true
+	}
+
+	manifest := make([]map[string]interface{}, 0, len(entries))
+	for _, entry := range entries {
+		manifest = append(manifest, map[string]interface{}{
+			"path":     entry.Path,
+			"size":     entry.Size,
+			"modified": entry.ModTime.Format(time.RFC3339),
+		})
+	}
+
+	return manifest, truncated
+}
+
+// 集計用の拡張子を取得（小文字、拡張子がない場合は "(none)"）
+func extensionOf(path string) string {
+	extension := strings.ToLower(filepath.Ext(path))
+	if extension == "" {
+		return NO_EXTENSION
+	}
+
+	return extension
+}
@@ -0,0 +1,148 @@
+package usb
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/mniyk/endpoint-security-and-monitoring-tools/internal/inspection"
+)
+
+// ファイルを作成して一覧に追加
+func addInventoryFile(t *testing.T, inventory *Inventory, path string, content string) {
+	t.Helper()
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	inventory.Update(path)
+}
+
+// マニフェストのパスを取得
+func manifestPaths(manifest interface{}) []string {
+	var paths []string
+	for _, entry := range manifest.([]map[string]interface{}) {
+		paths = append(paths, entry["path"].(string))
+	}
+
+	return paths
+}
+
+func TestInventorySummary(t *testing.T) {
+	root := t.TempDir()
+	inventory := NewInventory()
+	addInventoryFile(t, inventory, filepath.Join(root, "b.PDF"), "12345")
+	addInventoryFile(t, inventory, filepath.Join(root, "docs", "a.pdf"), "123")
+	addInventoryFile(t, inventory, filepath.Join(root, "docs", "README"), "1")
+	inventory.MarkBaseline()
+
+	// 接続後の変更は接続時の集計に含めない
+	addInventoryFile(t, inventory, filepath.Join(root, "later.txt"), "later")
+
+	data := make(map[string]interface{})
+	inventory.addSummaryTo(data, 2)
+
+	if data["file_count"] != 3 || data["total_bytes"] != int64(9) {
+		t.Errorf("file_count %v total_bytes %v, want 3 and 9", data["file_count"], data["total_bytes"])
+	}
+	if want := map[string]int{".pdf": 2, NO_EXTENSION: 1}; !reflect.DeepEqual(data["extensions"], want) {
+		t.Errorf("extensions = %v, want %v", data["extensions"], want)
+	}
+
+	// パス順で上限件数まで
+	want := []string{filepath.Join(root, "b.PDF"), filepath.Join(root, "docs", "README")}
+	if paths := manifestPaths(data["manifest"]); !reflect.DeepEqual(paths, want) || data["manifest_truncated"] != true {
+		t.Errorf("manifest %v truncated %v, want %v truncated", paths, data["manifest_truncated"], want)
+	}
+
+	inventory.addSummaryTo(data, 0)
+	if paths := manifestPaths(data["manifest"]); len(paths) != 3 || data["manifest_truncated"] != false {
+		t.Errorf("unlimited manifest %v truncated %v", paths, data["manifest_truncated"])
+	}
+}
+
+func TestInventoryDiff(t *testing.T) {
+	root := t.TempDir()
+	inventory := NewInventory()
+	for _, name := range []string{"keep.txt", "edit.txt", "gone.txt", "old/a.txt", "old/b.txt"} {
+		addInventoryFile(t, inventory, filepath.Join(root, name), name)
+	}
+	inventory.MarkBaseline()
+
+	// 変更（サイズと更新日時）、削除、ディレクトリの移動、追加
+	edited := filepath.Join(root, "edit.txt")
+	addInventoryFile(t, inventory, edited, "edited content")
+	if err := os.Chtimes(edited, time.Now(), time.Now().Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	inventory.Update(edited)
+	inventory.Remove(filepath.Join(root, "gone.txt"))
+	inventory.Move(filepath.Join(root, "old"), filepath.Join(root, "new"))
+	addInventoryFile(t, inventory, filepath.Join(root, "added.txt"), "added")
+	// 監視対象の外への移動は削除として扱う
+	inventory.Move(filepath.Join(root, "keep.txt"), "")
+
+	data := make(map[string]interface{})
+	inventory.addDiffTo(data, 0)
+
+	tests := []struct {
+		key  string
+		want []string
+	}{
+		{"added", []string{filepath.Join(root, "added.txt"), filepath.Join(root, "new", "a.txt"), filepath.Join(root, "new", "b.txt")}},
+		{"changed", []string{edited}},
+		{"removed", []string{filepath.Join(root, "gone.txt"), filepath.Join(root, "keep.txt"), filepath.Join(root, "old", "a.txt"), filepath.Join(root, "old", "b.txt")}},
+	}
+	for _, tt := range tests {
+		if paths := manifestPaths(data[tt.key]); !reflect.DeepEqual(paths, tt.want) {
+			t.Errorf("%s = %v, want %v", tt.key, paths, tt.want)
+		}
+		if count := data[tt.key+"_count"]; count != len(tt.want) {
+			t.Errorf("%s_count = %v, want %d", tt.key, count, len(tt.want))
+		}
+	}
+	if data["manifest_truncated"] != false {
+		t.Error("unlimited diff reported as truncated")
+	}
+
+	// 件数は上限に関係なく全体を数える
+	inventory.addDiffTo(data, 1)
+	if data["manifest_truncated"] != true || data["removed_count"] != 4 || len(manifestPaths(data["removed"])) != 1 {
+		t.Errorf("limited diff: truncated %v removed_count %v removed %v", data["manifest_truncated"], data["removed_count"], data["removed"])
+	}
+}
+
+func TestInventoryHashCache(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "report.txt")
+	inventory := NewInventory()
+	addInventoryFile(t, inventory, path, "first")
+	hasher := inspection.NewHasher(MODULE_NAME, []string{"sha256"}, 0)
+
+	first, err := inventory.Hash(path, hasher)
+	if err != nil {
+		t.Fatalf("Hash: %v", err)
+	}
+
+	// 一覧を更新しない限り計算済みの値を使用
+	if err := os.WriteFile(path, []byte("second"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if cached, err := inventory.Hash(path, hasher); err != nil || cached != first {
+		t.Fatalf("Hash after write without update = %s, %v, want cached %s", cached, err, first)
+	}
+
+	// 一覧を更新すると計算し直す
+	inventory.Update(path)
+	if rehashed, err := inventory.Hash(path, hasher); err != nil || rehashed == first {
+		t.Fatalf("Hash after update = %s, %v, want a new hash", rehashed, err)
+	}
+
+	if _, err := inventory.Hash(filepath.Join(t.TempDir(), "missing.txt"), hasher); err == nil {
+		t.Fatal("Hash of a missing file succeeded")
+	}
+}
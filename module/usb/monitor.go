@@ -4,426 +4,637 @@ import (
 	"context"
 	"fmt"
 	"log"
-	"os"
-	"path/filepath"
 	"strings"
 	"sync"
-	"syscall"
+	"sync/atomic"
 	"time"
-	"unsafe"
-
-	"github.com/fsnotify/fsnotify"
-	"golang.org/x/sys/windows"
 
 	"github.com/mniyk/endpoint-security-and-monitoring-tools/internal/config"
-	"github.com/mniyk/endpoint-security-and-monitoring-tools/internal/transmission"
+	"github.com/mniyk/endpoint-security-and-monitoring-tools/internal/filewatch"
+	"github.com/mniyk/endpoint-security-and-monitoring-tools/internal/inspection"
 	"github.com/mniyk/endpoint-security-and-monitoring-tools/internal/userinfo"
 	"github.com/mniyk/endpoint-security-and-monitoring-tools/module"
 )
 
 const (
 	MONITOR_INTERVAL            = 5 * time.Second
-	DRIVE_REMOVABLE             = 2
-	MAX_WATCH_DEPTH             = 10
+	HOTPLUG_RESCAN_INTERVAL     = 30 * time.Second // ホットプラグ通知が使える場合の保険のスキャン間隔
+	HOTPLUG_FOLLOWUP_INTERVAL   = 500 * time.Millisecond
+	HOTPLUG_FOLLOWUP_WINDOW     = 10 * time.Second // 通知後に自動マウントを待って再スキャンする期間
 	MODULE_NAME                 = "USB File Transfer Monitoring"
+	FILE_CHANNEL                = "usb" // ファイル操作イベントの持ち出し経路
 	CONNECTED_DRIVE_SEVERITY    = 5
 	DISCONNECTED_DRIVE_SEVERITY = 5
+	DRIVE_INVENTORY_SEVERITY    = 3
 	FILE_OPERATION_SEVERITY     = 5
 )
 
-// Windows API用の関数
-var (
-	kernel32 = windows.NewLazySystemDLL("kernel32.dll")
-
-	procGetLogicalDrives = kernel32.NewProc("GetLogicalDrives")
-	procGetDriveType     = kernel32.NewProc("GetDriveTypeW")
-)
-
-// ファイル操作情報の構造体
-type FileOperation struct {
-	Operation   string
-	FilePath    string
-	FileName    string
-	DriveLetter string
-	UserInfo    *userinfo.UserInfo
-	Timestamp   time.Time
-	FileSize    int64
-}
-
 // USB File Transfer Monitoringの設定の構造体
 type MonitorConfig struct {
+	SysfsRoot  string `json:"sysfs_root"`  // Linuxでsysfsを読み込むルート
+	ProcfsRoot string `json:"procfs_root"` // Linuxでprocfsを読み込むルート
+	DevfsRoot  string `json:"devfs_root"`  // Linuxでデバイスファイルを読み込むルート
+	Hotplug    bool   `json:"hotplug"`     // カーネルのホットプラグ通知で即時に検出
+
+	FileBackend         string `json:"file_backend"`          // "fsnotify" "fanotify" "auto"
+	WatchMaxDepth       int    `json:"watch_max_depth"`       // 監視するディレクトリの深さの上限（0の場合は無制限）
+	WatchMaxDirectories int    `json:"watch_max_directories"` // ドライブごとに監視するディレクトリ数の上限（0の場合は無制限）
+	WatchScanInterval   int    `json:"watch_scan_interval"`   // 監視できない部分木をスキャンする間隔（秒）
+	PolicyFile          string `json:"policy_file"`           // 承認済みデバイスのポリシーファイル（空の場合はポリシーなし）
+
+	IncludePatterns   []string `json:"include_patterns"`   // 対象とするファイル名または相対パスのパターン（空の場合はすべて）
+	ExcludePatterns   []string `json:"exclude_patterns"`   // 除外するファイル名、ディレクトリ名または相対パスのパターン
+	IncludeExtensions []string `json:"include_extensions"` // 対象とする拡張子（空の場合はすべて）
+	ExcludeExtensions []string `json:"exclude_extensions"` // 除外する拡張子
+	DefaultExcludes   bool     `json:"default_excludes"`   // OSのメタデータファイルなどの既定の除外一覧を使用
+
+	Enforcement       string `json:"enforcement"`        // "off" "audit" "block"
+	EnforcementAction string `json:"enforcement_action"` // "deauthorize" "read_only"
+	OverrideFile      string `json:"override_file"`      // 存在する間はブロックを停止する緊急時の解除ファイル
+
+	inspection.Config // 書き込まれたファイルのハッシュ計算、内容検査と元ファイルの照合
+
+	Inventory              bool `json:"inventory"`                // 接続時のファイル一覧と切断時の差分を記録
+	InventoryManifestLimit int  `json:"inventory_manifest_limit"` // イベントに含めるファイル一覧の上限件数
+
+	ImportDirectories  []string `json:"import_directories"`   // リムーバブルドライブからの持ち込みを検出するホストのディレクトリ
+	ImportReadTracking bool     `json:"import_read_tracking"` // Linuxでリムーバブルドライブの読み込みをfanotifyで記録
 }
 
 // 新しいMonitorConfigを作成
-func NewMonitorConfig(moduleConfig config.Config) *MonitorConfig {
-	return &MonitorConfig{}
+func NewMonitorConfig(moduleConfig config.Config) (*MonitorConfig, error) {
+	monitorConfig := &MonitorConfig{
+		SysfsRoot:  moduleConfig.String("sysfs_root", DEFAULT_SYSFS_ROOT),
+		ProcfsRoot: moduleConfig.String("procfs_root", DEFAULT_PROCFS_ROOT),
+		DevfsRoot:  moduleConfig.String("devfs_root", DEFAULT_DEVFS_ROOT),
+		Hotplug:    moduleConfig.Bool("hotplug", HOTPLUG_SUPPORTED),
+
+		FileBackend:         moduleConfig.String("file_backend", FILE_BACKEND_FSNOTIFY),
+		WatchMaxDepth:       moduleConfig.Int("watch_max_depth", DEFAULT_WATCH_MAX_DEPTH),
+		WatchMaxDirectories: moduleConfig.Int("watch_max_directories", DEFAULT_WATCH_MAX_DIRECTORIES),
+		WatchScanInterval:   moduleConfig.Int("watch_scan_interval", DEFAULT_WATCH_SCAN_INTERVAL),
+		PolicyFile:          moduleConfig.String("policy_file", ""),
+
+		IncludePatterns:   moduleConfig.Strings("include_patterns", nil),
+		ExcludePatterns:   moduleConfig.Strings("exclude_patterns", nil),
+		IncludeExtensions: moduleConfig.Strings("include_extensions", nil),
+		ExcludeExtensions: moduleConfig.Strings("exclude_extensions", nil),
+		DefaultExcludes:   moduleConfig.Bool("default_excludes", true),
+
+		Enforcement:       moduleConfig.String("enforcement", ENFORCEMENT_OFF),
+		EnforcementAction: moduleConfig.String("enforcement_action", ENFORCEMENT_DEAUTHORIZE),
+		OverrideFile:      moduleConfig.String("override_file", ""),
+
+		Inventory:              moduleConfig.Bool("inventory", false),
+		InventoryManifestLimit: moduleConfig.Int("inventory_manifest_limit", DEFAULT_INVENTORY_MANIFEST_LIMIT),
+
+		ImportDirectories:  moduleConfig.Strings("import_directories", nil),
+		ImportReadTracking: moduleConfig.Bool("import_read_tracking", true),
+	}
+
+	inspectionConfig, err := inspection.NewConfig(moduleConfig)
+	if err != nil {
+		return nil, err
+	}
+	monitorConfig.Config = inspectionConfig
+
+	return monitorConfig, nil
 }
 
 // 監視のための構造体
 type Monitor struct {
 	events          []module.Event
 	config          MonitorConfig
-	stopChan        chan struct{}
+	cancel          context.CancelFunc
+	lifecycleMu     sync.Mutex
+	wg              sync.WaitGroup
 	eventsMu        sync.RWMutex
-	connectedDrives map[string]bool
+	enumerator      DriveEnumerator
+	drivesMu        sync.RWMutex // 持ち込みの照合と稼働状況の集計から参照するconnectedDrives、inventories、watchesを保護
+	connectedDrives map[string]Volume
 	watchContexts   map[string]context.CancelFunc
+	inventories     map[string]*Inventory
+	watches         map[string]*filewatch.Watcher // ドライブとホストのディレクトリの監視（持ち込みの監視はIMPORT_WATCH_ID）
+	reads           *readTracker
+	filter          *PathFilter
+	filtered        atomic.Int64 // 絞り込みで記録しなかったファイル操作の件数
+	policy          *PolicyStore
+	enforcer        Enforcer
+	enforced        map[string]string // 強制を適用したUSBデバイスまたはボリュームと結果
+	inspector       *inspection.Inspector
 	userInfo        *userinfo.UserInfo
-	eventDispatcher transmission.EventDispatcher
+	eventDispatcher module.EventSink
+	health          *module.HealthTracker
 }
 
 // 新しいMonitorを作成
-func NewMonitor(config *MonitorConfig, userInfo *userinfo.UserInfo, eventDispatcher transmission.EventDispatcher) *Monitor {
+func NewMonitor(config *MonitorConfig, enumerator DriveEnumerator, userInfo *userinfo.UserInfo, eventDispatcher module.EventSink) *Monitor {
 	return &Monitor{
 		config:          *config,
 		events:          make([]module.Event, 0),
-		connectedDrives: make(map[string]bool),
+		enumerator:      enumerator,
+		connectedDrives: make(map[string]Volume),
 		watchContexts:   make(map[string]context.CancelFunc),
+		inventories:     make(map[string]*Inventory),
+		watches:         make(map[string]*filewatch.Watcher),
+		reads:           newReadTracker(),
+		enforcer:        NewEnforcer(config),
+		enforced:        make(map[string]string),
 		userInfo:        userInfo,
 		eventDispatcher: eventDispatcher,
+		health:          module.NewHealthTracker(),
 	}
 }
 
 // モジュールを初期化
 func (m *Monitor) Initialize() error {
-	m.stopChan = make(chan struct{})
+	m.health.SetState(module.StateInitializing)
+	m.connectedDrives = make(map[string]Volume)
+	m.watchContexts = make(map[string]context.CancelFunc)
+	m.inventories = make(map[string]*Inventory)
+	m.watches = make(map[string]*filewatch.Watcher)
+	m.enforced = make(map[string]string)
 	log.Printf("[%s] Initialize...", MODULE_NAME)
+
+	if err := validateFileBackend(m.config.FileBackend); err != nil {
+		return fmt.Errorf("invalid file monitoring settings: %w", err)
+	}
+	if err := validateWatchLimits(m.config); err != nil {
+		return fmt.Errorf("invalid file monitoring settings: %w", err)
+	}
+
+	// ファイル操作イベントの絞り込みを作成
+	filter, err := NewPathFilter(m.config)
+	if err != nil {
+		return fmt.Errorf("invalid file filter settings: %w", err)
+	}
+	m.filter = filter
+
+	// ハッシュ計算、内容検査と元ファイルの照合を作成
+	inspector, err := inspection.NewInspector(MODULE_NAME, m.config.Config, m.health)
+	if err != nil {
+		return err
+	}
+	m.inspector = inspector
+
+	if err := validateEnforcement(m.config); err != nil {
+		return fmt.Errorf("invalid enforcement settings: %w", err)
+	}
+
+	// デバイスのポリシーを読み込み（デバイスを識別できないプラットフォームではすべて未識別となるため使用しない）
+	if m.config.PolicyFile != "" && !DEVICE_IDENTITY_SUPPORTED {
+		log.Printf("[%s] Device policy is not supported on this platform, ignoring %s\n", MODULE_NAME, m.config.PolicyFile)
+	} else if m.config.PolicyFile != "" {
+		policy, err := NewPolicyStore(m.config.PolicyFile)
+		if err != nil {
+			return fmt.Errorf("failed loading device policy: %w", err)
+		}
+		m.policy = policy
+	}
+
 	return nil
 }
 
 // モニタリングを開始
-func (m *Monitor) Start() error {
+func (m *Monitor) Start(ctx context.Context) error {
+	m.lifecycleMu.Lock()
+	defer m.lifecycleMu.Unlock()
+
+	if m.cancel != nil {
+		return fmt.Errorf("%s already started", MODULE_NAME)
+	}
+
 	log.Printf("[%s] Start...", MODULE_NAME)
 
+	ctx, m.cancel = context.WithCancel(ctx)
+	m.health.SetState(module.StateRunning)
+
+	// ハッシュ計算と内容検査のワーカー、元ファイルの索引の更新を開始
+	if m.inspector.Enabled() || m.importEnabled() {
+		m.inspector.Start(ctx, &m.wg)
+	}
+
+	// ホストのディレクトリへの持ち込みを監視
+	if m.importEnabled() {
+		module.Go(ctx, &m.wg, func() {
+			m.watchImports(ctx)
+		})
+	}
+
 	// ドライブ監視を開始
-	go m.startDriveMonitoring()
+	module.Go(ctx, &m.wg, func() {
+		m.startDriveMonitoring(ctx)
+	})
 
 	return nil
 }
 
 // 監視の継続的なループを実行
-func (m *Monitor) startDriveMonitoring() {
-	for {
-		select {
-		case <-m.stopChan:
-			return
-		default:
-			// リムーバブルドライブをスキャン
-			drives := m.getAvailableDrives()
-			currentDrives, updatedConnectedDrives := m.detectConnectedDrives(drives)
-			m.connectedDrives = m.detectDisconnectedDrives(currentDrives, updatedConnectedDrives)
-
-			// 設定された間隔で再スキャン
-			time.Sleep(MONITOR_INTERVAL)
+func (m *Monitor) startDriveMonitoring(ctx context.Context) {
+	interval := MONITOR_INTERVAL
+
+	// ホットプラグ通知を購読（利用できない場合はポーリング）
+	var hotplugEvents <-chan Uevent
+	if m.config.Hotplug {
+		events, err := m.openHotplugEvents(ctx)
+		if err != nil {
+			log.Printf("[%s] Hotplug events unavailable, polling every %s: %v\n", MODULE_NAME, MONITOR_INTERVAL, err)
+		} else {
+			hotplugEvents = events
+			interval = HOTPLUG_RESCAN_INTERVAL
 		}
 	}
-}
+	m.setDetectionMode(hotplugEvents != nil)
 
-// 利用可能なドライブレターを取得
-func (m *Monitor) getAvailableDrives() []string {
-	var drives []string
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
 
-	// GetLogicalDrives Win32 API呼び出し
-	bitmask, _, err := procGetLogicalDrives.Call()
+	followUp := time.NewTimer(HOTPLUG_FOLLOWUP_INTERVAL)
+	followUp.Stop()
+	defer followUp.Stop()
+	var followUpUntil time.Time
 
-	if bitmask == 0 {
-		fmt.Printf("[%s] Failed getting logical drives: %v\n", MODULE_NAME, err)
-		return drives
-	}
+	// 監視者にループを登録
+	heartbeat := module.StartHeartbeat(ctx, "drive_monitoring")
+	defer heartbeat.Stop()
 
-	// 各ビットをチェック (A-Z)
-	for i := 0; i < 26; i++ {
-		if (bitmask & (1 << uint(i))) != 0 {
-			drives = append(drives, string(rune('A'+i)))
+	for {
+		// 監視者へ生存を通知
+		heartbeat.Beat()
+
+		// リムーバブルドライブをスキャン
+		m.scanDrives(ctx)
+
+		// 次のスキャンのきっかけを待機
+	wait:
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				break wait
+			case <-followUp.C:
+				if time.Now().Before(followUpUntil) {
+					followUp.Reset(HOTPLUG_FOLLOWUP_INTERVAL)
+				}
+				break wait
+			case event, ok := <-hotplugEvents:
+				if !ok {
+					// 受信が終了した場合はポーリングに戻す
+					hotplugEvents = nil
+					ticker.Reset(MONITOR_INTERVAL)
+					m.setDetectionMode(false)
+					break wait
+				}
+				// ポリシーファイルが更新されていれば接続中のデバイスも判定し直す
+				if event.AffectsDrives() || (m.policy != nil && m.policy.Changed()) {
+					// 自動マウントが完了するまで短い間隔で再スキャン
+					followUpUntil = time.Now().Add(HOTPLUG_FOLLOWUP_WINDOW)
+					followUp.Reset(HOTPLUG_FOLLOWUP_INTERVAL)
+					break wait
+				}
+			}
 		}
 	}
+}
 
-	return drives
+// ドライブの検出方式を稼働状況に記録
+func (m *Monitor) setDetectionMode(hotplug bool) {
+	if hotplug {
+		m.health.SetDetail("detection", "hotplug")
+	} else {
+		m.health.SetDetail("detection", "polling")
+	}
 }
 
-// 接続されているリムーバブルドライブを検出
-func (m *Monitor) detectConnectedDrives(drives []string) (map[string]bool, map[string]bool) {
-	currentDrives := make(map[string]bool)
-	newConnectedDrives := make(map[string]bool)
+// ボリュームを列挙して接続と切断を検出
+func (m *Monitor) scanDrives(ctx context.Context) {
+	// ポリシーファイルが更新されていれば読み込み直す
+	m.reloadPolicy()
 
-	// 既存の接続を引き継ぐ
-	for k, v := range m.connectedDrives {
-		newConnectedDrives[k] = v
+	volumes, err := m.enumerator.Volumes()
+	if err != nil {
+		log.Printf("[%s] Failed enumerating volumes: %v\n", MODULE_NAME, err)
+		m.health.RecordError(err)
+		return
 	}
 
-	// 各ドライブをチェック
-	for _, driveLetter := range drives {
-		drivePath := fmt.Sprintf("%s:\\", driveLetter)
-
-		// リムーバブルドライブかチェック
-		if m.isRemovableDrive(drivePath) {
-			currentDrives[driveLetter] = true
-
-			// 新しいドライブを検出
-			if _, exists := m.connectedDrives[driveLetter]; !exists {
-				log.Printf("[%s] Connected drive(%s)\n", MODULE_NAME, driveLetter)
-
-				// イベントを生成
-				m.addEvent(
-					"connected_drive",
-					CONNECTED_DRIVE_SEVERITY,
-					map[string]interface{}{
-						"drive": driveLetter,
-						"user":  m.userInfo.UserName,
-						"host":  m.userInfo.HostName,
-					},
-				)
-
-				// 接続を記録
-				newConnectedDrives[driveLetter] = true
-
-				// ファイル監視を開始
-				go m.monitorDriveFiles(driveLetter)
-			}
+	// リムーバブルドライブのみを対象とする
+	var drives []Volume
+	for _, volume := range volumes {
+		if volume.Removable {
+			drives = append(drives, volume)
 		}
 	}
 
-	return currentDrives, newConnectedDrives
-}
-
-// リムーバブルドライブかどうかをチェック
-func (m *Monitor) isRemovableDrive(drivePath string) bool {
-	drivePathUTF16, err := syscall.UTF16PtrFromString(drivePath)
-	if err != nil {
-		fmt.Printf("[%s] Failed converting drive path to UTF16: %v\n", MODULE_NAME, err)
-		return false
+	// 承認されていないデバイスをブロック（マウントされていないデバイスも対象）
+	var blocked map[string]bool
+	if m.enforcementEnabled() {
+		blocked = m.enforceConnected(drives)
 	}
 
-	// GetDriveType Win32 API呼び出し
-	driveType, _, _ := procGetDriveType.Call(uintptr(unsafe.Pointer(drivePathUTF16)))
+	currentDrives, updatedConnectedDrives := m.detectConnectedDrives(ctx, drives, blocked)
+	updatedConnectedDrives = m.detectDisconnectedDrives(currentDrives, updatedConnectedDrives)
 
-	return driveType == DRIVE_REMOVABLE
+	m.drivesMu.Lock()
+	m.connectedDrives = updatedConnectedDrives
+	m.drivesMu.Unlock()
+	m.health.MarkScan()
+	m.health.SetDetail("connected_drives", len(m.connectedDrives))
 }
 
-// ドライブのファイル操作を監視
-func (m *Monitor) monitorDriveFiles(driveLetter string) {
-	// 監視用のキャンセル可能なコンテキストを作成
-	ctx, cancel := context.WithCancel(context.Background())
-	m.watchContexts[driveLetter] = cancel
-
-	drivePath := fmt.Sprintf("%s:\\", driveLetter)
-	log.Printf("[%s] Starting file monitoring for drive(%s)\n", MODULE_NAME, driveLetter)
+// 接続されているリムーバブルドライブを検出
+func (m *Monitor) detectConnectedDrives(ctx context.Context, drives []Volume, blocked map[string]bool) (map[string]bool, map[string]Volume) {
+	currentDrives := make(map[string]bool)
+	newConnectedDrives := make(map[string]Volume)
 
-	// ファイル監視を設定
-	watcher, err := fsnotify.NewWatcher()
-	if err != nil {
-		log.Printf("[%s] Failed creating file watcher: %v\n", MODULE_NAME, err)
-		delete(m.watchContexts, driveLetter)
-		return
+	// 既存の接続を引き継ぐ
+	for k, v := range m.connectedDrives {
+		newConnectedDrives[k] = v
 	}
 
-	// 再帰的にディレクトリ監視を追加 (深さ制限付き)
-	m.addDirectoriesToWatch(watcher, drivePath, MAX_WATCH_DEPTH)
+	// 各ドライブをチェック
+	for _, drive := range drives {
+		currentDrives[drive.ID] = true
 
-	// イベント監視ループを開始
-	go func() {
-		// 関数終了時にリソースをクリーンアップ
-		defer func() {
-			watcher.Close()
-			log.Printf("[%s] Stopped file monitoring for drive(%s)\n", MODULE_NAME, driveLetter)
-		}()
+		// 新しいドライブを検出
+		if _, exists := m.connectedDrives[drive.ID]; !exists {
+			log.Printf("[%s] Connected drive(%s) at %s\n", MODULE_NAME, drive.ID, drive.MountPath)
 
-		m.processFileEvents(ctx, watcher, driveLetter)
-	}()
-}
+			// dm-cryptで判定できなかった場合はヘッダの署名で暗号化を判定
+			if drive.Encryption == "" && drive.DevicePath != "" {
+				drive.Encryption = detectVolumeEncryption(drive.DevicePath)
+			}
 
-// ドライブ内のすべてのディレクトリを再帰的に監視対象に追加
-func (m *Monitor) addDirectoriesToWatch(watcher *fsnotify.Watcher, rootPath string, maxDepth int) {
-	var walkFn filepath.WalkFunc = func(path string, info os.FileInfo, err error) error {
-		if err != nil {
-			// AccessDenied や NotFound などのエラーはスキップ
-			return nil
-		}
+			// イベントを生成
+			data := m.driveEventData(drive)
+			m.addEvent(
+				"connected_drive",
+				m.applyPolicy(drive.Device, CONNECTED_DRIVE_SEVERITY, data),
+				data,
+			)
 
-		if info != nil && info.IsDir() {
-			// 監視深さの制限をチェック
-			if maxDepth > 0 {
-				// 相対パスを取得して深さを計算
-				relPath, err := filepath.Rel(rootPath, path)
-				if err == nil && relPath != "" && relPath != "." {
-					depth := len(strings.Split(relPath, string(os.PathSeparator)))
-					if depth > maxDepth {
-						return filepath.SkipDir
-					}
-				}
+			// 接続を記録
+			newConnectedDrives[drive.ID] = drive
+
+			// ブロックしたドライブは監視しない
+			if blocked[drive.ID] {
+				log.Printf("[%s] Skipping file monitoring for blocked drive(%s)\n", MODULE_NAME, drive.ID)
+				continue
 			}
 
-			// ディレクトリを監視対象に追加
-			err = watcher.Add(path)
-			if err != nil {
-				log.Printf("[%s] Failed watching directory %s: %v\n", MODULE_NAME, path, err)
+			// 監視用のキャンセル可能なコンテキストを作成
+			watchCtx, cancel := context.WithCancel(ctx)
+			m.watchContexts[drive.ID] = cancel
+
+			// 切断時に差分を記録するためのファイル一覧を作成
+			var inventory *Inventory
+			if m.config.Inventory || m.importEnabled() {
+				inventory = NewInventory()
+				m.drivesMu.Lock()
+				m.inventories[drive.ID] = inventory
+				m.drivesMu.Unlock()
 			}
+
+			// ファイル監視を開始
+			module.Go(ctx, &m.wg, func() {
+				m.monitorDriveFiles(watchCtx, drive, inventory)
+			})
 		}
-		return nil
 	}
 
-	err := filepath.Walk(rootPath, walkFn)
-	if err != nil {
-		log.Printf("[%s] Failed walking directory tree: %v\n", MODULE_NAME, err)
-	}
+	return currentDrives, newConnectedDrives
 }
 
-// ファイルシステムイベントを処理するループを実行
-func (m *Monitor) processFileEvents(ctx context.Context, watcher *fsnotify.Watcher, driveLetter string) {
-	for {
-		select {
-		case <-ctx.Done():
-			// コンテキストがキャンセルされた場合は終了
-			return
-
-		case event, ok := <-watcher.Events:
-			if !ok {
+// ドライブのファイル操作を監視
+func (m *Monitor) monitorDriveFiles(ctx context.Context, drive Volume, inventory *Inventory) {
+	log.Printf("[%s] Starting file monitoring for drive(%s)\n", MODULE_NAME, drive.ID)
+	defer log.Printf("[%s] Stopped file monitoring for drive(%s)\n", MODULE_NAME, drive.ID)
+
+	// 再帰的にディレクトリを監視（上限を超えた部分木は定期スキャン）
+	watcher := m.startWatch(drive.ID)
+	defer m.stopWatch(drive.ID)
+
+	hooks := filewatch.Hooks{
+		Visit:  inventory.Add,
+		Update: inventory.Update,
+		Move:   inventory.Move,
+		Remove: inventory.Remove,
+		Allow: func(paths ...string) bool {
+			return m.allowsFile(drive, paths...)
+		},
+		Coverage: m.updateWatchCoverage,
+		// 接続時のファイル一覧を記録
+		Ready: func() {
+			if inventory == nil || !m.config.Inventory {
 				return
 			}
+			inventory.MarkBaseline()
 
-			// CreateとWrite操作のみを処理
-			if event.Op&fsnotify.Create == fsnotify.Create || event.Op&fsnotify.Write == fsnotify.Write {
-				filePath := event.Name
-
-				// ディレクトリの場合は監視対象に追加して、イベント処理はスキップ
-				fileInfo, err := os.Stat(filePath)
-				if err == nil && fileInfo.IsDir() {
-					watcher.Add(filePath)
-					continue
-				}
-
-				// ファイル操作情報を作成
-				fileName := filepath.Base(filePath)
-				operation := FileOperation{
-					Operation:   m.getOperationName(event.Op),
-					FilePath:    filePath,
-					FileName:    fileName,
-					DriveLetter: driveLetter,
-					UserInfo:    m.userInfo,
-					Timestamp:   time.Now(),
-					FileSize:    0,
-				}
-
-				// ファイルサイズを取得
-				if fileInfo, err := os.Stat(filePath); err == nil && !fileInfo.IsDir() {
-					operation.FileSize = fileInfo.Size()
-				}
+			data := m.driveEventData(drive)
+			inventory.addSummaryTo(data, m.config.InventoryManifestLimit)
+			m.addEvent("drive_inventory", DRIVE_INVENTORY_SEVERITY, data)
+		},
+		// リムーバブルドライブからの読み込みと書き込んだプロセスを記録
+		Writes: m.startAccessTracking(ctx, drive),
+	}
 
-				// ファイル操作を記録
-				m.logFileOperation(operation)
-			}
+	err := watcher.Run(ctx, &m.wg, []string{drive.MountPath}, hooks, func(change filewatch.Change) {
+		m.completeFileOperation(drive, change)
+	})
+	if err != nil {
+		log.Printf("[%s] Failed monitoring drive(%s): %v\n", MODULE_NAME, drive.ID, err)
+		m.health.RecordError(err)
+	}
+}
 
-		case err, ok := <-watcher.Errors:
-			if !ok {
-				return
-			}
-			log.Printf("[%s] Failed watcher: %v\n", MODULE_NAME, err)
+// いずれかのパスがファイル操作イベントの対象かどうかを確認（対象外の件数は稼働状況に記録）
+func (m *Monitor) allowsFile(drive Volume, filePaths ...string) bool {
+	for _, filePath := range filePaths {
+		if filePath != "" && m.filter.Allows(drive.MountPath, filePath) {
+			return true
 		}
 	}
+
+	m.health.SetDetail("filtered_file_events", m.filtered.Add(1))
+	return false
 }
 
-// イベント操作名を取得
-func (m *Monitor) getOperationName(op fsnotify.Op) string {
-	switch {
-	case op&fsnotify.Create == fsnotify.Create:
-		return "create"
-	case op&fsnotify.Write == fsnotify.Write:
-		return "write"
-	case op&fsnotify.Remove == fsnotify.Remove:
-		return "delete"
-	case op&fsnotify.Rename == fsnotify.Rename:
-		return "rename"
-	case op&fsnotify.Chmod == fsnotify.Chmod:
-		return "chmod"
-	default:
-		return "unknown"
+// 確定したファイル操作を記録（作成と書き込みはハッシュ計算と内容検査をしてから記録）
+func (m *Monitor) completeFileOperation(drive Volume, change filewatch.Change) {
+	if change.Operation != filewatch.OPERATION_CREATE && change.Operation != filewatch.OPERATION_WRITE {
+		m.logFileOperation(drive, change, inspection.Result{})
+		return
 	}
+
+	// 書き込みが落ち着く前に確定した場合は検査しない
+	if change.Interrupted {
+		m.logFileOperation(drive, change, m.inspector.Skipped(inspection.STATUS_NOT_SETTLED))
+		return
+	}
+
+	m.inspector.Submit(change.Path, func(result inspection.Result) {
+		m.logFileOperation(drive, change, result)
+	})
 }
 
 // ファイル操作を記録するメソッド
-func (m *Monitor) logFileOperation(op FileOperation) {
+func (m *Monitor) logFileOperation(drive Volume, change filewatch.Change, result inspection.Result) {
 	// コンソールに出力
 	log.Printf(
 		"[%s] Operation: %s User: %s Host: %s Drive: %s Path: %s File: %s Size: %d Time: %s\n",
 		MODULE_NAME,
-		strings.ToUpper(op.Operation),
-		op.UserInfo.UserName,
-		op.UserInfo.HostName,
-		op.DriveLetter,
-		op.FilePath,
-		op.FileName,
-		op.FileSize,
-		op.Timestamp.Format("2006-01-02 15:04:05"),
+		strings.ToUpper(change.Operation),
+		m.userInfo.UserName,
+		m.userInfo.HostName,
+		drive.ID,
+		change.Path,
+		change.FileName(),
+		change.Size,
+		change.Timestamp.Format("2006-01-02 15:04:05"),
 	)
 
+	data := change.EventData(drive.ID, m.userInfo)
+	data["channel"] = FILE_CHANNEL
+	drive.Device.addTo(data)
+	result.AddTo(data)
+
+	// 暗号化方式を判定できたボリュームは基準を下げ、暗号化されていないボリュームへの書き込みのみ重要度を上げる
+	severity := fileOperationSeverity(drive.Encryption)
+	if drive.Encryption != "" {
+		data["encrypted"] = drive.Encryption != ENCRYPTION_NONE
+	}
+	if drive.Encryption == ENCRYPTION_NONE && (change.Operation == filewatch.OPERATION_CREATE || change.Operation == filewatch.OPERATION_WRITE) {
+		severity = raiseUnencryptedSeverity(severity)
+	}
+	severity = m.applyPolicy(drive.Device, severity, data)
+
 	// モジュールのイベントとして追加
-	m.addEvent(
-		"file_"+op.Operation,
-		FILE_OPERATION_SEVERITY,
-		map[string]interface{}{
-			"path":      op.FilePath,
-			"filename":  op.FileName,
-			"drive":     op.DriveLetter,
-			"user":      op.UserInfo.UserName,
-			"host":      op.UserInfo.HostName,
-			"timestamp": op.Timestamp.Format(time.RFC3339),
-			"operation": op.Operation,
-			"size":      op.FileSize,
-		},
-	)
+	m.addEvent("file_"+change.Operation, severity, data)
 }
 
 // 切断されたドライブを検出
-func (m *Monitor) detectDisconnectedDrives(currentDrives map[string]bool, connectedDrives map[string]bool) map[string]bool {
+func (m *Monitor) detectDisconnectedDrives(currentDrives map[string]bool, connectedDrives map[string]Volume) map[string]Volume {
 	// 新しいマップを作成（元のマップを変更しないため）
-	updatedDrives := make(map[string]bool)
+	updatedDrives := make(map[string]Volume)
 	for k, v := range connectedDrives {
 		updatedDrives[k] = v
 	}
 
 	// 切断されたドライブを検出
-	for driveLetter := range connectedDrives {
-		if _, exists := currentDrives[driveLetter]; !exists {
-			log.Printf("[%s] Disconnected drive(%s)\n", MODULE_NAME, driveLetter)
+	for driveName, drive := range connectedDrives {
+		if _, exists := currentDrives[driveName]; !exists {
+			log.Printf("[%s] Disconnected drive(%s)\n", MODULE_NAME, driveName)
 
 			// イベントを生成
+			data := m.driveEventData(drive)
 			m.addEvent(
 				"disconnected_drive",
-				DISCONNECTED_DRIVE_SEVERITY,
-				map[string]interface{}{
-					"drive": driveLetter,
-					"user":  m.userInfo.UserName,
-					"host":  m.userInfo.HostName,
-				},
+				m.applyPolicy(drive.Device, DISCONNECTED_DRIVE_SEVERITY, data),
+				data,
 			)
 
+			// 接続時からのファイル一覧の差分を記録
+			if inventory, ok := m.inventories[driveName]; ok {
+				if m.config.Inventory {
+					inventoryData := m.driveEventData(drive)
+					inventory.addDiffTo(inventoryData, m.config.InventoryManifestLimit)
+					m.addEvent("drive_inventory_diff", DRIVE_INVENTORY_SEVERITY, inventoryData)
+				}
+
+				m.drivesMu.Lock()
+				delete(m.inventories, driveName)
+				m.drivesMu.Unlock()
+			}
+
 			// 関連するゴルーチンを終了させる
-			if cancel, ok := m.watchContexts[driveLetter]; ok {
+			if cancel, ok := m.watchContexts[driveName]; ok {
 				cancel()
-				delete(m.watchContexts, driveLetter)
+				delete(m.watchContexts, driveName)
 			}
 
 			// 更新後のマップから削除
-			delete(updatedDrives, driveLetter)
+			delete(updatedDrives, driveName)
 		}
 	}
 
 	return updatedDrives
 }
 
+// ドライブの接続と切断のイベントデータを作成
+func (m *Monitor) driveEventData(drive Volume) map[string]interface{} {
+	data := map[string]interface{}{
+		"drive":       drive.ID,
+		"mount_path":  drive.MountPath,
+		"file_system": drive.FileSystem,
+		"label":       drive.Label,
+		"uuid":        drive.UUID,
+		"size":        drive.Size,
+		"free_bytes":  drive.FreeBytes,
+		"user":        m.userInfo.UserName,
+		"host":        m.userInfo.HostName,
+	}
+	drive.Device.addTo(data)
+	if drive.Encryption != "" {
+		data["encryption"] = drive.Encryption
+		data["encrypted"] = drive.Encryption != ENCRYPTION_NONE
+	}
+
+	return data
+}
+
+// ポリシーの判定結果をイベントデータに追加して重要度を加減（ポリシーがない場合は元の重要度）
+func (m *Monitor) applyPolicy(device *DeviceInfo, severity int, data map[string]interface{}) int {
+	if m.policy == nil {
+		return severity
+	}
+
+	decision := m.policy.Evaluate(device)
+	data["policy_violation"] = decision.Violation
+	data["policy_reason"] = decision.Reason
+	if decision.Label != "" {
+		data["policy_label"] = decision.Label
+	}
+
+	if decision.Violation {
+		return min(severity+POLICY_VIOLATION_INCREASE, MAX_SEVERITY)
+	}
+
+	return max(severity-APPROVED_DEVICE_DECREASE, MIN_SEVERITY)
+}
+
 // モニタリングを停止
-func (m *Monitor) Stop() error {
+func (m *Monitor) Stop(ctx context.Context) error {
+	m.lifecycleMu.Lock()
+	cancel := m.cancel
+	m.cancel = nil
+	m.lifecycleMu.Unlock()
+
+	// 停止済みの場合は何もしない
+	if cancel == nil {
+		return nil
+	}
+
 	log.Printf("[%s] Stop...", MODULE_NAME)
 
-	// すべての監視コンテキストを終了
-	for _, cancel := range m.watchContexts {
-		cancel()
+	// ドライブ監視とすべてのファイル監視を終了
+	cancel()
+
+	// 監視ゴルーチンの終了を待機
+	if err := module.Wait(ctx, &m.wg); err != nil {
+		return fmt.Errorf("%s did not stop: %w", MODULE_NAME, err)
 	}
 
-	close(m.stopChan)
+	m.health.SetState(module.StateStopped)
 
 	return nil
 }
 
+// モジュールの稼働状況を取得
+func (m *Monitor) Health() module.Health {
+	return m.health.Snapshot()
+}
+
 // モジュールが検出したイベントを取得
 func (m *Monitor) GetEvents() []module.Event {
 	m.eventsMu.RLock()
@@ -450,6 +661,8 @@ func (m *Monitor) addEvent(eventType string, severity int, data map[string]inter
 	m.events = append(m.events, event)
 	m.eventsMu.Unlock()
 
+	m.health.RecordEvent()
+
 	log.Printf("[%s] Detection new event: %s Importance: %d\n", MODULE_NAME, eventType, severity)
 
 	// イベントをsenderに送信（非同期）
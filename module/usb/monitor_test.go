@@ -0,0 +1,131 @@
+package usb
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestScanDrivesConnectAndDisconnect(t *testing.T) {
+	stick := Volume{ID: "sdb1", MountPath: t.TempDir(), Removable: true, FileSystem: "vfat", Label: "STICK"}
+	card := Volume{ID: "mmcblk0p1", MountPath: t.TempDir(), Removable: true, FileSystem: "exfat"}
+	internal := Volume{ID: "sda1", MountPath: t.TempDir(), FileSystem: "ext4"}
+
+	enumerator := newFakeDriveEnumerator(internal, stick)
+	monitor, ctx := newTestMonitor(t, nil, enumerator)
+
+	steps := []struct {
+		name      string
+		change    func()
+		want      []string // このスキャンで追加されるイベント
+		connected []string
+	}{
+		{
+			name:      "initial scan ignores non-removable volumes",
+			change:    func() {},
+			want:      []string{"connected_drive"},
+			connected: []string{"sdb1"},
+		},
+		{
+			name:      "rescan without changes emits nothing",
+			change:    func() {},
+			connected: []string{"sdb1"},
+		},
+		{
+			name:      "second drive connects",
+			change:    func() { enumerator.Attach(card) },
+			want:      []string{"connected_drive"},
+			connected: []string{"mmcblk0p1", "sdb1"},
+		},
+		{
+			name:      "enumeration failure keeps connected drives",
+			change:    func() { enumerator.SetError(errors.New("mountinfo unavailable")) },
+			connected: []string{"mmcblk0p1", "sdb1"},
+		},
+		{
+			name: "first drive disconnects",
+			change: func() {
+				enumerator.SetError(nil)
+				enumerator.Detach("sdb1")
+			},
+			want:      []string{"disconnected_drive"},
+			connected: []string{"mmcblk0p1"},
+		},
+		{
+			name:      "drive reconnects under the same id",
+			change:    func() { enumerator.Attach(stick) },
+			want:      []string{"connected_drive"},
+			connected: []string{"mmcblk0p1", "sdb1"},
+		},
+	}
+
+	for _, step := range steps {
+		before := len(monitor.GetEvents())
+
+		step.change()
+		monitor.scanDrives(ctx)
+
+		if got := eventTypes(monitor)[before:]; !slices.Equal(got, step.want) {
+			t.Fatalf("%s: events = %v, want %v", step.name, got, step.want)
+		}
+
+		monitor.drivesMu.RLock()
+		var connected []string
+		for _, id := range []string{"mmcblk0p1", "sda1", "sdb1"} {
+			if _, ok := monitor.connectedDrives[id]; ok {
+				connected = append(connected, id)
+			}
+		}
+		monitor.drivesMu.RUnlock()
+
+		if !slices.Equal(connected, step.connected) {
+			t.Fatalf("%s: connected drives = %v, want %v", step.name, connected, step.connected)
+		}
+		if len(monitor.watchContexts) != len(step.connected) {
+			t.Fatalf("%s: %d file watches, want %d", step.name, len(monitor.watchContexts), len(step.connected))
+		}
+	}
+}
+
+func TestScanDrivesEventData(t *testing.T) {
+	device := &DeviceInfo{VendorID: "0781", ProductID: "5581", Serial: "4C530001", BusPath: "2-1"}
+	stick := Volume{ID: "sdb1", MountPath: t.TempDir(), Removable: true, FileSystem: "vfat", Label: "STICK", UUID: "1A2B-3C4D", Encryption: ENCRYPTION_NONE, Device: device}
+
+	enumerator := newFakeDriveEnumerator(stick)
+	monitor, ctx := newTestMonitor(t, nil, enumerator)
+
+	monitor.scanDrives(ctx)
+	enumerator.Detach(stick.ID)
+	monitor.scanDrives(ctx)
+
+	events := monitor.GetEvents()
+	if len(events) != 2 {
+		t.Fatalf("got %d events, want 2: %v", len(events), eventTypes(monitor))
+	}
+
+	for _, event := range events {
+		if event.Severity != CONNECTED_DRIVE_SEVERITY {
+			t.Errorf("%s severity = %d, want %d", event.Type, event.Severity, CONNECTED_DRIVE_SEVERITY)
+		}
+
+		want := map[string]interface{}{
+			"drive":       "sdb1",
+			"mount_path":  stick.MountPath,
+			"file_system": "vfat",
+			"label":       "STICK",
+			"uuid":        "1A2B-3C4D",
+			"vendor_id":   "0781",
+			"serial":      "4C530001",
+			"bus_path":    "2-1",
+			"user":        "alice",
+			"host":        "workstation",
+			"encryption":  ENCRYPTION_NONE,
+			"encrypted":   false,
+		}
+		for key, value := range want {
+			if event.Data[key] != value {
+				t.Errorf("%s %s = %v, want %v", event.Type, key, event.Data[key], value)
+			}
+		}
+	}
+}
@@ -0,0 +1,224 @@
+package usb
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ポリシーを設定した場合はイベントの重要度をこれらの値だけ加減（暗号化などによる重要度の差は維持）
+const (
+	APPROVED_DEVICE_DECREASE  = 2 // ポリシーで承認されたデバイスのイベントで下げる重要度
+	POLICY_VIOLATION_INCREASE = 1 // ポリシーで承認されていないデバイスのイベントで上げる重要度
+	MIN_SEVERITY              = 1
+)
+
+// デバイスを識別するポリシーのエントリの構造体（空の項目はすべてに一致、"*"や"?"のワイルドカードを使用可能）
+type PolicyEntry struct {
+	VendorID  string `json:"vendor_id"`
+	ProductID string `json:"product_id"`
+	Serial    string `json:"serial"`
+	Label     string `json:"label"`
+}
+
+// USBデバイスの許可リストと拒否リストの構造体
+type Policy struct {
+	Approved []PolicyEntry `json:"approved"` // 空の場合は拒否リスト以外のデバイスをすべて承認
+	Blocked  []PolicyEntry `json:"blocked"`
+}
+
+// ポリシーの判定結果の構造体
+type PolicyDecision struct {
+	Violation bool
+	Label     string
+	Reason    string // "approved" "blocked" "not_approved" "unidentified"
+}
+
+// ポリシーファイルを読み込み
+func LoadPolicy(path string) (*Policy, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var policy Policy
+	if err := json.Unmarshal(data, &policy); err != nil {
+		return nil, fmt.Errorf("failed parsing policy %s: %w", path, err)
+	}
+
+	for _, entry := range append(policy.Approved, policy.Blocked...) {
+		if err := entry.validate(); err != nil {
+			return nil, fmt.Errorf("invalid policy %s: %w", path, err)
+		}
+	}
+
+	return &policy, nil
+}
+
+// デバイスをポリシーに照らして判定
+func (p *Policy) Evaluate(device *DeviceInfo) PolicyDecision {
+	// 識別情報がないデバイスは許可リストがある場合は承認しない
+	if device == nil {
+		if len(p.Approved) > 0 {
+			return PolicyDecision{Violation: true, Reason: "unidentified"}
+		}
+		return PolicyDecision{Reason: "approved"}
+	}
+
+	// 拒否リストを優先
+	for _, entry := range p.Blocked {
+		if entry.matches(device) {
+			return PolicyDecision{Violation: true, Label: entry.Label, Reason: "blocked"}
+		}
+	}
+
+	if len(p.Approved) == 0 {
+		return PolicyDecision{Reason: "approved"}
+	}
+
+	for _, entry := range p.Approved {
+		if entry.matches(device) {
+			return PolicyDecision{Label: entry.Label, Reason: "approved"}
+		}
+	}
+
+	return PolicyDecision{Violation: true, Reason: "not_approved"}
+}
+
+// エントリのパターンが正しいかどうかを確認
+func (e PolicyEntry) validate() error {
+	for _, pattern := range []string{e.VendorID, e.ProductID, e.Serial} {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("malformed pattern %q", pattern)
+		}
+	}
+
+	return nil
+}
+
+// デバイスがエントリに一致するかどうかを確認
+func (e PolicyEntry) matches(device *DeviceInfo) bool {
+	return matchPattern(e.VendorID, device.VendorID) &&
+		matchPattern(e.ProductID, device.ProductID) &&
+		matchPattern(e.Serial, device.Serial)
+}
+
+// 大文字と小文字を区別せずにワイルドカードで比較
+func matchPattern(pattern string, value string) bool {
+	if pattern == "" {
+		return true
+	}
+
+	matched, err := path.Match(strings.ToLower(pattern), strings.ToLower(value))
+	return err == nil && matched
+}
+
+// ポリシーファイルを保持し、変更があれば読み込み直す構造体
+type PolicyStore struct {
+	path    string
+	mu      sync.RWMutex
+	policy  *Policy
+	modTime time.Time
+}
+
+// ポリシーファイルを読み込んで新しいPolicyStoreを作成
+func NewPolicyStore(path string) (*PolicyStore, error) {
+	store := &PolicyStore{path: path}
+	if err := store.Reload(); err != nil {
+		return nil, err
+	}
+
+	return store, nil
+}
+
+// ポリシーファイルを読み込み直す（失敗した場合は以前のポリシーを維持）
+func (s *PolicyStore) Reload() error {
+	info, err := os.Stat(s.path)
+	if err != nil {
+		return err
+	}
+
+	policy, err := LoadPolicy(s.path)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	s.policy = policy
+	s.modTime = info.ModTime()
+	s.mu.Unlock()
+
+	log.Printf("[%s] Loaded device policy %s (approved: %d, blocked: %d)\n", MODULE_NAME, s.path, len(policy.Approved), len(policy.Blocked))
+
+	return nil
+}
+
+// ポリシーファイルの更新日時が変わったかどうかを確認
+func (s *PolicyStore) Changed() bool {
+	info, err := os.Stat(s.path)
+	if err != nil {
+		return false
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return !info.ModTime().Equal(s.modTime)
+}
+
+// ポリシーファイルの更新日時が変わっていれば読み込み直す（読み込み直した場合はtrue）
+func (s *PolicyStore) ReloadIfChanged() (bool, error) {
+	info, err := os.Stat(s.path)
+	if err != nil {
+		return false, err
+	}
+
+	s.mu.RLock()
+	changed := !info.ModTime().Equal(s.modTime)
+	s.mu.RUnlock()
+
+	if !changed {
+		return false, nil
+	}
+
+	if err := s.Reload(); err != nil {
+		// 同じ内容で失敗を繰り返さないよう、次の更新まで読み込みを見送る
+		s.mu.Lock()
+		s.modTime = info.ModTime()
+		s.mu.Unlock()
+		return false, err
+	}
+
+	return true, nil
+}
+
+// 現在のポリシーでデバイスを判定
+func (s *PolicyStore) Evaluate(device *DeviceInfo) PolicyDecision {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.policy.Evaluate(device)
+}
+
+// ポリシーファイルが更新されていれば読み込み直す
+func (m *Monitor) reloadPolicy() {
+	if m.policy == nil {
+		return
+	}
+
+	reloaded, err := m.policy.ReloadIfChanged()
+	if err != nil {
+		log.Printf("[%s] Failed reloading device policy, keeping previous policy: %v\n", MODULE_NAME, err)
+		m.health.RecordError(err)
+	}
+
+	// 接続中のデバイスは続くスキャンで新しいポリシーに照らして強制する
+	if reloaded && m.enforcementEnabled() {
+		log.Printf("[%s] Re-evaluating connected devices against reloaded policy\n", MODULE_NAME)
+	}
+}
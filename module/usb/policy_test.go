@@ -0,0 +1,142 @@
+package usb
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/mniyk/endpoint-security-and-monitoring-tools/internal/config"
+	"github.com/mniyk/endpoint-security-and-monitoring-tools/internal/filewatch"
+	"github.com/mniyk/endpoint-security-and-monitoring-tools/internal/inspection"
+)
+
+func TestPolicyEvaluate(t *testing.T) {
+	policy := &Policy{
+		Approved: []PolicyEntry{
+			{VendorID: "0781", ProductID: "55*", Label: "corporate sandisk"},
+			{VendorID: "0951", Serial: "ABC?", Label: "kingston vault"},
+		},
+		Blocked: []PolicyEntry{
+			{VendorID: "0781", ProductID: "5581", Serial: "BAD*", Label: "lost stick"},
+		},
+	}
+
+	tests := []struct {
+		name      string
+		device    *DeviceInfo
+		violation bool
+		reason    string
+		label     string
+	}{
+		{"approved by wildcard", &DeviceInfo{VendorID: "0781", ProductID: "5583"}, false, "approved", "corporate sandisk"},
+		{"case insensitive", &DeviceInfo{VendorID: "0951", Serial: "abc1"}, false, "approved", "kingston vault"},
+		{"blocked wins over approved", &DeviceInfo{VendorID: "0781", ProductID: "5581", Serial: "BAD01"}, true, "blocked", "lost stick"},
+		{"not listed", &DeviceInfo{VendorID: "1234", ProductID: "5678"}, true, "not_approved", ""},
+		{"unidentified", nil, true, "unidentified", ""},
+	}
+
+	for _, tt := range tests {
+		decision := policy.Evaluate(tt.device)
+		if decision.Violation != tt.violation || decision.Reason != tt.reason || decision.Label != tt.label {
+			t.Errorf("%s: got %+v, want violation %v reason %q label %q", tt.name, decision, tt.violation, tt.reason, tt.label)
+		}
+	}
+}
+
+func TestPolicyStoreReloadIfChanged(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "policy.json")
+	writePolicy := func(content string, modTime time.Time) {
+		t.Helper()
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(path, modTime, modTime); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	started := time.Now().Add(-time.Hour)
+	writePolicy(`{"approved": [{"vendor_id": "0781"}]}`, started)
+
+	store, err := NewPolicyStore(path)
+	if err != nil {
+		t.Fatalf("NewPolicyStore: %v", err)
+	}
+
+	device := &DeviceInfo{VendorID: "0951"}
+	if !store.Evaluate(device).Violation {
+		t.Fatal("device not on the allowlist was approved")
+	}
+
+	if reloaded, err := store.ReloadIfChanged(); reloaded || err != nil {
+		t.Fatalf("unchanged policy: reloaded %v, err %v", reloaded, err)
+	}
+
+	writePolicy(`{"approved": [{"vendor_id": "0781"}, {"vendor_id": "0951"}]}`, started.Add(time.Minute))
+	if reloaded, err := store.ReloadIfChanged(); !reloaded || err != nil {
+		t.Fatalf("changed policy: reloaded %v, err %v", reloaded, err)
+	}
+	if store.Evaluate(device).Violation {
+		t.Fatal("reloaded policy not applied")
+	}
+
+	// 壊れたファイルは以前のポリシーを維持し、次の更新まで読み込み直さない
+	writePolicy(`{"approved": [`, started.Add(2*time.Minute))
+	if reloaded, err := store.ReloadIfChanged(); reloaded || err == nil {
+		t.Fatalf("malformed policy: reloaded %v, err %v", reloaded, err)
+	}
+	if reloaded, err := store.ReloadIfChanged(); reloaded || err != nil {
+		t.Fatalf("malformed policy retried: reloaded %v, err %v", reloaded, err)
+	}
+	if store.Evaluate(device).Violation {
+		t.Fatal("previous policy not kept after a failed reload")
+	}
+}
+
+func TestPolicySeverity(t *testing.T) {
+	if !DEVICE_IDENTITY_SUPPORTED {
+		t.Skip("device policy is not supported on this platform")
+	}
+
+	path := filepath.Join(t.TempDir(), "policy.json")
+	if err := os.WriteFile(path, []byte(`{"approved": [{"vendor_id": "0781"}]}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	monitorConfig, err := NewMonitorConfig(config.Config{Options: map[string]interface{}{"policy_file": path}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	approved := &DeviceInfo{VendorID: "0781"}
+	unapproved := &DeviceInfo{VendorID: "1234"}
+
+	// 暗号化による重要度の差を維持したままポリシーで加減
+	tests := []struct {
+		name       string
+		device     *DeviceInfo
+		encryption string
+		operation  string
+		want       int
+	}{
+		{"approved encrypted write", approved, ENCRYPTION_LUKS, filewatch.OPERATION_WRITE, ASSESSED_FILE_OPERATION_SEVERITY - APPROVED_DEVICE_DECREASE},
+		{"approved unencrypted write", approved, ENCRYPTION_NONE, filewatch.OPERATION_CREATE, MAX_SEVERITY - APPROVED_DEVICE_DECREASE},
+		{"approved unknown encryption", approved, "", filewatch.OPERATION_WRITE, FILE_OPERATION_SEVERITY - APPROVED_DEVICE_DECREASE},
+		{"unapproved encrypted write", unapproved, ENCRYPTION_LUKS, filewatch.OPERATION_WRITE, ASSESSED_FILE_OPERATION_SEVERITY + POLICY_VIOLATION_INCREASE},
+		{"unapproved unencrypted write", unapproved, ENCRYPTION_NONE, filewatch.OPERATION_CREATE, MAX_SEVERITY},
+		{"unidentified encrypted write", nil, ENCRYPTION_LUKS, filewatch.OPERATION_WRITE, ASSESSED_FILE_OPERATION_SEVERITY + POLICY_VIOLATION_INCREASE},
+	}
+
+	for _, tt := range tests {
+		monitor, _ := newTestMonitor(t, monitorConfig, newFakeDriveEnumerator())
+		monitor.logFileOperation(Volume{ID: "sdb1", Encryption: tt.encryption, Device: tt.device}, filewatch.Change{
+			Operation: tt.operation,
+			Path:      "/media/usb/file.txt",
+		}, inspection.Result{})
+
+		events := monitor.GetEvents()
+		if len(events) != 1 || events[0].Severity != tt.want {
+			t.Errorf("%s: got %+v, want severity %d", tt.name, events, tt.want)
+		}
+	}
+}
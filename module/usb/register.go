@@ -0,0 +1,51 @@
+package usb
+
+import (
+	"github.com/mniyk/endpoint-security-and-monitoring-tools/internal/config"
+	"github.com/mniyk/endpoint-security-and-monitoring-tools/internal/inspection"
+	"github.com/mniyk/endpoint-security-and-monitoring-tools/module"
+)
+
+const REGISTRY_NAME = "usb_file_transfer_monitoring"
+
+// モジュールをレジストリに登録
+func init() {
+	module.Register(module.Descriptor{
+		Name:        REGISTRY_NAME,
+		Description: "Tracking of file transfers to USB devices",
+		Options: append([]module.OptionSpec{
+			{Name: "sysfs_root", Type: "string", Default: DEFAULT_SYSFS_ROOT, Description: "Root of sysfs on Linux"},
+			{Name: "procfs_root", Type: "string", Default: DEFAULT_PROCFS_ROOT, Description: "Root of procfs on Linux"},
+			{Name: "devfs_root", Type: "string", Default: DEFAULT_DEVFS_ROOT, Description: "Root of /dev on Linux"},
+			{Name: "hotplug", Type: "bool", Default: HOTPLUG_SUPPORTED, Description: "Detect drives immediately from kernel uevents (Linux only, defaults to false elsewhere)"},
+			{Name: "file_backend", Type: "string", Default: FILE_BACKEND_FSNOTIFY, Description: "File event source: fsnotify, fanotify (Linux, reports the writing process) or auto"},
+			{Name: "watch_max_depth", Type: "number", Default: DEFAULT_WATCH_MAX_DEPTH, Description: "Directories deeper than this are scanned periodically instead of watched (0 is unlimited)"},
+			{Name: "watch_max_directories", Type: "number", Default: DEFAULT_WATCH_MAX_DIRECTORIES, Description: "Maximum watched directories per drive, the rest are scanned periodically (0 is unlimited)"},
+			{Name: "watch_scan_interval", Type: "number", Default: DEFAULT_WATCH_SCAN_INTERVAL, Description: "Seconds between scans of directories that are not watched"},
+			{Name: "include_patterns", Type: "array", Default: []string{}, Description: "Only report files whose name or drive-relative path matches one of these globs (empty reports all)"},
+			{Name: "exclude_patterns", Type: "array", Default: []string{}, Description: "Do not report files whose name, parent directory or drive-relative path matches one of these globs"},
+			{Name: "include_extensions", Type: "array", Default: []string{}, Description: "Only report files with these extensions (empty reports all)"},
+			{Name: "exclude_extensions", Type: "array", Default: []string{}, Description: "Do not report files with these extensions"},
+			{Name: "default_excludes", Type: "bool", Default: true, Description: "Also exclude OS metadata and lock files such as ~$*, .DS_Store, Thumbs.db and System Volume Information"},
+			{Name: "policy_file", Type: "string", Default: "", Description: "JSON file listing approved and blocked devices by VID/PID/serial, reloaded when it changes. When set, events from approved devices are lowered by 2 and events from unapproved devices are raised by 1 (Linux only)"},
+			{Name: "enforcement", Type: "string", Default: ENFORCEMENT_OFF, Description: "Enforcement for unapproved devices on Linux: off, audit or block"},
+			{Name: "enforcement_action", Type: "string", Default: ENFORCEMENT_DEAUTHORIZE, Description: "Enforcement action: deauthorize or read_only"},
+			{Name: "override_file", Type: "string", Default: "", Description: "Enforcement is suspended while this file exists"},
+			{Name: "inventory", Type: "bool", Default: false, Description: "Record the files on a drive when connected and what changed when disconnected"},
+			{Name: "inventory_manifest_limit", Type: "number", Default: DEFAULT_INVENTORY_MANIFEST_LIMIT, Description: "Maximum number of files listed in inventory events"},
+			{Name: "import_directories", Type: "array", Default: []string{}, Description: "Host directories watched for files copied from removable drives"},
+			{Name: "import_read_tracking", Type: "bool", Default: true, Description: "Attribute imports to the reading process via fanotify on Linux"},
+		}, inspection.Options()...),
+		Platforms: []string{"windows", "linux"},
+		Factory:   newModule,
+	})
+}
+
+// 設定からモジュールを生成
+func newModule(moduleConfig config.Config, deps module.Dependencies) (module.Module, error) {
+	monitorConfig, err := NewMonitorConfig(moduleConfig)
+	if err != nil {
+		return nil, err
+	}
+	return NewMonitor(monitorConfig, NewDriveEnumerator(monitorConfig), deps.UserInfo, deps.EventSink), nil
+}
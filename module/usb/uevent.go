@@ -0,0 +1,86 @@
+package usb
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// udevデーモンが再送信したメッセージ（カーネルのメッセージではない）
+var ErrUdevMessage = errors.New("uevent: message from udev daemon, not kernel")
+
+// カーネルから通知されたデバイスイベントの構造体
+type Uevent struct {
+	Action    string // "add" "remove" "change" "bind" など
+	DevPath   string // /sys からの相対パス（例: /devices/.../block/sdb/sdb1）
+	Subsystem string // "block" "usb" など
+	DevType   string // "disk" "partition" "usb_device" など
+	DevName   string // /dev からの相対パス（例: sdb1）
+	Env       map[string]string
+}
+
+// NETLINK_KOBJECT_UEVENTのメッセージを解析
+// 例: "add@/devices/...\x00ACTION=add\x00DEVPATH=/devices/...\x00SUBSYSTEM=block\x00"
+func ParseUevent(message []byte) (Uevent, error) {
+	if bytes.HasPrefix(message, []byte("libudev\x00")) {
+		return Uevent{}, ErrUdevMessage
+	}
+
+	fields := bytes.Split(bytes.TrimRight(message, "\x00"), []byte{0})
+	if len(fields) == 0 || len(fields[0]) == 0 {
+		return Uevent{}, errors.New("uevent: empty message")
+	}
+
+	// ヘッダー "ACTION@DEVPATH" を解析
+	header := string(fields[0])
+	action, devPath, ok := strings.Cut(header, "@")
+	if !ok || action == "" || devPath == "" {
+		return Uevent{}, fmt.Errorf("uevent: malformed header %q", header)
+	}
+
+	event := Uevent{
+		Action:  action,
+		DevPath: devPath,
+		Env:     make(map[string]string),
+	}
+
+	// "KEY=VALUE" の環境変数を解析
+	for _, field := range fields[1:] {
+		key, value, ok := strings.Cut(string(field), "=")
+		if !ok || key == "" {
+			continue
+		}
+		event.Env[key] = value
+	}
+
+	if value, ok := event.Env["ACTION"]; ok {
+		event.Action = value
+	}
+	if value, ok := event.Env["DEVPATH"]; ok {
+		event.DevPath = value
+	}
+	event.Subsystem = event.Env["SUBSYSTEM"]
+	event.DevType = event.Env["DEVTYPE"]
+	event.DevName = event.Env["DEVNAME"]
+
+	return event, nil
+}
+
+// ドライブの再スキャンが必要なイベントかどうかを確認
+func (e Uevent) AffectsDrives() bool {
+	switch e.Action {
+	case "add", "remove", "change":
+	default:
+		return false
+	}
+
+	switch e.Subsystem {
+	case "block":
+		return true
+	case "usb":
+		return e.DevType == "usb_device"
+	default:
+		return false
+	}
+}
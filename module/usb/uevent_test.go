@@ -0,0 +1,122 @@
+package usb
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// testdata/uevent のフィクスチャはNETLINK_KOBJECT_UEVENTで受信したメッセージ（NUL区切り）
+const (
+	stickDevPath = "/devices/pci0000:00/0000:00:14.0/usb2/2-1"
+	stickBlock   = stickDevPath + "/2-1:1.0/host6/target6:0:0/6:0:0:0/block/sdb"
+)
+
+func TestParseUeventFixtures(t *testing.T) {
+	tests := []struct {
+		fixture       string
+		action        string
+		devPath       string
+		subsystem     string
+		devType       string
+		devName       string
+		affectsDrives bool
+	}{
+		{"usb_device_add", "add", stickDevPath, "usb", "usb_device", "bus/usb/002/002", true},
+		{"usb_interface_add", "add", stickDevPath + "/2-1:1.0", "usb", "usb_interface", "", false},
+		{"usb_device_bind", "bind", stickDevPath, "usb", "usb_device", "bus/usb/002/002", false},
+		{"scsi_device_add", "add", stickDevPath + "/2-1:1.0/host6/target6:0:0/6:0:0:0", "scsi", "scsi_device", "", false},
+		{"block_disk_add", "add", stickBlock, "block", "disk", "sdb", true},
+		{"block_partition_add", "add", stickBlock + "/sdb1", "block", "partition", "sdb1", true},
+		{"block_disk_change", "change", stickBlock, "block", "disk", "sdb", true},
+		{"block_partition_remove", "remove", stickBlock + "/sdb1", "block", "partition", "sdb1", true},
+		{"usb_device_remove", "remove", stickDevPath, "usb", "usb_device", "bus/usb/002/002", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.fixture, func(t *testing.T) {
+			message, err := os.ReadFile(filepath.Join("testdata", "uevent", tt.fixture+".bin"))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			event, err := ParseUevent(message)
+			if err != nil {
+				t.Fatalf("ParseUevent: %v", err)
+			}
+
+			if event.Action != tt.action {
+				t.Errorf("Action = %q, want %q", event.Action, tt.action)
+			}
+			if event.DevPath != tt.devPath {
+				t.Errorf("DevPath = %q, want %q", event.DevPath, tt.devPath)
+			}
+			if event.Subsystem != tt.subsystem {
+				t.Errorf("Subsystem = %q, want %q", event.Subsystem, tt.subsystem)
+			}
+			if event.DevType != tt.devType {
+				t.Errorf("DevType = %q, want %q", event.DevType, tt.devType)
+			}
+			if event.DevName != tt.devName {
+				t.Errorf("DevName = %q, want %q", event.DevName, tt.devName)
+			}
+			if got := event.AffectsDrives(); got != tt.affectsDrives {
+				t.Errorf("AffectsDrives() = %v, want %v", got, tt.affectsDrives)
+			}
+			if event.Env["SEQNUM"] == "" {
+				t.Errorf("SEQNUM missing from Env: %v", event.Env)
+			}
+		})
+	}
+}
+
+func TestParseUeventUdevMessage(t *testing.T) {
+	message, err := os.ReadFile(filepath.Join("testdata", "uevent", "libudev_block_add.bin"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := ParseUevent(message); !errors.Is(err, ErrUdevMessage) {
+		t.Fatalf("ParseUevent error = %v, want ErrUdevMessage", err)
+	}
+}
+
+func TestParseUeventMalformed(t *testing.T) {
+	tests := []struct {
+		name    string
+		message string
+	}{
+		{"empty", ""},
+		{"only terminators", "\x00\x00"},
+		{"no at sign", "add/devices/foo\x00ACTION=add\x00"},
+		{"missing action", "@/devices/foo\x00"},
+		{"missing devpath", "add@\x00ACTION=add\x00"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ParseUevent([]byte(tt.message)); err == nil {
+				t.Fatalf("ParseUevent(%q) succeeded, want error", tt.message)
+			}
+		})
+	}
+}
+
+func TestParseUeventHeaderOnly(t *testing.T) {
+	// 環境変数がない場合はヘッダーの操作とパスを使用し、不正な環境変数は無視する
+	event, err := ParseUevent([]byte("remove@/devices/virtual/block/loop0\x00garbage\x00=novalue\x00"))
+	if err != nil {
+		t.Fatalf("ParseUevent: %v", err)
+	}
+
+	if event.Action != "remove" || event.DevPath != "/devices/virtual/block/loop0" {
+		t.Errorf("got action %q devpath %q", event.Action, event.DevPath)
+	}
+	if len(event.Env) != 0 {
+		t.Errorf("Env = %v, want empty", event.Env)
+	}
+	if event.AffectsDrives() {
+		t.Error("AffectsDrives() = true for event without subsystem")
+	}
+}
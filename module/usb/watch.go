@@ -0,0 +1,65 @@
+package usb
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/mniyk/endpoint-security-and-monitoring-tools/internal/filewatch"
+)
+
+const (
+	DEFAULT_WATCH_MAX_DEPTH       = 10
+	DEFAULT_WATCH_MAX_DIRECTORIES = 8192
+	DEFAULT_WATCH_SCAN_INTERVAL   = 60 // 秒
+)
+
+// 監視の上限の設定を検証
+func validateWatchLimits(config MonitorConfig) error {
+	if config.WatchMaxDepth < 0 {
+		return fmt.Errorf("watch_max_depth must not be negative: %d", config.WatchMaxDepth)
+	}
+	if config.WatchMaxDirectories < 0 {
+		return fmt.Errorf("watch_max_directories must not be negative: %d", config.WatchMaxDirectories)
+	}
+	if config.WatchScanInterval <= 0 {
+		return fmt.Errorf("watch_scan_interval must be positive: %d", config.WatchScanInterval)
+	}
+
+	return nil
+}
+
+// ディレクトリの監視を作成し、稼働状況の集計に登録
+func (m *Monitor) startWatch(id string) *filewatch.Watcher {
+	watcher := filewatch.NewWatcher(MODULE_NAME, filewatch.Limits{
+		MaxDepth:       m.config.WatchMaxDepth,
+		MaxDirectories: m.config.WatchMaxDirectories,
+		ScanInterval:   time.Duration(m.config.WatchScanInterval) * time.Second,
+	}, m.health)
+
+	m.drivesMu.Lock()
+	m.watches[id] = watcher
+	m.drivesMu.Unlock()
+
+	return watcher
+}
+
+// 監視の終了を稼働状況の集計に反映
+func (m *Monitor) stopWatch(id string) {
+	m.drivesMu.Lock()
+	delete(m.watches, id)
+	m.drivesMu.Unlock()
+	m.updateWatchCoverage()
+}
+
+// すべての監視の対象範囲を稼働状況に記録
+func (m *Monitor) updateWatchCoverage() {
+	var total filewatch.Coverage
+
+	m.drivesMu.RLock()
+	for _, watch := range m.watches {
+		total.Add(watch.Coverage())
+	}
+	m.drivesMu.RUnlock()
+
+	total.SetDetails(m.health)
+}